@@ -0,0 +1,131 @@
+// Package cell defines hierarchical cell systems used to preindex locations.
+//
+// A cell system splits the earth into a rectilinear grid of cells at every
+// level, finer levels nested inside coarser ones. [MapTile], OSM style Web
+// Mercator tiles, is the default and only covers to ~85° latitude.
+// [Geohash] and [EqualArea] cover the whole globe including poles.
+package cell
+
+import (
+	"fmt"
+
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/paulmach/orb"
+)
+
+// Cell is a grid cell at level Z, X grows to east.
+type Cell struct {
+	X uint32
+	Y uint32
+	Z uint32
+}
+
+// System is a hierarchical cell system.
+type System interface {
+	// Kind is the value recorded in [pb.PreindexLocations].
+	Kind() pb.CellSystem
+	// Size returns columns and rows count at level z.
+	Size(z uint32) (uint32, uint32)
+	// At returns the cell contains point at level z.
+	At(p orb.Point, z uint32) Cell
+	// Bound returns cell's lng/lat bound.
+	Bound(c Cell) orb.Bound
+	// Parent returns the cell at level z-1 contains c.
+	Parent(c Cell) Cell
+	// Children returns cells at level z+1 inside c.
+	Children(c Cell) []Cell
+	// Cover returns cells at level z cover the polygon, cells may include
+	// some area outside polygon.
+	Cover(poly orb.Polygon, z uint32) ([]Cell, error)
+}
+
+// FromKind returns the cell system of kind.
+func FromKind(kind pb.CellSystem) (System, error) {
+	switch kind {
+	case pb.CellSystem_MapTile:
+		return MapTile{}, nil
+	case pb.CellSystem_Geohash:
+		return Geohash{}, nil
+	case pb.CellSystem_EqualArea:
+		return EqualArea{}, nil
+	default:
+		return nil, fmt.Errorf("pinpoint/cell: unknown cell system %v", kind)
+	}
+}
+
+// Neighbors returns the 8 cells around c at same level.
+func Neighbors(c Cell) []Cell {
+	return []Cell{
+		{c.X - 1, c.Y - 1, c.Z},
+		{c.X, c.Y - 1, c.Z},
+		{c.X + 1, c.Y - 1, c.Z},
+
+		{c.X - 1, c.Y, c.Z},
+		{c.X + 1, c.Y, c.Z},
+
+		{c.X - 1, c.Y + 1, c.Z},
+		{c.X, c.Y + 1, c.Z},
+		{c.X + 1, c.Y + 1, c.Z},
+	}
+}
+
+// MergeUp merges cells into parent when all children are in cells, up to
+// level min. All input cells must have same level.
+//
+// It works like [github.com/paulmach/orb/maptile/tilecover.MergeUp] for any
+// cell system.
+func MergeUp(s System, cells map[Cell]bool, min uint32) map[Cell]bool {
+	merged := map[Cell]bool{}
+	if len(cells) == 0 {
+		return merged
+	}
+	current := map[Cell]bool{}
+	max := uint32(0)
+	for c := range cells {
+		current[c] = true
+		max = c.Z
+	}
+	if max <= min {
+		return current
+	}
+
+	for z := max; z > min; z-- {
+		parents := map[Cell]bool{}
+		for c := range current {
+			if !current[c] {
+				continue
+			}
+			parent := s.Parent(c)
+			siblings := s.Children(parent)
+			all := true
+			for _, sibling := range siblings {
+				if !current[sibling] {
+					all = false
+					break
+				}
+			}
+			if all {
+				for _, sibling := range siblings {
+					delete(current, sibling)
+				}
+				if z-1 == min {
+					merged[parent] = true
+				} else {
+					parents[parent] = true
+				}
+				continue
+			}
+			for _, sibling := range siblings {
+				if current[sibling] {
+					merged[sibling] = true
+					delete(current, sibling)
+				}
+			}
+		}
+		current = parents
+	}
+	for c := range current {
+		merged[c] = true
+	}
+	return merged
+}
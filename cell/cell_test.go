@@ -0,0 +1,111 @@
+package cell_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deslittle/pinpoint/cell"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile/tilecover"
+)
+
+var systems = []cell.System{cell.MapTile{}, cell.Geohash{}, cell.EqualArea{}}
+
+func TestAtInsideBound(t *testing.T) {
+	points := []orb.Point{
+		{-74.0344, 40.7157},
+		{116.3883, 39.9289},
+		{-179.9, -84.9},
+		{179.9, 84.9},
+		{0, 0},
+	}
+	for _, s := range systems {
+		for _, p := range points {
+			for z := uint32(0); z <= 20; z++ {
+				c := s.At(p, z)
+				b := s.Bound(c)
+				const eps = 1e-9
+				if p.Lon() < b.Min.Lon()-eps || p.Lon() > b.Max.Lon()+eps || p.Lat() < b.Min.Lat()-eps || p.Lat() > b.Max.Lat()+eps {
+					t.Errorf("%T: point %v not inside bound %v of %v", s, p, b, c)
+				}
+				if z > 0 && s.Parent(c) != s.At(p, z-1) {
+					t.Errorf("%T: parent of %v is %v, want %v", s, c, s.Parent(c), s.At(p, z-1))
+				}
+				found := false
+				for _, child := range s.Children(c) {
+					if s.Parent(child) != c {
+						t.Errorf("%T: parent of child %v is not %v", s, child, c)
+					}
+					if child == s.At(p, z+1) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("%T: children of %v not contains %v", s, c, s.At(p, z+1))
+				}
+			}
+		}
+	}
+}
+
+func TestGeohashString(t *testing.T) {
+	// Example from https://en.wikipedia.org/wiki/Geohash
+	c := cell.Geohash{}.At(orb.Point{10.40744, 57.64911}, 55)
+	if got := (cell.Geohash{}).String(c); got != "u4pruydqqvj" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestEqualAreaSameArea(t *testing.T) {
+	s := cell.EqualArea{}
+	area := func(b orb.Bound) float64 {
+		lng := (b.Max.Lon() - b.Min.Lon()) * math.Pi / 180
+		return lng * (math.Sin(b.Max.Lat()*math.Pi/180) - math.Sin(b.Min.Lat()*math.Pi/180))
+	}
+	equator := area(s.Bound(s.At(orb.Point{0, 0}, 8)))
+	pole := area(s.Bound(s.At(orb.Point{0, 89.99}, 8)))
+	if math.Abs(equator-pole) > 1e-9 {
+		t.Errorf("area at equator %v not equal to pole %v", equator, pole)
+	}
+}
+
+func TestCoverPole(t *testing.T) {
+	poly := orb.Polygon{{{-10, 86}, {10, 86}, {10, 89.5}, {-10, 89.5}, {-10, 86}}}
+	for _, s := range []cell.System{cell.Geohash{}, cell.EqualArea{}} {
+		cells, err := s.Cover(poly, 12)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cells) == 0 {
+			t.Errorf("%T: expect cells near pole", s)
+		}
+		for _, c := range cells {
+			center := s.Bound(c).Center()
+			if center.Lat() < 86 || center.Lat() > 89.5 || center.Lon() < -10 || center.Lon() > 10 {
+				t.Errorf("%T: cell %v center %v outside polygon", s, c, center)
+			}
+		}
+	}
+}
+
+func TestMergeUpLikeTilecover(t *testing.T) {
+	poly := orb.Polygon{{{-75, 39}, {-73, 39}, {-73.5, 41}, {-75, 41}, {-75, 39}}}
+	tiles, err := tilecover.Geometry(poly, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := map[cell.Cell]bool{}
+	for tile := range tiles {
+		cells[cell.FromTile(tile)] = true
+	}
+	expect := tilecover.MergeUp(tiles, 3)
+	got := cell.MergeUp(cell.MapTile{}, cells, 3)
+	if len(got) != len(expect) {
+		t.Fatalf("got %v cells, want %v", len(got), len(expect))
+	}
+	for tile := range expect {
+		if !got[cell.FromTile(tile)] {
+			t.Errorf("missing %v", tile)
+		}
+	}
+}
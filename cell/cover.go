@@ -0,0 +1,60 @@
+package cell
+
+import (
+	"sort"
+
+	"github.com/paulmach/orb"
+)
+
+// scanlineCover returns cells whose center is inside polygon, row by row.
+//
+// It works for systems which rows are latitude bands and columns are
+// longitude bands. Polygon's holes are handled by even-odd rule.
+func scanlineCover(s System, poly orb.Polygon, z uint32) []Cell {
+	if len(poly) == 0 {
+		return nil
+	}
+	bound := poly.Bound()
+	minCell := s.At(bound.Min, z)
+	maxCell := s.At(bound.Max, z)
+	minY, maxY := minCell.Y, maxCell.Y
+	if minY > maxY {
+		minY, maxY = maxY, minY
+	}
+	minX, maxX := minCell.X, maxCell.X
+
+	ret := []Cell{}
+	crossings := []float64{}
+	for y := minY; y <= maxY; y++ {
+		rowBound := s.Bound(Cell{X: minX, Y: y, Z: z})
+		lat := (rowBound.Min.Lat() + rowBound.Max.Lat()) / 2
+
+		crossings = crossings[:0]
+		for _, ring := range poly {
+			for i := range ring {
+				a, b := ring[i], ring[(i+1)%len(ring)]
+				if (a.Lat() <= lat) == (b.Lat() <= lat) {
+					continue
+				}
+				t := (lat - a.Lat()) / (b.Lat() - a.Lat())
+				crossings = append(crossings, a.Lon()+t*(b.Lon()-a.Lon()))
+			}
+		}
+		sort.Float64s(crossings)
+
+		for i := 0; i+1 < len(crossings); i += 2 {
+			from, to := crossings[i], crossings[i+1]
+			for x := s.At(orb.Point{from, lat}, z).X; x <= maxX; x++ {
+				cellBound := s.Bound(Cell{X: x, Y: y, Z: z})
+				center := (cellBound.Min.Lon() + cellBound.Max.Lon()) / 2
+				if center > to {
+					break
+				}
+				if center >= from {
+					ret = append(ret, Cell{X: x, Y: y, Z: z})
+				}
+			}
+		}
+	}
+	return ret
+}
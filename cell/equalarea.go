@@ -0,0 +1,63 @@
+package cell
+
+import (
+	"math"
+
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/paulmach/orb"
+)
+
+// EqualArea is a quadtree on Lambert cylindrical equal-area projection, all
+// cells at same level have the same area on the sphere.
+//
+// Level 0 has two cells, western and eastern hemisphere, each splits into 4
+// children at next level. Y grows to north, rows are equal steps of sin(lat).
+type EqualArea struct{}
+
+func (EqualArea) Kind() pb.CellSystem { return pb.CellSystem_EqualArea }
+
+func (EqualArea) Size(z uint32) (uint32, uint32) {
+	return 2 << z, 1 << z
+}
+
+func (s EqualArea) At(p orb.Point, z uint32) Cell {
+	cols, rows := s.Size(z)
+	return Cell{
+		X: fraction(p.Lon(), -180, 180, cols),
+		Y: fraction(math.Sin(p.Lat()*math.Pi/180), -1, 1, rows),
+		Z: z,
+	}
+}
+
+func (s EqualArea) Bound(c Cell) orb.Bound {
+	cols, rows := s.Size(c.Z)
+	w := 360 / float64(cols)
+	h := 2 / float64(rows)
+	lat := func(y uint32) float64 {
+		return math.Asin(math.Max(-1, math.Min(1, -1+float64(y)*h))) * 180 / math.Pi
+	}
+	return orb.Bound{
+		Min: orb.Point{-180 + float64(c.X)*w, lat(c.Y)},
+		Max: orb.Point{-180 + float64(c.X+1)*w, lat(c.Y + 1)},
+	}
+}
+
+func (EqualArea) Parent(c Cell) Cell {
+	if c.Z == 0 {
+		return c
+	}
+	return Cell{X: c.X >> 1, Y: c.Y >> 1, Z: c.Z - 1}
+}
+
+func (EqualArea) Children(c Cell) []Cell {
+	return []Cell{
+		{X: c.X << 1, Y: c.Y << 1, Z: c.Z + 1},
+		{X: c.X<<1 + 1, Y: c.Y << 1, Z: c.Z + 1},
+		{X: c.X<<1 + 1, Y: c.Y<<1 + 1, Z: c.Z + 1},
+		{X: c.X << 1, Y: c.Y<<1 + 1, Z: c.Z + 1},
+	}
+}
+
+func (s EqualArea) Cover(poly orb.Polygon, z uint32) ([]Cell, error) {
+	return scanlineCover(s, poly, z), nil
+}
@@ -0,0 +1,106 @@
+package cell
+
+import (
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/paulmach/orb"
+)
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash is the cell system behind geohash strings, Z is bits count.
+//
+// Bits interleave longitude and latitude starting with longitude, so cell at
+// level z has (z+1)/2 longitude bits as X and z/2 latitude bits as Y. Every 5
+// levels is one geohash character. Y grows to north.
+type Geohash struct{}
+
+func (Geohash) Kind() pb.CellSystem { return pb.CellSystem_Geohash }
+
+func (Geohash) Size(z uint32) (uint32, uint32) {
+	return 1 << ((z + 1) / 2), 1 << (z / 2)
+}
+
+func (s Geohash) At(p orb.Point, z uint32) Cell {
+	cols, rows := s.Size(z)
+	return Cell{
+		X: fraction(p.Lon(), -180, 180, cols),
+		Y: fraction(p.Lat(), -90, 90, rows),
+		Z: z,
+	}
+}
+
+func (s Geohash) Bound(c Cell) orb.Bound {
+	cols, rows := s.Size(c.Z)
+	w := 360 / float64(cols)
+	h := 180 / float64(rows)
+	return orb.Bound{
+		Min: orb.Point{-180 + float64(c.X)*w, -90 + float64(c.Y)*h},
+		Max: orb.Point{-180 + float64(c.X+1)*w, -90 + float64(c.Y+1)*h},
+	}
+}
+
+func (Geohash) Parent(c Cell) Cell {
+	if c.Z == 0 {
+		return c
+	}
+	// odd level's last bit is longitude
+	if c.Z%2 == 1 {
+		return Cell{X: c.X >> 1, Y: c.Y, Z: c.Z - 1}
+	}
+	return Cell{X: c.X, Y: c.Y >> 1, Z: c.Z - 1}
+}
+
+func (Geohash) Children(c Cell) []Cell {
+	if (c.Z+1)%2 == 1 {
+		return []Cell{
+			{X: c.X << 1, Y: c.Y, Z: c.Z + 1},
+			{X: c.X<<1 + 1, Y: c.Y, Z: c.Z + 1},
+		}
+	}
+	return []Cell{
+		{X: c.X, Y: c.Y << 1, Z: c.Z + 1},
+		{X: c.X, Y: c.Y<<1 + 1, Z: c.Z + 1},
+	}
+}
+
+func (s Geohash) Cover(poly orb.Polygon, z uint32) ([]Cell, error) {
+	return scanlineCover(s, poly, z), nil
+}
+
+// String returns cell's geohash string, only works when z is multiple of 5.
+func (Geohash) String(c Cell) string {
+	if c.Z%5 != 0 {
+		return ""
+	}
+	ret := make([]byte, 0, c.Z/5)
+	xbits, ybits := (c.Z+1)/2, c.Z/2
+	v := 0
+	for i := uint32(0); i < c.Z; i++ {
+		var bit uint32
+		if i%2 == 0 {
+			xbits--
+			bit = c.X >> xbits & 1
+		} else {
+			ybits--
+			bit = c.Y >> ybits & 1
+		}
+		v = v<<1 | int(bit)
+		if i%5 == 4 {
+			ret = append(ret, geohashBase32[v])
+			v = 0
+		}
+	}
+	return string(ret)
+}
+
+// fraction returns which of n equal parts of [min, max) v falls in.
+func fraction(v, min, max float64, n uint32) uint32 {
+	f := (v - min) / (max - min) * float64(n)
+	if f < 0 {
+		return 0
+	}
+	if f >= float64(n) {
+		return n - 1
+	}
+	return uint32(f)
+}
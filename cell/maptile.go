@@ -0,0 +1,59 @@
+package cell
+
+import (
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+	"github.com/paulmach/orb/maptile/tilecover"
+)
+
+// MapTile is OSM style Web Mercator map tile, Z is zoom level.
+//
+// Y grows to south and tiles only cover to ~85.0511° latitude.
+type MapTile struct{}
+
+func (MapTile) Kind() pb.CellSystem { return pb.CellSystem_MapTile }
+
+func (MapTile) Size(z uint32) (uint32, uint32) {
+	return 1 << z, 1 << z
+}
+
+func (MapTile) At(p orb.Point, z uint32) Cell {
+	return FromTile(maptile.At(p, maptile.Zoom(z)))
+}
+
+func (MapTile) Bound(c Cell) orb.Bound {
+	return ToTile(c).Bound()
+}
+
+func (MapTile) Parent(c Cell) Cell {
+	return FromTile(ToTile(c).Parent())
+}
+
+func (MapTile) Children(c Cell) []Cell {
+	ret := []Cell{}
+	for _, tile := range ToTile(c).Children() {
+		ret = append(ret, FromTile(tile))
+	}
+	return ret
+}
+
+func (MapTile) Cover(poly orb.Polygon, z uint32) ([]Cell, error) {
+	tiles, err := tilecover.Geometry(poly, maptile.Zoom(z))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]Cell, 0, len(tiles))
+	for tile := range tiles {
+		ret = append(ret, FromTile(tile))
+	}
+	return ret, nil
+}
+
+func FromTile(tile maptile.Tile) Cell {
+	return Cell{X: tile.X, Y: tile.Y, Z: uint32(tile.Z)}
+}
+
+func ToTile(c Cell) maptile.Tile {
+	return maptile.New(c.X, c.Y, maptile.Zoom(c.Z))
+}
@@ -1,18 +1,58 @@
 // CLI tool to reduce polygon filesize
+//
+// Usage:
+//
+//	compresslocpb [flags] <locations pb file>
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/deslittle/pinpoint/convert"
 	"github.com/deslittle/pinpoint/pb"
 	"github.com/deslittle/pinpoint/reduce"
 	"google.golang.org/protobuf/proto"
 )
 
+var (
+	method          string
+	scale           float64
+	ringCompression string
+	outputPath      string
+)
+
+func init() {
+	flag.StringVar(&method, "method", "polyline", "compress method, one of polyline, delta-varint")
+	flag.Float64Var(&scale, "scale", reduce.DefaultScale, "delta-varint coordinates multiplier, 1e5 means 5 decimals")
+	flag.StringVar(&ringCompression, "ring-compression", "none", "delta-varint compression of every ring, one of none, deflate")
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's .pb with .compress.pb")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: compresslocpb [flags] <locations pb file>")
+		flag.PrintDefaults()
+	}
+}
+
 func main() {
-	originalProbufPath := os.Args[1]
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	compressMethod, err := pb.ParseCompressMethod(method)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	ringMethod, err := pb.ParseRingCompression(ringCompression)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	originalProbufPath := flag.Arg(0)
 	rawFile, err := os.ReadFile(originalProbufPath)
 	if err != nil {
 		panic(err)
@@ -21,9 +61,15 @@ func main() {
 	if err := proto.Unmarshal(rawFile, input); err != nil {
 		panic(err)
 	}
-	output := reduce.CompressWithPolyline(input)
+	output, err := reduce.Compress(input, compressMethod, reduce.SetScale(scale), reduce.SetRingCompression(ringMethod))
+	if err != nil {
+		panic(err)
+	}
+	convert.AddStepFromFlags(output.Metadata, "compresslocpb", flag.CommandLine)
 
-	outputPath := strings.Replace(originalProbufPath, ".pb", ".compress.pb", 1)
+	if outputPath == "" {
+		outputPath = strings.Replace(originalProbufPath, ".pb", ".compress.pb", 1)
+	}
 	outputBin, _ := proto.Marshal(output)
 	f, err := os.Create(outputPath)
 	if err != nil {
@@ -0,0 +1,69 @@
+// CLI tool to find overlaps and gaps between locations.
+//
+// Usage:
+//
+//	coveragelocpb [flags] <locations pb file>
+//
+// A summary table of overlaps and gaps grouped by locations is printed, and
+// every region is written to a GeoJSON file for review on maps like
+// geojson.io. Exit code is 1 if any region is found.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deslittle/pinpoint/coverage"
+	"github.com/deslittle/pinpoint/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	minArea    float64
+	outputPath string
+)
+
+func init() {
+	flag.Float64Var(&minArea, "min-area", coverage.DefaultMinArea, "smallest area in square meters of reported regions")
+	flag.StringVar(&outputPath, "o", "", "output GeoJSON path, default replace input's .pb with .coverage.geojson")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: coveragelocpb [flags] <locations pb file>")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	inputPath := flag.Arg(0)
+	rawFile, err := os.ReadFile(inputPath)
+	if err != nil {
+		panic(err)
+	}
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(rawFile, input); err != nil {
+		panic(err)
+	}
+
+	report := coverage.Check(input, coverage.SetMinArea(minArea))
+	if err := report.WriteTable(os.Stdout); err != nil {
+		panic(err)
+	}
+	fmt.Printf("overlaps: %d, gaps: %d\n", report.Count(coverage.Overlap), report.Count(coverage.Gap))
+
+	if outputPath == "" {
+		outputPath = strings.Replace(inputPath, ".pb", ".coverage.geojson", 1)
+	}
+	if err := os.WriteFile(outputPath, report.GeoJSON(), 0644); err != nil {
+		panic(err)
+	}
+	fmt.Println(outputPath)
+	if len(report.Regions) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,103 @@
+// CLI tool to convert CSV with WKT or hex WKB geometry to pinpoints's Probuf format.
+//
+// Usage:
+//
+//	csv2locpb [flags] <csv file>
+//
+// Every row is a location, geometry is a Polygon or MultiPolygon and other
+// columns are properties.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/crs"
+)
+
+var (
+	geometryColumn string
+	outputPath     string
+	projection     crs.Flag
+	repair         bool
+	featureFlags   *convert.FeatureFlags
+	metadataFlags  *convert.MetadataFlags
+)
+
+func init() {
+	flag.StringVar(&geometryColumn, "geometry-column", convert.DefaultGeometryColumn, "column of WKT or hex WKB geometry")
+	featureFlags = convert.NewFeatureFlags(flag.CommandLine, "columns")
+	flag.Var(&projection, "crs", "source `CRS` like EPSG:3857 or a PROJ string, default WGS84")
+	flag.BoolVar(&repair, "repair", false, "repair rings, like closing them and splitting bow-ties, and report repaired issues")
+	metadataFlags = convert.NewMetadataFlags(flag.CommandLine)
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's .csv with .pb")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: csv2locpb [flags] <csv file>")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	csvPath := flag.Arg(0)
+
+	in, err := os.Open(csvPath)
+	if err != nil {
+		panic(err)
+	}
+	defer in.Close()
+
+	reader, err := convert.NewCSVReader(bufio.NewReader(in),
+		convert.SetGeometryColumn(geometryColumn),
+		featureFlags.Option(os.Stderr),
+		convert.SetRepairFlag(repair, os.Stderr),
+		convert.SetProjection(projection.Projection),
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(csvPath, ".csv") + ".pb"
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		panic(err)
+	}
+	out := bufio.NewWriter(f)
+	writer := convert.NewLocationsWriter(out)
+	for {
+		location, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := writer.Write(location); err != nil {
+			panic(err)
+		}
+	}
+	if err := writer.WriteMetadata(metadataFlags.Metadata("csv2locpb", flag.CommandLine)); err != nil {
+		panic(err)
+	}
+	if err := out.Flush(); err != nil {
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+	fmt.Println(outputPath)
+}
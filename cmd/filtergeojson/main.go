@@ -3,8 +3,11 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+
+	"github.com/deslittle/pinpoint/convert"
 )
 
 type FeatureCollection struct {
@@ -13,29 +16,36 @@ type FeatureCollection struct {
 }
 
 type Features struct {
-	Type       string                 `json:"type"`
-	Properties Properties             `json:"properties"`
-	Geometry   map[string]interface{} `json:"geometry"`
+	Type       string                   `json:"type"`
+	Properties convert.PropertiesDefine `json:"properties"`
+	Geometry   map[string]interface{}   `json:"geometry"`
 }
 
-type Properties struct {
-	Name string `json:"name"`
+var property string
+
+func init() {
+	flag.StringVar(&property, "property", convert.DefaultNameProperty, "property matched with whitelist, case-insensitive if no exact match")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: filtergeojson [flags] <geojsonin file> <geojsonout file> <whitelist string 1> <whitelist string 1> ...")
+		flag.PrintDefaults()
+	}
 }
 
 func main() {
-	if len(os.Args) < 4 {
-		fmt.Println("Usage: combinegeojson <geojsonin file> <geojsonout file> <whitelist string 1> <whitelist string 1> ...")
+	flag.Parse()
+	if flag.NArg() < 3 {
+		flag.Usage()
 		return
 	}
 
 	// Create a map of the whitelist strings
 	whitelist := make(map[string]struct{})
-	for _, allowedName := range os.Args[3:] {
+	for _, allowedName := range flag.Args()[2:] {
 		whitelist[allowedName] = struct{}{}
 	}
 
 	// Parse the json into a FeatureCollection
-	rawFile, err := os.ReadFile(os.Args[1])
+	rawFile, err := os.ReadFile(flag.Arg(0))
 	if err != nil {
 		panic(err)
 	}
@@ -51,14 +61,15 @@ func main() {
 	}
 	// Loop through the features and only add the ones that are in the whitelist
 	for _, feature := range collection.Features {
-		if _, ok := whitelist[feature.Properties.Name]; ok {
+		value, _ := feature.Properties.GetFold(property)
+		if _, ok := whitelist[value]; ok {
 			filteredCollection.Features = append(filteredCollection.Features, feature)
 		}
 	}
 
 	// Convert back into json
 	outFile, _ := json.Marshal(filteredCollection)
-	outputPath := os.Args[2]
+	outputPath := flag.Arg(1)
 	f, err := os.Create(outputPath)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,138 @@
+// CLI tool to convert Geobuf or FlatGeobuf based Location boundary to pinpoints's Probuf format.
+//
+// Usage:
+//
+//	geobuf2locpb [flags] <geobuf or flatgeobuf file>
+//
+// FlatGeobuf is detected by its magic bytes and read one feature at a time.
+// Feature's id is property id for -id-property.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/crs"
+	"github.com/deslittle/pinpoint/pb"
+)
+
+var (
+	outputPath    string
+	projection    crs.Flag
+	repair        bool
+	featureFlags  *convert.FeatureFlags
+	metadataFlags *convert.MetadataFlags
+)
+
+func init() {
+	featureFlags = convert.NewFeatureFlags(flag.CommandLine, "properties")
+	flag.Var(&projection, "crs", "source `CRS` like EPSG:3857 or a PROJ string, default FlatGeobuf header's crs or WGS84")
+	flag.BoolVar(&repair, "repair", false, "repair rings, like closing them and splitting bow-ties, and report repaired issues")
+	metadataFlags = convert.NewMetadataFlags(flag.CommandLine)
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's extension with .pb")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: geobuf2locpb [flags] <geobuf or flatgeobuf file>")
+		flag.PrintDefaults()
+	}
+}
+
+// locationReader is implemented by convert's streaming readers.
+type locationReader interface {
+	Next() (*pb.Location, error)
+}
+
+// sliceReader returns locations already read.
+type sliceReader struct {
+	locations []*pb.Location
+}
+
+func (r *sliceReader) Next() (*pb.Location, error) {
+	if len(r.locations) == 0 {
+		return nil, io.EOF
+	}
+	location := r.locations[0]
+	r.locations = r.locations[1:]
+	return location, nil
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	inputPath := flag.Arg(0)
+	opts := []convert.OptionFunc{
+		featureFlags.Option(os.Stderr),
+		convert.SetProjection(projection.Projection),
+		convert.SetRepairFlag(repair, os.Stderr),
+	}
+
+	in, err := os.Open(inputPath)
+	if err != nil {
+		panic(err)
+	}
+	defer in.Close()
+	br := bufio.NewReader(in)
+	magic, _ := br.Peek(3)
+
+	var reader locationReader
+	if bytes.Equal(magic, []byte("fgb")) {
+		reader, err = convert.NewFlatGeobufReader(br, opts...)
+	} else {
+		var data []byte
+		if data, err = io.ReadAll(br); err != nil {
+			panic(err)
+		}
+		var input *pb.Locations
+		input, err = convert.ReadGeobuf(data, opts...)
+		if input != nil {
+			reader = &sliceReader{locations: input.Locations}
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".pb"
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		panic(err)
+	}
+	out := bufio.NewWriter(f)
+	writer := convert.NewLocationsWriter(out)
+	for {
+		location, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := writer.Write(location); err != nil {
+			panic(err)
+		}
+	}
+	if err := writer.WriteMetadata(metadataFlags.Metadata("geobuf2locpb", flag.CommandLine)); err != nil {
+		panic(err)
+	}
+	if err := out.Flush(); err != nil {
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+	fmt.Println(outputPath)
+}
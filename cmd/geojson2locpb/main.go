@@ -1,42 +1,96 @@
 // CLI tool to convert GeoJSON based Location boundary to pinpoints's Probuf format.
+//
+// Usage:
+//
+//	geojson2locpb [flags] <geojson file>
+//
+// Features are read and written one at a time, so very large files could be
+// converted with bounded memory.
 package main
 
 import (
-	"encoding/json"
+	"bufio"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/deslittle/pinpoint/convert"
-	"google.golang.org/protobuf/proto"
+	"github.com/deslittle/pinpoint/crs"
 )
 
-func main() {
-	jsonFilePath := os.Args[1]
+var (
+	outputPath    string
+	projection    crs.Flag
+	repair        bool
+	featureFlags  *convert.FeatureFlags
+	metadataFlags *convert.MetadataFlags
+)
 
-	rawFile, err := os.ReadFile(jsonFilePath)
-	if err != nil {
-		panic(err)
+func init() {
+	featureFlags = convert.NewFeatureFlags(flag.CommandLine, "properties")
+	flag.Var(&projection, "crs", "source `CRS` like EPSG:3857 or a PROJ string, default the file's crs member or WGS84")
+	flag.BoolVar(&repair, "repair", false, "repair rings, like closing them and splitting bow-ties, and report repaired issues")
+	metadataFlags = convert.NewMetadataFlags(flag.CommandLine)
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's .json with .pb")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: geojson2locpb [flags] <geojson file>")
+		flag.PrintDefaults()
 	}
+}
 
-	// Parse the json into a BoundaryFile
-	boundaryFile := &convert.BoundaryFile{}
-	if err := json.Unmarshal(rawFile, boundaryFile); err != nil {
-		panic(err)
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
 	}
+	jsonFilePath := flag.Arg(0)
 
-	// Convert the boundaryFile to a protobuf
-	output, err := convert.Do(boundaryFile)
+	in, err := os.Open(jsonFilePath)
 	if err != nil {
 		panic(err)
 	}
-	outputPath := strings.Replace(jsonFilePath, ".json", ".pb", 1)
-	outputBin, _ := proto.Marshal(output)
+	defer in.Close()
 
+	if outputPath == "" {
+		outputPath = strings.Replace(jsonFilePath, ".json", ".pb", 1)
+	}
 	f, err := os.Create(outputPath)
 	if err != nil {
 		panic(err)
 	}
-	_, _ = f.Write(outputBin)
+	out := bufio.NewWriter(f)
+
+	reader := convert.NewReader(bufio.NewReader(in),
+		featureFlags.Option(os.Stderr),
+		convert.SetRepairFlag(repair, os.Stderr),
+		convert.SetProjection(projection.Projection),
+	)
+	writer := convert.NewLocationsWriter(out)
+	for {
+		location, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := writer.Write(location); err != nil {
+			panic(err)
+		}
+	}
+	if err := writer.WriteMetadata(metadataFlags.Metadata("geojson2locpb", flag.CommandLine)); err != nil {
+		panic(err)
+	}
+	if err := out.Flush(); err != nil {
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
 	fmt.Println(outputPath)
 }
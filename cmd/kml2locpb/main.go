@@ -0,0 +1,110 @@
+// CLI tool to convert KML or KMZ Placemark polygons to pinpoints's Probuf format.
+//
+// Usage:
+//
+//	kml2locpb [flags] <kml or kmz file>
+//
+// Placemark's name is location's name by default, -name-property reads an
+// ExtendedData field instead, Name is Placemark's name and Placemark's id
+// attribute is field id. A Placemark without polygon fails unless -lenient is
+// set.
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deslittle/pinpoint/convert"
+)
+
+var (
+	outputPath    string
+	repair        bool
+	featureFlags  *convert.FeatureFlags
+	metadataFlags *convert.MetadataFlags
+)
+
+func init() {
+	featureFlags = convert.NewFeatureFlags(flag.CommandLine, "ExtendedData fields")
+	flag.BoolVar(&repair, "repair", false, "repair rings, like closing them and splitting bow-ties, and report repaired issues")
+	metadataFlags = convert.NewMetadataFlags(flag.CommandLine)
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's extension with .pb")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: kml2locpb [flags] <kml or kmz file>")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	inputPath := flag.Arg(0)
+
+	var in io.ReadCloser
+	if strings.EqualFold(filepath.Ext(inputPath), ".kmz") {
+		zr, err := zip.OpenReader(inputPath)
+		if err != nil {
+			panic(err)
+		}
+		defer zr.Close()
+		if in, err = convert.OpenKMZDocument(&zr.Reader); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		f, err := os.Open(inputPath)
+		if err != nil {
+			panic(err)
+		}
+		in = f
+	}
+	defer in.Close()
+
+	reader := convert.NewKMLReader(bufio.NewReader(in),
+		featureFlags.Option(os.Stderr),
+		convert.SetRepairFlag(repair, os.Stderr),
+	)
+
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".pb"
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		panic(err)
+	}
+	out := bufio.NewWriter(f)
+	writer := convert.NewLocationsWriter(out)
+	for {
+		location, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := writer.Write(location); err != nil {
+			panic(err)
+		}
+	}
+	if err := writer.WriteMetadata(metadataFlags.Metadata("kml2locpb", flag.CommandLine)); err != nil {
+		panic(err)
+	}
+	if err := out.Flush(); err != nil {
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+	fmt.Println(outputPath)
+}
@@ -0,0 +1,82 @@
+// CLI tool to convert pinpoint's Probuf format to CSV with WKT or hex WKB geometry.
+//
+// Usage:
+//
+//	locpb2csv [flags] <locations pb>
+//
+// Columns are id, name and WKT, read back by csv2locpb with
+// -id-property id -name-property name.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	encoding   string
+	outputPath string
+)
+
+func init() {
+	flag.StringVar(&encoding, "encoding", "wkt", "geometry encoding, wkt or wkb for hex encoded WKB")
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's .pb with .csv")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: locpb2csv [flags] <locations pb>")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var geometryEncoding convert.GeometryEncoding
+	switch encoding {
+	case "wkt":
+		geometryEncoding = convert.WKT
+	case "wkb":
+		geometryEncoding = convert.HexWKB
+	default:
+		fmt.Fprintf(os.Stderr, "unknown encoding %q\n", encoding)
+		os.Exit(2)
+	}
+	inputPath := flag.Arg(0)
+
+	rawFile, err := os.ReadFile(inputPath)
+	if err != nil {
+		panic(err)
+	}
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(rawFile, input); err != nil {
+		panic(err)
+	}
+
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(inputPath, ".pb") + ".csv"
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		panic(err)
+	}
+	out := bufio.NewWriter(f)
+	if err := convert.RevertCSV(out, input, geometryEncoding); err != nil {
+		panic(err)
+	}
+	if err := out.Flush(); err != nil {
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+	fmt.Println(outputPath)
+}
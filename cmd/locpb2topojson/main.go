@@ -0,0 +1,71 @@
+// CLI tool to convert pinpoint's Probuf format to TopoJSON.
+//
+// Usage:
+//
+//	locpb2topojson [flags] <locations pb>
+//
+// Borders shared by neighbor locations are stored once as arcs, location's
+// ID is geometry's id and name is property Name.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	object       string
+	quantization int
+	outputPath   string
+)
+
+func init() {
+	flag.StringVar(&object, "object", "locations", "name of the GeometryCollection object")
+	flag.IntVar(&quantization, "quantization", 0, "quantize arcs to positions per axis, e.g. 1e6, 0 keeps coordinates unchanged")
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's .pb with .topojson")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: locpb2topojson [flags] <locations pb>")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	inputPath := flag.Arg(0)
+
+	rawFile, err := os.ReadFile(inputPath)
+	if err != nil {
+		panic(err)
+	}
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(rawFile, input); err != nil {
+		panic(err)
+	}
+	output, err := convert.RevertTopoJSON(input, object, quantization)
+	if err != nil {
+		panic(err)
+	}
+	outputBin, err := json.Marshal(output)
+	if err != nil {
+		panic(err)
+	}
+
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(inputPath, ".pb") + ".topojson"
+	}
+	if err := os.WriteFile(outputPath, outputBin, 0644); err != nil {
+		panic(err)
+	}
+	fmt.Println(outputPath)
+}
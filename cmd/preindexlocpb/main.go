@@ -1,26 +1,147 @@
 // CLI tool to preindex location shape.
+//
+// Usage:
+//
+//	preindexlocpb [flags] <locations pb file>
+//
+// Progress and a summary of tiles are printed to stderr, output path to stdout.
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/deslittle/pinpoint/cell"
+	"github.com/deslittle/pinpoint/convert"
 	"github.com/deslittle/pinpoint/pb"
 	"github.com/deslittle/pinpoint/preindex"
+	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/maptile"
 	"google.golang.org/protobuf/proto"
 )
 
+// zoomDefaults are default idx/agg/keep/max-idx zoom of each cell system.
+//
+// Geohash levels are bits so about twice of map tile's zoom, equal-area root
+// level has 2 cells so one less than map tile's zoom.
+var zoomDefaults = map[string]struct {
+	system                   cell.System
+	idx, agg, keep, maxIndex int
+}{
+	"maptile":    {cell.MapTile{}, 13, 3, 10, 17},
+	"geohash":    {cell.Geohash{}, 26, 6, 20, 34},
+	"equal-area": {cell.EqualArea{}, 12, 2, 9, 16},
+}
+
 var (
-	idxZoom            = 13
-	aggZoom            = 3
-	maxZoomLevelToKeep = 10
-	layerDrop          = 2
+	cellSystem         string
+	idxZoom            int
+	aggZoom            int
+	maxZoomLevelToKeep int
+	layerDrop          int
+	maxIdxZoom         int
+	workers            int
+	queriesPath        string
+	maxTiles           int
+	maxBytes           int
+	outputPath         string
+	geojsonPath        string
+	quiet              bool
 )
 
+func init() {
+	flag.StringVar(&cellSystem, "cell", "maptile", "cell system, one of maptile, geohash, equal-area")
+	flag.IntVar(&idxZoom, "idx-zoom", -1, "zoom level tiles generated at, -1 means cell system's default")
+	flag.IntVar(&aggZoom, "agg-zoom", -1, "zoom level tiles merged up to, -1 means cell system's default")
+	flag.IntVar(&maxZoomLevelToKeep, "keep-zoom", -1, "max zoom level of tiles to keep, -1 means cell system's default")
+	flag.IntVar(&layerDrop, "layer-drop", 2, "how many edge tile layers to drop")
+	flag.IntVar(&maxIdxZoom, "max-idx-zoom", -1, "finest zoom level tried for small polygons, set to idx-zoom to disable, -1 means cell system's default")
+	flag.IntVar(&workers, "workers", 0, "goroutines count, 0 means twice of CPU count")
+	flag.StringVar(&queriesPath, "queries", "", "historical query points file, one lng,lat per line, keep finer tiles where queries are dense")
+	flag.IntVar(&maxTiles, "max-tiles", 0, "max tiles count when optimizing with queries, 0 means no limit")
+	flag.IntVar(&maxBytes, "max-bytes", 0, "max output bytes when optimizing with queries, 0 means no limit")
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's .pb with .preindex.pb")
+	flag.StringVar(&geojsonPath, "geojson", "", "dump tiles as GeoJSON to this path, colored by location")
+	flag.BoolVar(&quiet, "q", false, "do not print progress and summary")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: preindexlocpb [flags] <locations pb file>")
+		flag.PrintDefaults()
+	}
+}
+
+func progressPrinter(start time.Time) func(done, total int) {
+	return func(done, total int) {
+		elapsed := time.Since(start)
+		eta := time.Duration(0)
+		if done > 0 {
+			eta = elapsed / time.Duration(done) * time.Duration(total-done)
+		}
+		fmt.Fprintf(os.Stderr, "\rpreindex: %d/%d locations (%3.0f%%) elapsed %v eta %v   ",
+			done, total, float64(done)/float64(total)*100,
+			elapsed.Round(time.Second), eta.Round(time.Second))
+		if done == total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
+
+func readQueries(path string) ([]orb.Point, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return preindex.ReadQueryPoints(f)
+}
+
+func printSkipped(report *preindex.LocationReport) {
+	if report.Err != nil {
+		fmt.Fprintf(os.Stderr, "skip location %v: %v\n", report.Name, report.Err)
+		return
+	}
+	for _, poly := range report.Skipped() {
+		fmt.Fprintf(os.Stderr, "skip location %v polygon %d: %v\n", report.Name, poly.Index, poly.Err)
+	}
+}
+
+func printSummary(input *pb.Locations, output *pb.PreindexLocations) {
+	byZoom := map[int32]int{}
+	byName := map[string]int{}
+	for _, key := range output.Keys {
+		byZoom[key.Z]++
+		byName[key.Name]++
+	}
+
+	zooms := make([]int32, 0, len(byZoom))
+	for z := range byZoom {
+		zooms = append(zooms, z)
+	}
+	sort.Slice(zooms, func(i, j int) bool { return zooms[i] < zooms[j] })
+
+	fmt.Fprintf(os.Stderr, "tiles: %d\n", len(output.Keys))
+	fmt.Fprintln(os.Stderr, "zoom\ttiles")
+	for _, z := range zooms {
+		fmt.Fprintf(os.Stderr, "%d\t%d\n", z, byZoom[z])
+	}
+
+	fmt.Fprintln(os.Stderr, "location\ttiles")
+	for _, location := range input.Locations {
+		fmt.Fprintf(os.Stderr, "%s\t%d\n", location.Name, byName[location.Name])
+	}
+}
+
 func main() {
-	originalProbufPath := os.Args[1]
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	originalProbufPath := flag.Arg(0)
 	rawFile, err := os.ReadFile(originalProbufPath)
 	if err != nil {
 		panic(err)
@@ -30,15 +151,70 @@ func main() {
 		panic(err)
 	}
 
-	output := preindex.PreIndexLocations(input, maptile.Zoom(idxZoom), maptile.Zoom(aggZoom), maptile.Zoom(maxZoomLevelToKeep), layerDrop)
+	defaults, ok := zoomDefaults[cellSystem]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown cell system %q\n", cellSystem)
+		os.Exit(2)
+	}
+	for _, v := range []struct {
+		flag *int
+		def  int
+	}{
+		{&idxZoom, defaults.idx},
+		{&aggZoom, defaults.agg},
+		{&maxZoomLevelToKeep, defaults.keep},
+		{&maxIdxZoom, defaults.maxIndex},
+	} {
+		if *v.flag < 0 {
+			*v.flag = v.def
+		}
+	}
 
-	// file := preindex.PreIndexLocationsToGeoJSON(output)
-	// err = os.WriteFile("preindex_tiles.geojson", file, 0644)
-	// if err != nil {
-	// 	panic(err)
-	// }
+	opts := []preindex.OptionFunc{
+		preindex.SetCellSystem(defaults.system),
+		preindex.SetMaxIdxZoom(maptile.Zoom(maxIdxZoom)),
+	}
+	if workers > 0 {
+		opts = append(opts, preindex.SetWorkers(workers))
+	}
+	var queries []orb.Point
+	if queriesPath != "" {
+		queries, err = readQueries(queriesPath)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts,
+			preindex.SetQueries(queries),
+			preindex.SetMaxTiles(maxTiles),
+			preindex.SetMaxBytes(maxBytes),
+		)
+	}
+	if !quiet && len(input.Locations) > 0 {
+		opts = append(opts, preindex.SetProgress(progressPrinter(time.Now())))
+	}
+	if !quiet {
+		opts = append(opts, preindex.SetReport(printSkipped))
+	}
+	output := preindex.PreIndexLocations(input, maptile.Zoom(idxZoom), maptile.Zoom(aggZoom), maptile.Zoom(maxZoomLevelToKeep), layerDrop, opts...)
+	convert.AddStepFromFlags(output.Metadata, "preindexlocpb", flag.CommandLine)
 
-	outputPath := strings.Replace(originalProbufPath, ".pb", ".preindex.pb", 1)
+	if !quiet {
+		printSummary(input, output)
+		if len(queries) != 0 {
+			fmt.Fprintf(os.Stderr, "queries hit rate: %.2f%%\n", preindex.HitRate(output, queries)*100)
+		}
+	}
+
+	if geojsonPath != "" {
+		file := preindex.PreIndexLocationsToGeoJSON(output)
+		if err := os.WriteFile(geojsonPath, file, 0644); err != nil {
+			panic(err)
+		}
+	}
+
+	if outputPath == "" {
+		outputPath = strings.Replace(originalProbufPath, ".pb", ".preindex.pb", 1)
+	}
 	outputBin, _ := proto.Marshal(output)
 	f, err := os.Create(outputPath)
 	if err != nil {
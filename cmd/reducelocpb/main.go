@@ -1,11 +1,22 @@
 // CLI tool to reduce polygon filesize
+//
+// Usage:
+//
+//	reducelocpb [flags] <locations pb file>
+//
+// A JSON quality report of every location is written next to the output,
+// .reduce.pb replaced with .reduce.report.json. Exit code is 1 if any
+// location exceeds -max-error.
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/deslittle/pinpoint/convert"
 	"github.com/deslittle/pinpoint/pb"
 	"github.com/deslittle/pinpoint/reduce"
 	"google.golang.org/protobuf/proto"
@@ -17,8 +28,46 @@ const (
 	MINDISTENCE float64 = 10    // min dist to previous point, except begin&end point
 )
 
+var (
+	skip         int
+	precise      float64
+	minist       float64
+	tolerance    float64
+	algorithm    string
+	areaRef      float64
+	vertexBudget int
+	maxError     float64
+	topology     bool
+	outputPath   string
+	reportPath   string
+)
+
+func init() {
+	flag.IntVar(&skip, "skip", SKIP, "keep one point of every skip points, 1 to disable")
+	flag.Float64Var(&precise, "precise", PRECISE, "round coordinates to 1/precise, 0 to disable")
+	flag.Float64Var(&minist, "min-distance", MINDISTENCE, "min distance in meters to previous point, except begin&end point, 0 to disable")
+	flag.Float64Var(&tolerance, "tolerance", reduce.DefaultTolerance, "simplification tolerance in degree")
+	flag.StringVar(&algorithm, "algorithm", reduce.DouglasPeucker.String(), "simplification algorithm, one of douglas-peucker, visvalingam-whyatt, radial")
+	flag.Float64Var(&areaRef, "area-ref", 0, "area in square degree reduced with full tolerance, smaller locations use smaller tolerance, 0 to disable")
+	flag.IntVar(&vertexBudget, "vertex-budget", 0, "max points per location, tolerance picked per location, overrides -area-ref, 0 to disable")
+	flag.Float64Var(&maxError, "max-error", 0, "max Hausdorff distance in meters a location could change, 0 to disable")
+	flag.BoolVar(&topology, "topology", false, "reduce borders shared by neighbor locations once to keep them seamless")
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's .pb with .reduce.pb")
+	flag.StringVar(&reportPath, "report", "", "quality report path, default replace output's .pb with .report.json, - to disable")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: reducelocpb [flags] <locations pb file>")
+		flag.PrintDefaults()
+	}
+}
+
 func main() {
-	originalProbufPath := os.Args[1]
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	originalProbufPath := flag.Arg(0)
 	rawFile, err := os.ReadFile(originalProbufPath)
 	if err != nil {
 		panic(err)
@@ -27,9 +76,31 @@ func main() {
 	if err := proto.Unmarshal(rawFile, input); err != nil {
 		panic(err)
 	}
-	output := reduce.Do(input, SKIP, PRECISE, MINDISTENCE)
+	algo, err := reduce.ParseAlgorithm(algorithm)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	opts := []reduce.OptionFunc{
+		reduce.SetTolerance(tolerance),
+		reduce.SetAlgorithm(algo),
+		reduce.SetAreaAdaptive(areaRef),
+		reduce.SetVertexBudget(vertexBudget),
+		reduce.SetMaxError(maxError),
+	}
+	report := &Report{MaxError: maxError}
+	if reportPath != "-" {
+		opts = append(opts, reduce.SetReport(report.Add))
+	}
+	if topology {
+		opts = append(opts, reduce.SetTopology)
+	}
+	output := reduce.Do(input, skip, precise, minist, opts...)
+	convert.AddStepFromFlags(output.Metadata, "reducelocpb", flag.CommandLine)
 
-	outputPath := strings.Replace(originalProbufPath, ".pb", ".reduce.pb", 1)
+	if outputPath == "" {
+		outputPath = strings.Replace(originalProbufPath, ".pb", ".reduce.pb", 1)
+	}
 	outputBin, _ := proto.Marshal(output)
 	f, err := os.Create(outputPath)
 	if err != nil {
@@ -37,4 +108,47 @@ func main() {
 	}
 	_, _ = f.Write(outputBin)
 	fmt.Println(outputPath)
+
+	if reportPath == "-" {
+		return
+	}
+	if reportPath == "" {
+		reportPath = strings.TrimSuffix(outputPath, ".pb") + ".report.json"
+	}
+	reportBin, _ := json.MarshalIndent(report, "", "  ")
+	if err := os.WriteFile(reportPath, reportBin, 0644); err != nil {
+		panic(err)
+	}
+	fmt.Println(reportPath)
+	for _, quality := range report.Locations {
+		if quality.Exceeded {
+			fmt.Fprintf(os.Stderr, "location %v exceeds max error: %.1f meters\n", quality.Name, quality.Hausdorff)
+		}
+	}
+	if report.Exceeded > 0 {
+		os.Exit(1)
+	}
+}
+
+// Report is the quality report file's content.
+type Report struct {
+	MaxError     float64 `json:"maxError"`
+	InputPoints  int     `json:"inputPoints"`
+	OutputPoints int     `json:"outputPoints"`
+	MaxHausdorff float64 `json:"maxHausdorff"`
+	// Exceeded is how many locations exceed MaxError.
+	Exceeded  int               `json:"exceeded"`
+	Locations []*reduce.Quality `json:"locations"`
+}
+
+func (r *Report) Add(quality *reduce.Quality) {
+	r.InputPoints += quality.InputPoints
+	r.OutputPoints += quality.OutputPoints
+	if quality.Hausdorff > r.MaxHausdorff {
+		r.MaxHausdorff = quality.Hausdorff
+	}
+	if quality.Exceeded {
+		r.Exceeded++
+	}
+	r.Locations = append(r.Locations, quality)
 }
@@ -0,0 +1,98 @@
+// CLI tool to convert ESRI Shapefile based Location boundary to pinpoints's Probuf format.
+//
+// Usage:
+//
+//	shp2locpb [flags] <shp file>
+//
+// Attributes are read from the .dbf file next to the .shp file.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/crs"
+)
+
+var (
+	outputPath    string
+	projection    crs.Flag
+	repair        bool
+	featureFlags  *convert.FeatureFlags
+	metadataFlags *convert.MetadataFlags
+)
+
+func init() {
+	featureFlags = convert.NewFeatureFlags(flag.CommandLine, "DBF fields")
+	flag.Var(&projection, "crs", "source `CRS` like EPSG:3857 or a PROJ string, see the .prj file, default WGS84")
+	flag.BoolVar(&repair, "repair", false, "repair rings, like closing them and splitting bow-ties, and report repaired issues")
+	metadataFlags = convert.NewMetadataFlags(flag.CommandLine)
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's .shp with .pb")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: shp2locpb [flags] <shp file>")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	shpPath := flag.Arg(0)
+
+	reader, err := convert.OpenShapefileReader(shpPath,
+		featureFlags.Option(os.Stderr),
+		convert.SetRepairFlag(repair, os.Stderr),
+		convert.SetProjection(projection.Projection),
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer reader.Close()
+	if !reader.HasAttributes() {
+		fmt.Fprintln(os.Stderr, "no .dbf file, locations have no name")
+	}
+
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(shpPath, filepath.Ext(shpPath)) + ".pb"
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		panic(err)
+	}
+	out := bufio.NewWriter(f)
+	writer := convert.NewLocationsWriter(out)
+	for {
+		location, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := writer.Write(location); err != nil {
+			panic(err)
+		}
+	}
+	if err := writer.WriteMetadata(metadataFlags.Metadata("shp2locpb", flag.CommandLine)); err != nil {
+		panic(err)
+	}
+	if err := out.Flush(); err != nil {
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+	fmt.Println(outputPath)
+}
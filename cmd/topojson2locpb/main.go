@@ -0,0 +1,83 @@
+// CLI tool to convert TopoJSON based Location boundary to pinpoints's Probuf format.
+//
+// Usage:
+//
+//	topojson2locpb [flags] <topojson file>
+//
+// Every geometry of the object's GeometryCollection is a location, geometry's
+// id is property id for -id-property.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/crs"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	object        string
+	outputPath    string
+	projection    crs.Flag
+	repair        bool
+	featureFlags  *convert.FeatureFlags
+	metadataFlags *convert.MetadataFlags
+)
+
+func init() {
+	flag.StringVar(&object, "object", "", "object to convert, could be empty if topology has only one")
+	featureFlags = convert.NewFeatureFlags(flag.CommandLine, "properties")
+	flag.Var(&projection, "crs", "source `CRS` of untransformed positions like EPSG:3857 or a PROJ string, default WGS84")
+	flag.BoolVar(&repair, "repair", false, "repair rings, like closing them and splitting bow-ties, and report repaired issues")
+	metadataFlags = convert.NewMetadataFlags(flag.CommandLine)
+	flag.StringVar(&outputPath, "o", "", "output path, default replace input's .json with .pb")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: topojson2locpb [flags] <topojson file>")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	inputPath := flag.Arg(0)
+
+	rawFile, err := os.ReadFile(inputPath)
+	if err != nil {
+		panic(err)
+	}
+	input := &convert.TopoJSON{}
+	if err := json.Unmarshal(rawFile, input); err != nil {
+		panic(err)
+	}
+	output, err := convert.DoTopoJSON(input, object,
+		featureFlags.Option(os.Stderr),
+		convert.SetRepairFlag(repair, os.Stderr),
+		convert.SetProjection(projection.Projection),
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	output.Metadata = metadataFlags.Metadata("topojson2locpb", flag.CommandLine)
+	outputBin, err := proto.Marshal(output)
+	if err != nil {
+		panic(err)
+	}
+
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(strings.TrimSuffix(inputPath, ".json"), ".topojson") + ".pb"
+	}
+	if err := os.WriteFile(outputPath, outputBin, 0644); err != nil {
+		panic(err)
+	}
+	fmt.Println(outputPath)
+}
@@ -0,0 +1,108 @@
+// CLI tool to check locations' rings and repair them.
+//
+// Usage:
+//
+//	validatelocpb [flags] <locations pb file>
+//
+// Every issue is printed with its location, polygon and ring, see package
+// validate for kinds of issues. Without -repair, exit code is 1 if any issue
+// is found.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/validate"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	kinds      string
+	repair     bool
+	outputPath string
+)
+
+func init() {
+	names := make([]string, 0, len(validate.Kinds))
+	for _, kind := range validate.Kinds {
+		names = append(names, kind.String())
+	}
+	flag.StringVar(&kinds, "kinds", strings.Join(names, ","), "comma separated kinds of issues checked and repaired")
+	flag.BoolVar(&repair, "repair", false, "repair issues and write repaired locations")
+	flag.StringVar(&outputPath, "o", "", "output path of -repair, default replace input's .pb with .valid.pb")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: validatelocpb [flags] <locations pb file>")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts := []validate.OptionFunc{}
+	if kinds != "" {
+		checked := make([]validate.Kind, 0)
+		for _, name := range strings.Split(kinds, ",") {
+			kind, err := validate.ParseKind(name)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			checked = append(checked, kind)
+		}
+		opts = append(opts, validate.SetKinds(checked...))
+	}
+	inputPath := flag.Arg(0)
+	rawFile, err := os.ReadFile(inputPath)
+	if err != nil {
+		panic(err)
+	}
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(rawFile, input); err != nil {
+		panic(err)
+	}
+
+	var issues []*validate.Issue
+	if repair {
+		issues = validate.Repair(input, opts...)
+	} else {
+		issues = validate.Check(input, opts...)
+	}
+	counts := map[validate.Kind]int{}
+	for _, issue := range issues {
+		fmt.Println(issue)
+		counts[issue.Kind]++
+	}
+	for _, kind := range validate.Kinds {
+		if counts[kind] > 0 {
+			fmt.Printf("%v: %d\n", kind, counts[kind])
+		}
+	}
+
+	if !repair {
+		if len(issues) > 0 {
+			fmt.Println("FAIL")
+			os.Exit(1)
+		}
+		fmt.Println("OK")
+		return
+	}
+	outputBin, err := proto.Marshal(input)
+	if err != nil {
+		panic(err)
+	}
+	if outputPath == "" {
+		outputPath = strings.Replace(inputPath, ".pb", ".valid.pb", 1)
+	}
+	if err := os.WriteFile(outputPath, outputBin, 0644); err != nil {
+		panic(err)
+	}
+	fmt.Println(outputPath)
+}
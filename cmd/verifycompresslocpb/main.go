@@ -0,0 +1,99 @@
+// CLI tool to verify compressed data decompress back to its input.
+//
+// Usage:
+//
+//	verifycompresslocpb [flags] <locations pb file> [compressed pb file]
+//
+// If compressed pb file is not given, locations are compressed by flags then
+// decompressed. Exit code is 1 if any ring differs or drifts more than the
+// method's precision.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/reduce"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	method          string
+	scale           float64
+	ringCompression string
+)
+
+func init() {
+	flag.StringVar(&method, "method", "polyline", "compress method, one of polyline, delta-varint")
+	flag.Float64Var(&scale, "scale", reduce.DefaultScale, "delta-varint coordinates multiplier, 1e5 means 5 decimals")
+	flag.StringVar(&ringCompression, "ring-compression", "none", "delta-varint compression of every ring, one of none, deflate")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: verifycompresslocpb [flags] <locations pb file> [compressed pb file]")
+		flag.PrintDefaults()
+	}
+}
+
+func readPB(path string, m proto.Message) {
+	rawFile, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	if err := proto.Unmarshal(rawFile, m); err != nil {
+		panic(err)
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	input := &pb.Locations{}
+	readPB(flag.Arg(0), input)
+
+	var verification *reduce.Verification
+	if flag.NArg() > 1 {
+		compressed := &pb.CompressedLocations{}
+		readPB(flag.Arg(1), compressed)
+		output, err := reduce.Decompress(compressed)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		verification = reduce.CompareLocations(input, output, reduce.Precision(compressed.Method, compressed.Scale))
+	} else {
+		compressMethod, err := pb.ParseCompressMethod(method)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		ringMethod, err := pb.ParseRingCompression(ringCompression)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		verification, err = reduce.Verify(input, compressMethod, reduce.SetScale(scale), reduce.SetRingCompression(ringMethod))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("precision: %g\n", verification.Precision)
+	fmt.Printf("max drift: %g", verification.MaxDrift)
+	if verification.MaxDriftAt != "" {
+		fmt.Printf(" at %v", verification.MaxDriftAt)
+	}
+	fmt.Println()
+	for _, diff := range verification.Differences {
+		fmt.Println(diff)
+	}
+	if !verification.OK() {
+		fmt.Println("FAIL")
+		os.Exit(1)
+	}
+	fmt.Println("OK")
+}
@@ -1,8 +1,10 @@
 package convert
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/deslittle/pinpoint/crs"
 	"github.com/deslittle/pinpoint/pb"
 	"github.com/mitchellh/mapstructure"
 )
@@ -19,10 +21,94 @@ type MultiPolygonCoordinates []PolygonCoordinates
 type GeometryDefine struct {
 	Coordinates interface{} `json:"coordinates"`
 	Type        string      `json:"type"`
+	// Geometries are GeometryCollection's members.
+	Geometries []*GeometryDefine `json:"geometries,omitempty"`
 }
 
-type PropertiesDefine struct {
-	Name string `json:"Name"`
+// ErrUnsupportedGeometry is matched by [errors.Is] for errors of features
+// without polygons, like a LineString or null geometry, which are skipped
+// in lenient mode, see [SetLenient].
+var ErrUnsupportedGeometry = errors.New("unsupported geometry")
+
+// unsupportedGeometryError is an error of ErrUnsupportedGeometry with a
+// format specific message.
+type unsupportedGeometryError string
+
+func (e unsupportedGeometryError) Error() string {
+	return string(e)
+}
+
+func (e unsupportedGeometryError) Is(target error) bool {
+	return target == ErrUnsupportedGeometry
+}
+
+func unsupportedGeometry(format string, args ...interface{}) error {
+	return unsupportedGeometryError(fmt.Sprintf(format, args...))
+}
+
+// SkippedFeature is a feature skipped in lenient mode.
+type SkippedFeature struct {
+	// Index is feature's index in source, skipped ones included.
+	Index int
+	Name  string
+	Err   error
+}
+
+func (f SkippedFeature) String() string {
+	return fmt.Sprintf("feature %d %q: %v", f.Index, f.Name, f.Err)
+}
+
+// geometryCoordinates converts polygons of a geometry, GeometryCollection's
+// members without polygons, like Point, are ignored. decode decodes
+// Polygon and MultiPolygon's coordinates to v.
+func geometryCoordinates(typ string, members int, decode func(v interface{}) error, member func(i int) (MultiPolygonCoordinates, error)) (MultiPolygonCoordinates, error) {
+	var coordinates MultiPolygonCoordinates
+	switch typ {
+	case MultiPolygonType:
+		if err := decode(&coordinates); err != nil {
+			return nil, err
+		}
+	case PolygonType:
+		var polygonCoordinates PolygonCoordinates
+		if err := decode(&polygonCoordinates); err != nil {
+			return nil, err
+		}
+		coordinates = append(coordinates, polygonCoordinates)
+	case GeometryCollectionType:
+		for i := 0; i < members; i++ {
+			polygons, err := member(i)
+			if errors.Is(err, ErrUnsupportedGeometry) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("geometries[%d]: %w", i, err)
+			}
+			coordinates = append(coordinates, polygons...)
+		}
+		if len(coordinates) == 0 {
+			return nil, unsupportedGeometry("GeometryCollection has no Polygon or MultiPolygon")
+		}
+	case "":
+		return nil, unsupportedGeometry("geometry is null")
+	default:
+		return nil, unsupportedGeometry("geometry type %q is not Polygon, MultiPolygon or GeometryCollection", typ)
+	}
+	return coordinates, nil
+}
+
+// coordinates returns g's polygons, see geometryCoordinates.
+func (g *GeometryDefine) coordinates() (MultiPolygonCoordinates, error) {
+	if g == nil {
+		return nil, unsupportedGeometry("geometry is null")
+	}
+	return geometryCoordinates(g.Type, len(g.Geometries),
+		func(v interface{}) error {
+			return mapstructure.Decode(g.Coordinates, v)
+		},
+		func(i int) (MultiPolygonCoordinates, error) {
+			return g.Geometries[i].coordinates()
+		},
+	)
 }
 
 type FeatureItem struct {
@@ -33,95 +119,132 @@ type FeatureItem struct {
 
 type BoundaryFile struct {
 	Type     string         `json:"type"`
+	CRS      *CRSDefine     `json:"crs,omitempty"`
 	Features []*FeatureItem `json:"features"`
 }
 
-func Do(input *BoundaryFile) (*pb.Locations, error) {
-	output := make([]*pb.Location, 0)
+// CRSDefine is GeoJSON 2008's named crs member, like
+// "urn:ogc:def:crs:EPSG::3857". RFC 7946 removed it and requires WGS84, but
+// many GIS exports still have it.
+type CRSDefine struct {
+	Type       string `json:"type"`
+	Properties struct {
+		Name string `json:"name"`
+	} `json:"properties"`
+}
+
+// projection returns opt's projection, or c's if opt has none.
+func (c *CRSDefine) projection(opt *Option) (crs.Projection, error) {
+	if opt.Projection != nil || c == nil {
+		return opt.Projection, nil
+	}
+	if c.Type != "name" {
+		return nil, fmt.Errorf("pinpoint/convert: crs type %q is not supported", c.Type)
+	}
+	return crs.Parse(c.Properties.Name)
+}
 
-	for _, item := range input.Features {
-		pblocItem := &pb.Location{
-			Name: item.Properties.Name,
+// project reprojects coordinates to WGS84 in place, nil p means they are
+// longitude and latitude already.
+func project(coordinates MultiPolygonCoordinates, p crs.Projection) {
+	if p == nil {
+		return
+	}
+	for _, polygon := range coordinates {
+		for _, ring := range polygon {
+			for i, position := range ring {
+				ring[i][0], ring[i][1] = p.Inverse(position[0], position[1])
+			}
 		}
+	}
+}
+
+// newLocation returns a location without polygons, ID and name read from
+// properties by opt.
+func newLocation(properties *PropertiesDefine, opt *Option) *pb.Location {
+	id, _ := properties.lookup(opt.IDProperties)
+	name, ok := properties.lookup(opt.NameProperties)
+	if !ok {
+		name = id
+	}
+	return &pb.Location{
+		Name: name,
+		Id:   id,
+	}
+}
 
-		var coordinates MultiPolygonCoordinates
+func toPbPoints(ring [][2]float64) []*pb.Point {
+	points := make([]*pb.Point, 0, len(ring))
+	for _, rawCoords := range ring {
+		points = append(points, &pb.Point{
+			Lng: float32(rawCoords[0]),
+			Lat: float32(rawCoords[1]),
+		})
+	}
+	return points
+}
 
-		MultiPolygonTypeHandler := func() error {
-			if err := mapstructure.Decode(item.Geometry.Coordinates, &coordinates); err != nil {
-				return err
-			}
-			return nil
+// FromGeoMultipolygonToPbPolygon converts GeoJSON MultiPolygon coordinates to
+// polygons, first ring of each polygon is the exterior ring, others are holes.
+func FromGeoMultipolygonToPbPolygon(coordinates MultiPolygonCoordinates) []*pb.Polygon {
+	polygons := make([]*pb.Polygon, 0, len(coordinates))
+	for _, subcoordinates := range coordinates {
+		newpbPoly := &pb.Polygon{
+			Points: make([]*pb.Point, 0),
+			Holes:  make([]*pb.Polygon, 0),
 		}
-		PolygonTypeHandler := func() error {
-			var polygonCoordinates PolygonCoordinates
-			if err := mapstructure.Decode(item.Geometry.Coordinates, &polygonCoordinates); err != nil {
-				return err
+		for index, geoPoly := range subcoordinates {
+			if index == 0 {
+				newpbPoly.Points = toPbPoints(geoPoly)
+				continue
 			}
-			coordinates = append(coordinates, polygonCoordinates)
-			return nil
+			newpbPoly.Holes = append(newpbPoly.Holes, &pb.Polygon{
+				Points: toPbPoints(geoPoly),
+			})
 		}
+		polygons = append(polygons, newpbPoly)
+	}
+	return polygons
+}
 
+// Do converts GeoJSON features to locations, see [SetIDProperty] and
+// [SetNameProperty] for how ID and name are read from properties.
+//
+// Features' geometry is Polygon, MultiPolygon or GeometryCollection with
+// them, others are errors of [ErrUnsupportedGeometry] or skipped in lenient
+// mode.
+//
+// Coordinates are reprojected to WGS84 by input's crs member or
+// [SetProjection].
+func Do(input *BoundaryFile, opts ...OptionFunc) (*pb.Locations, error) {
+	opt := newOption(opts...)
+	output := make([]*pb.Location, 0)
+	projection, err := input.CRS.projection(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, item := range input.Features {
+		pblocItem := newLocation(&item.Properties, opt)
+		geometry := &item.Geometry
 		switch item.Type {
-		case MultiPolygonType:
-			if err := MultiPolygonTypeHandler(); err != nil {
-				return nil, err
-			}
-		case PolygonType:
-			if err := PolygonTypeHandler(); err != nil {
-				return nil, err
-			}
 		case FeatureType:
-			switch item.Geometry.Type {
-			case MultiPolygonType:
-				if err := MultiPolygonTypeHandler(); err != nil {
-					return nil, err
-				}
-			case PolygonType:
-				if err := PolygonTypeHandler(); err != nil {
-					return nil, err
-				}
-			default:
-				return nil, fmt.Errorf("unknown type %v", item.Type)
-			}
+		case MultiPolygonType, PolygonType:
+			// feature typed by its geometry, kept for old files
+			geometry = &GeometryDefine{Type: item.Type, Coordinates: item.Geometry.Coordinates}
 		default:
-			return nil, fmt.Errorf("unknown type %v", item.Type)
+			return nil, fmt.Errorf("pinpoint/convert: feature %d: got type %q, want %v", index, item.Type, FeatureType)
 		}
-
-		polygons := make([]*pb.Polygon, 0)
-
-		for _, subcoordinates := range coordinates {
-			newpbPoly := &pb.Polygon{
-				Points: make([]*pb.Point, 0),
-				Holes:  make([]*pb.Polygon, 0),
-			}
-			for index, geoPoly := range subcoordinates {
-				if index == 0 {
-					for _, rawCoords := range geoPoly {
-						newpbPoly.Points = append(newpbPoly.Points, &pb.Point{
-							Lng: float32(rawCoords[0]),
-							Lat: float32(rawCoords[1]),
-						})
-					}
-					continue
-				}
-
-				holePoly := &pb.Polygon{
-					Points: make([]*pb.Point, 0),
-				}
-				for _, rawCoords := range geoPoly {
-					holePoly.Points = append(holePoly.Points, &pb.Point{
-						Lng: float32(rawCoords[0]),
-						Lat: float32(rawCoords[1]),
-					})
-				}
-				newpbPoly.Holes = append(newpbPoly.Holes, holePoly)
-
-			}
-			polygons = append(polygons, newpbPoly)
+		coordinates, err := geometry.coordinates()
+		if opt.skip(index, pblocItem.Name, err) {
+			continue
 		}
-
-		pblocItem.Polygons = polygons
-		output = append(output, pblocItem)
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/convert: feature %d %q: %w", index, pblocItem.Name, err)
+		}
+		project(coordinates, projection)
+		pblocItem.Polygons = FromGeoMultipolygonToPbPolygon(coordinates)
+		output = append(output, opt.repair(index, pblocItem))
 	}
 
 	return &pb.Locations{
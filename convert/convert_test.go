@@ -0,0 +1,284 @@
+package convert_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/crs"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/validate"
+)
+
+const squareFeatures = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "properties": {"GEOID": "44", "NAME": "Rhode Island", "Name": "RI"},
+      "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]]}
+    },
+    {
+      "type": "Feature",
+      "properties": {"GEOID": 25, "STUSPS": "MA"},
+      "geometry": {"type": "Polygon", "coordinates": [[[1, 0], [2, 0], [2, 1], [1, 1], [1, 0]]]}
+    }
+  ]
+}`
+
+func parse(t *testing.T, data string) *convert.BoundaryFile {
+	input := &convert.BoundaryFile{}
+	if err := json.Unmarshal([]byte(data), input); err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+func TestDoProperties(t *testing.T) {
+	cases := []struct {
+		name   string
+		opts   []convert.OptionFunc
+		expect [][2]string // id, name of each location
+	}{
+		{"default", nil, [][2]string{{"", "RI"}, {"", ""}}},
+		{"id", []convert.OptionFunc{convert.SetIDProperty("GEOID")}, [][2]string{{"44", "RI"}, {"25", "25"}}},
+		{"fallbacks", []convert.OptionFunc{convert.SetIDProperty("GEOID"), convert.SetNameProperty("NAME", "STUSPS")}, [][2]string{{"44", "Rhode Island"}, {"25", "MA"}}},
+	}
+	for _, c := range cases {
+		output, err := convert.Do(parse(t, squareFeatures), c.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, location := range output.Locations {
+			if got := [2]string{location.Id, location.Name}; got != c.expect[i] {
+				t.Errorf("%v: location %d got %q, want %q", c.name, i, got, c.expect[i])
+			}
+		}
+	}
+}
+
+func TestFeatureFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := convert.NewFeatureFlags(fs, "properties")
+	if err := fs.Parse([]string{"-id-property", "GEOID", "-name-property", "NAME,STUSPS", "-lenient"}); err != nil {
+		t.Fatal(err)
+	}
+	output, err := convert.Do(parse(t, squareFeatures), f.Option(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expect := range [][2]string{{"44", "Rhode Island"}, {"25", "MA"}} {
+		if got := [2]string{output.Locations[i].Id, output.Locations[i].Name}; got != expect {
+			t.Errorf("location %d got %q, want %q", i, got, expect)
+		}
+	}
+}
+
+func TestPropertiesGetFold(t *testing.T) {
+	cases := []struct {
+		properties string
+		key        string
+		expect     string
+	}{
+		{`{"Name": "RI"}`, "name", "RI"},
+		{`{"name": "RI"}`, "Name", "RI"},
+		{`{"NAME": "Rhode Island", "Name": "RI"}`, "Name", "RI"},
+		{`{"NAME": "Rhode Island", "name": "RI"}`, "Name", "Rhode Island"},
+		{`{"GEOID": 44}`, "geoid", "44"},
+		{`{"Name": ""}`, "name", ""},
+	}
+	for _, c := range cases {
+		properties := &convert.PropertiesDefine{}
+		if err := json.Unmarshal([]byte(c.properties), properties); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := properties.GetFold(c.key); got != c.expect {
+			t.Errorf("%v %v: got %q, want %q", c.properties, c.key, got, c.expect)
+		}
+	}
+}
+
+func TestRevertKeepsID(t *testing.T) {
+	output, err := convert.Do(parse(t, squareFeatures), convert.SetIDProperty("GEOID"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(convert.Revert(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := convert.Do(parse(t, string(data)), convert.SetIDProperty(convert.RevertIDProperty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Locations[0].Id != "44" || again.Locations[0].Name != "RI" {
+		t.Errorf("got %v", again.Locations[0])
+	}
+}
+
+func TestDoCRS(t *testing.T) {
+	ring := [][2]float64{{-74, 40}, {-73, 40}, {-73, 41}, {-74, 41}, {-74, 40}}
+	projected := make([][2]float64, len(ring))
+	for i, position := range ring {
+		projected[i][0], projected[i][1] = crs.WebMercator.Forward(position[0], position[1])
+	}
+	coordinates, err := json.Marshal([][][2]float64{projected})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := `{"type":"FeatureCollection","crs":{"type":"name","properties":{"name":"urn:ogc:def:crs:EPSG::3857"}},"features":[` +
+		`{"type":"Feature","properties":{"name":"NY"},"geometry":{"type":"Polygon","coordinates":` + string(coordinates) + `}}]}`
+
+	check := func(name string, output *pb.Locations, expect [][2]float64, tolerance float64) {
+		t.Helper()
+		points := output.Locations[0].Polygons[0].Points
+		for i, point := range points {
+			if math.Abs(float64(point.Lng)-expect[i][0]) > tolerance || math.Abs(float64(point.Lat)-expect[i][1]) > tolerance {
+				t.Errorf("%v: point %d got %v, want %v", name, i, point, expect[i])
+			}
+		}
+	}
+	output, err := convert.Do(parse(t, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Do", output, ring, 1e-4)
+	output, err = convert.ReadAll(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("ReadAll", output, ring, 1e-4)
+	output, err = convert.Do(parse(t, data), convert.SetProjection(crs.LngLat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// float32 of Web Mercator meters
+	check("SetProjection", output, projected, 1)
+
+	unknown := strings.Replace(data, "EPSG::3857", "EPSG::2000", 1)
+	if _, err := convert.Do(parse(t, unknown)); err == nil {
+		t.Error("expect error of unsupported crs")
+	}
+	if _, err := convert.ReadAll(strings.NewReader(unknown)); err == nil {
+		t.Error("expect error of unsupported crs from ReadAll")
+	}
+}
+
+const mixedFeatures = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "properties": {"Name": "square"},
+      "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]]}
+    },
+    {
+      "type": "Feature",
+      "properties": {"Name": "collection"},
+      "geometry": {"type": "GeometryCollection", "geometries": [
+        {"type": "Point", "coordinates": [0.5, 0.5]},
+        {"type": "Polygon", "coordinates": [[[1, 0], [2, 0], [2, 1], [1, 0]]]},
+        {"type": "GeometryCollection", "geometries": [
+          {"type": "MultiPolygon", "coordinates": [[[[3, 0], [4, 0], [4, 1], [3, 0]]]]}
+        ]}
+      ]}
+    },
+    {"type": "Feature", "properties": {"Name": "null"}, "geometry": null},
+    {
+      "type": "Feature",
+      "properties": {"Name": "road"},
+      "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}
+    },
+    {
+      "type": "Feature",
+      "properties": {"Name": "points"},
+      "geometry": {"type": "GeometryCollection", "geometries": [{"type": "Point", "coordinates": [0, 0]}]}
+    }
+  ]
+}`
+
+func TestDoUnsupportedGeometry(t *testing.T) {
+	expect := `pinpoint/convert: feature 2 "null": geometry is null`
+	if _, err := convert.Do(parse(t, mixedFeatures)); err == nil || err.Error() != expect {
+		t.Errorf("got error %v, want %q", err, expect)
+	}
+	if _, err := convert.ReadAll(strings.NewReader(mixedFeatures)); err == nil || err.Error() != expect {
+		t.Errorf("ReadAll got error %v, want %q", err, expect)
+	}
+
+	expectSkipped := []string{
+		`feature 2 "null": geometry is null`,
+		`feature 3 "road": geometry type "LineString" is not Polygon, MultiPolygon or GeometryCollection`,
+		`feature 4 "points": GeometryCollection has no Polygon or MultiPolygon`,
+	}
+	read := map[string]func(opts ...convert.OptionFunc) (*pb.Locations, error){
+		"Do": func(opts ...convert.OptionFunc) (*pb.Locations, error) {
+			return convert.Do(parse(t, mixedFeatures), opts...)
+		},
+		"ReadAll": func(opts ...convert.OptionFunc) (*pb.Locations, error) {
+			return convert.ReadAll(strings.NewReader(mixedFeatures), opts...)
+		},
+	}
+	for name, fn := range read {
+		var skipped []string
+		output, err := fn(convert.SetLenient(func(f convert.SkippedFeature) {
+			if !errors.Is(f.Err, convert.ErrUnsupportedGeometry) {
+				t.Errorf("%v: skipped error %v is not ErrUnsupportedGeometry", name, f.Err)
+			}
+			skipped = append(skipped, f.String())
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(output.Locations) != 2 || output.Locations[1].Name != "collection" || len(output.Locations[1].Polygons) != 2 {
+			t.Errorf("%v: got %v", name, output.Locations)
+		}
+		if strings.Join(skipped, "\n") != strings.Join(expectSkipped, "\n") {
+			t.Errorf("%v: got skipped %q, want %q", name, skipped, expectSkipped)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := convert.Do(parse(t, mixedFeatures), convert.SetLenientFlag(true, buf)); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "skipped " + strings.Join(expectSkipped, "\nskipped ") + "\n"; buf.String() != expect {
+		t.Errorf("SetLenientFlag: got %q, want %q", buf.String(), expect)
+	}
+	if _, err := convert.Do(parse(t, mixedFeatures), convert.SetLenientFlag(false, buf)); err == nil {
+		t.Error("SetLenientFlag false: got no error")
+	}
+}
+
+func TestDoRepair(t *testing.T) {
+	bowTie := `{"type": "FeatureCollection", "features": [` + feature("a") + `,
+		{"type": "Feature", "properties": {"Name": "b"}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [2, 2], [2, 0], [0, 1], [0, 0]]]}}]}`
+	var repaired []string
+	output, err := convert.Do(parse(t, bowTie), convert.SetRepair(func(issue *validate.Issue) {
+		repaired = append(repaired, issue.String())
+	}, validate.SetKinds(validate.SelfIntersection)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Locations[1].Polygons) != 2 {
+		t.Errorf("got %d polygons, want bow-tie split in 2", len(output.Locations[1].Polygons))
+	}
+	expect := `location 1 "b" polygon 0 ring 0: self-intersection at 0.6666667,0.6666667, repaired`
+	if strings.Join(repaired, "\n") != expect {
+		t.Errorf("got repaired %q, want %q", repaired, expect)
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := convert.Do(parse(t, bowTie), convert.SetRepairFlag(true, buf, validate.SetKinds(validate.SelfIntersection))); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != expect+"\n" {
+		t.Errorf("SetRepairFlag: got %q, want %q", buf.String(), expect+"\n")
+	}
+}
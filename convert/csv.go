@@ -0,0 +1,168 @@
+package convert
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// DefaultGeometryColumn is the column [CSVReader] reads geometry from if not
+// set, like ogr2ogr's GEOMETRY=AS_WKT output.
+const DefaultGeometryColumn = "WKT"
+
+// GeometryEncoding is how geometry is written in a CSV column.
+type GeometryEncoding int
+
+const (
+	// WKT is Well-known text.
+	WKT GeometryEncoding = iota
+	// HexWKB is hex encoded Well-known binary, like PostGIS's text output.
+	HexWKB
+)
+
+// isHex reports if s looks like hex encoded WKB instead of WKT.
+func isHex(s string) bool {
+	if len(s) == 0 || len(s)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseGeometry parses WKT or hex encoded WKB.
+func parseGeometry(s string) (MultiPolygonCoordinates, error) {
+	s = strings.TrimSpace(s)
+	if !isHex(s) {
+		return parseWKT(s)
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return parseWKB(b)
+}
+
+// CSVReader reads CSV rows with a WKT or hex WKB geometry column one at a
+// time. Other columns are properties for ID and name.
+type CSVReader struct {
+	r        *csv.Reader
+	opt      *Option
+	header   []string
+	geometry int
+	// index is the next row's index, header excluded.
+	index int
+}
+
+// NewCSVReader returns a reader of r whose first row is header, see [Do] and
+// [SetGeometryColumn] for opts.
+func NewCSVReader(r io.Reader, opts ...OptionFunc) (*CSVReader, error) {
+	reader := &CSVReader{
+		r:   csv.NewReader(r),
+		opt: newOption(opts...),
+	}
+	header, err := reader.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: csv header: %w", err)
+	}
+	reader.header = append([]string{}, header...)
+	reader.geometry = -1
+	for i, column := range reader.header {
+		if column == reader.opt.GeometryColumn {
+			reader.geometry = i
+			break
+		}
+	}
+	if reader.geometry < 0 {
+		return nil, fmt.Errorf("pinpoint/convert: csv has no geometry column %q", reader.opt.GeometryColumn)
+	}
+	reader.r.ReuseRecord = true
+	return reader, nil
+}
+
+// Next returns the next row's location, [io.EOF] after the last one. Errors
+// of a row name its index.
+func (r *CSVReader) Next() (*pb.Location, error) {
+	for {
+		index := r.index
+		r.index++
+		record, err := r.r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/convert: csv row %d: %w", index, err)
+		}
+		properties := &PropertiesDefine{Values: make(map[string]interface{}, len(record)-1)}
+		for i, value := range record {
+			if i != r.geometry {
+				properties.Values[r.header[i]] = value
+			}
+		}
+		location := newLocation(properties, r.opt)
+		coordinates, err := parseGeometry(record[r.geometry])
+		if r.opt.skip(index, location.Name, err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/convert: csv row %d %q: %w", index, location.Name, err)
+		}
+		project(coordinates, r.opt.Projection)
+		location.Polygons = FromGeoMultipolygonToPbPolygon(coordinates)
+		return r.opt.repair(index, location), nil
+	}
+}
+
+// ReadCSV reads all rows' locations, see [NewCSVReader].
+func ReadCSV(r io.Reader, opts ...OptionFunc) (*pb.Locations, error) {
+	reader, err := NewCSVReader(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	output := &pb.Locations{Locations: make([]*pb.Location, 0)}
+	for {
+		location, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return output, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		output.Locations = append(output.Locations, location)
+	}
+}
+
+// RevertCSV writes locations as CSV with columns "id", "name" and
+// [DefaultGeometryColumn] encoded as encoding, the reverse of [ReadCSV] with
+// SetIDProperty("id") and SetNameProperty("name").
+func RevertCSV(w io.Writer, input *pb.Locations, encoding GeometryEncoding) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{RevertIDProperty, "name", DefaultGeometryColumn}); err != nil {
+		return err
+	}
+	for _, location := range input.Locations {
+		var geometry string
+		switch encoding {
+		case WKT:
+			geometry = RevertWKT(location)
+		case HexWKB:
+			geometry = strings.ToUpper(hex.EncodeToString(RevertWKB(location)))
+		default:
+			return fmt.Errorf("pinpoint/convert: unknown geometry encoding %d", encoding)
+		}
+		if err := writer.Write([]string{location.Id, location.Name, geometry}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
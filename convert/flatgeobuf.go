@@ -0,0 +1,620 @@
+package convert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/deslittle/pinpoint/crs"
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// FlatGeobuf's magic bytes, the last one is patch version.
+var fgbMagic = []byte{'f', 'g', 'b', 3, 'f', 'g', 'b'}
+
+// FlatGeobuf header, column, feature and geometry fields, see
+// https://github.com/flatgeobuf/flatgeobuf/tree/master/src/fbs
+const (
+	fgbHeaderGeometryType  = 2
+	fgbHeaderColumns       = 7
+	fgbHeaderFeaturesCount = 8
+	fgbHeaderIndexNodeSize = 9
+	fgbHeaderCRS           = 10
+
+	fgbCRSOrg        = 0
+	fgbCRSCode       = 1
+	fgbCRSWKT        = 4
+	fgbCRSCodeString = 5
+
+	fgbColumnName = 0
+	fgbColumnType = 1
+
+	fgbFeatureGeometry   = 0
+	fgbFeatureProperties = 1
+
+	fgbGeometryEnds  = 0
+	fgbGeometryXY    = 1
+	fgbGeometryType  = 6
+	fgbGeometryParts = 7
+
+	fgbPolygon      = 3
+	fgbMultiPolygon = 6
+
+	fgbDefaultIndexNodeSize = 16
+	// fgbNodeItemBytes is a spatial index node's size, a bbox and an offset.
+	fgbNodeItemBytes = 40
+	// fgbMaxBufferBytes limits header and feature's size, corrupt files could
+	// claim GBs.
+	fgbMaxBufferBytes = 1 << 30
+)
+
+// FlatGeobuf column types.
+const (
+	fgbByte = iota
+	fgbUByte
+	fgbBool
+	fgbShort
+	fgbUShort
+	fgbInt
+	fgbUInt
+	fgbLong
+	fgbULong
+	fgbFloat
+	fgbDouble
+	fgbString
+	fgbJSON
+	fgbDateTime
+	fgbBinary
+)
+
+// fgbColumnSizes are sizes of fixed size column types, others are length
+// prefixed.
+var fgbColumnSizes = map[int]int{
+	fgbByte: 1, fgbUByte: 1, fgbBool: 1, fgbShort: 2, fgbUShort: 2,
+	fgbInt: 4, fgbUInt: 4, fgbLong: 8, fgbULong: 8, fgbFloat: 4, fgbDouble: 8,
+}
+
+var errFlatbufferOutOfRange = errors.New("flatgeobuf: offset out of range")
+
+// fbTable is a FlatBuffers table in buf at pos.
+type fbTable struct {
+	buf []byte
+	pos int
+	// vtable is the position of the table's field offsets.
+	vtable int
+}
+
+func fbUint32(buf []byte, pos int) (int, error) {
+	if pos < 0 || pos+4 > len(buf) {
+		return 0, errFlatbufferOutOfRange
+	}
+	return int(binary.LittleEndian.Uint32(buf[pos:])), nil
+}
+
+func newFBTable(buf []byte, pos int) (*fbTable, error) {
+	soffset, err := fbUint32(buf, pos)
+	if err != nil {
+		return nil, err
+	}
+	t := &fbTable{buf: buf, pos: pos, vtable: pos - int(int32(soffset))}
+	if t.vtable < 0 || t.vtable+4 > len(buf) {
+		return nil, errFlatbufferOutOfRange
+	}
+	return t, nil
+}
+
+// fbRoot returns the root table of a FlatBuffers buffer.
+func fbRoot(buf []byte) (*fbTable, error) {
+	pos, err := fbUint32(buf, 0)
+	if err != nil {
+		return nil, err
+	}
+	return newFBTable(buf, pos)
+}
+
+// field returns the position of field i, 0 if absent.
+func (t *fbTable) field(i int) (int, error) {
+	vtableSize := int(binary.LittleEndian.Uint16(t.buf[t.vtable:]))
+	at := 4 + i*2
+	if at+2 > vtableSize {
+		return 0, nil
+	}
+	if t.vtable+at+2 > len(t.buf) {
+		return 0, errFlatbufferOutOfRange
+	}
+	offset := int(binary.LittleEndian.Uint16(t.buf[t.vtable+at:]))
+	if offset == 0 {
+		return 0, nil
+	}
+	return t.pos + offset, nil
+}
+
+// scalar returns field i of size 1, 2 or 8 bytes, fallback if absent.
+func (t *fbTable) scalar(i int, size int, fallback uint64) (uint64, error) {
+	pos, err := t.field(i)
+	if err != nil || pos == 0 {
+		return fallback, err
+	}
+	if pos+size > len(t.buf) {
+		return 0, errFlatbufferOutOfRange
+	}
+	switch size {
+	case 1:
+		return uint64(t.buf[pos]), nil
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(t.buf[pos:])), nil
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(t.buf[pos:])), nil
+	case 8:
+		return binary.LittleEndian.Uint64(t.buf[pos:]), nil
+	default:
+		panic("pinpoint/convert: invalid scalar size")
+	}
+}
+
+// indirect follows field i's offset, 0 if absent.
+func (t *fbTable) indirect(i int) (int, error) {
+	pos, err := t.field(i)
+	if err != nil || pos == 0 {
+		return 0, err
+	}
+	offset, err := fbUint32(t.buf, pos)
+	if err != nil {
+		return 0, err
+	}
+	return pos + offset, nil
+}
+
+// vectorAt returns the position and length of field i's vector whose
+// elements are elemSize bytes each, 0 position if absent.
+func (t *fbTable) vectorAt(i int, elemSize int) (int, int, error) {
+	pos, err := t.indirect(i)
+	if err != nil || pos == 0 {
+		return 0, 0, err
+	}
+	n, err := fbUint32(t.buf, pos)
+	if err != nil {
+		return 0, 0, err
+	}
+	if n > (len(t.buf)-pos-4)/elemSize {
+		return 0, 0, errFlatbufferOutOfRange
+	}
+	return pos + 4, n, nil
+}
+
+// vector returns field i's elements' bytes, nil if absent.
+func (t *fbTable) vector(i int, elemSize int) ([]byte, error) {
+	start, n, err := t.vectorAt(i, elemSize)
+	if err != nil || start == 0 {
+		return nil, err
+	}
+	return t.buf[start : start+n*elemSize], nil
+}
+
+func (t *fbTable) string(i int) (string, error) {
+	b, err := t.vector(i, 1)
+	return string(b), err
+}
+
+func (t *fbTable) table(i int) (*fbTable, error) {
+	pos, err := t.indirect(i)
+	if err != nil || pos == 0 {
+		return nil, err
+	}
+	return newFBTable(t.buf, pos)
+}
+
+// tables returns field i's vector of tables, every element is an offset to
+// a table.
+func (t *fbTable) tables(i int) ([]*fbTable, error) {
+	start, n, err := t.vectorAt(i, 4)
+	if err != nil || start == 0 {
+		return nil, err
+	}
+	ret := make([]*fbTable, 0, n)
+	for j := 0; j < n; j++ {
+		pos := start + j*4
+		table, err := newFBTable(t.buf, pos+int(binary.LittleEndian.Uint32(t.buf[pos:])))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, table)
+	}
+	return ret, nil
+}
+
+type fgbColumn struct {
+	name string
+	typ  int
+}
+
+// FlatGeobufReader reads a FlatGeobuf file's polygon features one at a time,
+// spatial index is skipped.
+type FlatGeobufReader struct {
+	r       *bufio.Reader
+	opt     *Option
+	columns []fgbColumn
+	// geometryType is header's geometry type, 0 if every feature has its own.
+	geometryType int
+	// projection is opt's or header crs's projection.
+	projection crs.Projection
+	index      int
+	// left is how many features are not read, 0 if header doesn't know.
+	left uint64
+}
+
+// readFGBBuffer reads a size prefixed FlatBuffers buffer.
+func readFGBBuffer(r io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	if size > fgbMaxBufferBytes {
+		return nil, fmt.Errorf("flatgeobuf: buffer size %d too large", size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// fgbIndexSize returns packed Hilbert R-Tree's size.
+func fgbIndexSize(numItems uint64, nodeSize uint64) uint64 {
+	if nodeSize < 2 {
+		nodeSize = 2
+	}
+	n, numNodes := numItems, numItems
+	for {
+		n = (n + nodeSize - 1) / nodeSize
+		numNodes += n
+		if n <= 1 {
+			break
+		}
+	}
+	return numNodes * fgbNodeItemBytes
+}
+
+// NewFlatGeobufReader returns a reader of r, see [Do] for opts. Header's crs
+// is honored if [SetProjection] is not set, one [crs.Parse] doesn't know
+// like a WKT only crs is an error.
+func NewFlatGeobufReader(r io.Reader, opts ...OptionFunc) (*FlatGeobufReader, error) {
+	opt := newOption(opts...)
+	reader := &FlatGeobufReader{
+		r:          bufio.NewReader(r),
+		opt:        opt,
+		projection: opt.Projection,
+	}
+	if err := reader.readHeader(); err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: %w", err)
+	}
+	return reader, nil
+}
+
+func (r *FlatGeobufReader) readHeader() error {
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r.r, magic); err != nil {
+		return fmt.Errorf("flatgeobuf: magic bytes: %w", err)
+	}
+	if !bytes.Equal(magic[:len(fgbMagic)], fgbMagic) {
+		return errors.New("flatgeobuf: invalid magic bytes")
+	}
+	buf, err := readFGBBuffer(r.r)
+	if err != nil {
+		return fmt.Errorf("flatgeobuf: header: %w", err)
+	}
+	header, err := fbRoot(buf)
+	if err != nil {
+		return fmt.Errorf("flatgeobuf: header: %w", err)
+	}
+
+	geometryType, err := header.scalar(fgbHeaderGeometryType, 1, 0)
+	if err != nil {
+		return err
+	}
+	switch geometryType {
+	case 0, fgbPolygon, fgbMultiPolygon: // 0 is Unknown, every feature has type
+	default:
+		return fmt.Errorf("flatgeobuf: geometry type %d is not Polygon or MultiPolygon", geometryType)
+	}
+	r.geometryType = int(geometryType)
+	featuresCount, err := header.scalar(fgbHeaderFeaturesCount, 8, 0)
+	if err != nil {
+		return err
+	}
+	r.left = featuresCount
+	columns, err := header.tables(fgbHeaderColumns)
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		name, err := column.string(fgbColumnName)
+		if err != nil {
+			return err
+		}
+		typ, err := column.scalar(fgbColumnType, 1, 0)
+		if err != nil {
+			return err
+		}
+		r.columns = append(r.columns, fgbColumn{name: name, typ: int(typ)})
+	}
+
+	if r.projection == nil {
+		if r.projection, err = fgbProjection(header); err != nil {
+			return err
+		}
+	}
+
+	nodeSize, err := header.scalar(fgbHeaderIndexNodeSize, 2, fgbDefaultIndexNodeSize)
+	if err != nil {
+		return err
+	}
+	if nodeSize > 0 && featuresCount > 0 {
+		if featuresCount > fgbMaxBufferBytes {
+			return fmt.Errorf("flatgeobuf: features count %d too large", featuresCount)
+		}
+		size := fgbIndexSize(featuresCount, nodeSize)
+		if n, err := r.r.Discard(int(size)); err != nil {
+			return fmt.Errorf("flatgeobuf: skip %d bytes of index, got %d: %w", size, n, err)
+		}
+	}
+	return nil
+}
+
+// fgbProjection returns projection of header's crs, nil if it has none.
+func fgbProjection(header *fbTable) (crs.Projection, error) {
+	define, err := header.table(fgbHeaderCRS)
+	if err != nil || define == nil {
+		return nil, err
+	}
+	org, err := define.string(fgbCRSOrg)
+	if err != nil {
+		return nil, err
+	}
+	if org == "" {
+		org = "EPSG"
+	}
+	code, err := define.scalar(fgbCRSCode, 4, 0)
+	if err != nil {
+		return nil, err
+	}
+	name := ""
+	if code != 0 {
+		name = org + ":" + strconv.Itoa(int(int32(code)))
+	} else if name, err = define.string(fgbCRSCodeString); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		wkt, err := define.string(fgbCRSWKT)
+		if err != nil || wkt == "" {
+			return nil, err
+		}
+		return nil, errors.New("flatgeobuf: crs has only WKT, set its projection like -crs instead")
+	}
+	projection, err := crs.Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("flatgeobuf: crs: %w, set its projection like -crs instead", err)
+	}
+	return projection, nil
+}
+
+// Next returns the next feature's location, [io.EOF] after the last one.
+// Errors of a feature name its index.
+func (r *FlatGeobufReader) Next() (*pb.Location, error) {
+	for {
+		index := r.index
+		r.index++
+		buf, err := readFGBBuffer(r.r)
+		if err == io.EOF {
+			if r.left > 0 {
+				return nil, fmt.Errorf("pinpoint/convert: got %d features, header has %d", index, uint64(index)+r.left)
+			}
+			return nil, io.EOF
+		}
+		if r.left > 0 {
+			r.left--
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/convert: feature %d: %w", index, err)
+		}
+		location, err := r.feature(buf)
+		if err == nil {
+			return r.opt.repair(index, location), nil
+		}
+		if location == nil || !r.opt.skip(index, location.Name, err) {
+			return nil, fmt.Errorf("pinpoint/convert: %w", featureError(index, location, err))
+		}
+	}
+}
+
+// feature returns feature's location, location is returned with error if
+// its properties are read.
+func (r *FlatGeobufReader) feature(buf []byte) (*pb.Location, error) {
+	feature, err := fbRoot(buf)
+	if err != nil {
+		return nil, err
+	}
+	propertiesBytes, err := feature.vector(fgbFeatureProperties, 1)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := r.properties(propertiesBytes)
+	if err != nil {
+		return nil, err
+	}
+	location := newLocation(properties, r.opt)
+
+	geometry, err := feature.table(fgbFeatureGeometry)
+	if err != nil {
+		return location, err
+	}
+	if geometry == nil {
+		return location, unsupportedGeometry("flatgeobuf: feature has no geometry")
+	}
+	location.Polygons, err = r.geometry(geometry, r.geometryType)
+	return location, err
+}
+
+func (r *FlatGeobufReader) properties(b []byte) (*PropertiesDefine, error) {
+	properties := &PropertiesDefine{Values: make(map[string]interface{}, len(r.columns))}
+	take := func(n int) ([]byte, error) {
+		if n > len(b) {
+			return nil, errors.New("flatgeobuf: truncated properties")
+		}
+		ret := b[:n]
+		b = b[n:]
+		return ret, nil
+	}
+	for len(b) > 0 {
+		column, err := take(2)
+		if err != nil {
+			return nil, err
+		}
+		i := int(binary.LittleEndian.Uint16(column))
+		if i >= len(r.columns) {
+			return nil, fmt.Errorf("flatgeobuf: column %d out of %d columns", i, len(r.columns))
+		}
+		var value interface{}
+		size, ok := fgbColumnSizes[r.columns[i].typ]
+		if !ok {
+			prefix, err := take(4)
+			if err != nil {
+				return nil, err
+			}
+			size = int(binary.LittleEndian.Uint32(prefix))
+		}
+		v, err := take(size)
+		if err != nil {
+			return nil, err
+		}
+		switch r.columns[i].typ {
+		case fgbByte:
+			value = float64(int8(v[0]))
+		case fgbUByte:
+			value = float64(v[0])
+		case fgbBool:
+			value = v[0] != 0
+		case fgbShort:
+			value = float64(int16(binary.LittleEndian.Uint16(v)))
+		case fgbUShort:
+			value = float64(binary.LittleEndian.Uint16(v))
+		case fgbInt:
+			value = float64(int32(binary.LittleEndian.Uint32(v)))
+		case fgbUInt:
+			value = float64(binary.LittleEndian.Uint32(v))
+		case fgbLong:
+			value = strconv.FormatInt(int64(binary.LittleEndian.Uint64(v)), 10)
+		case fgbULong:
+			value = strconv.FormatUint(binary.LittleEndian.Uint64(v), 10)
+		case fgbFloat:
+			value = float64(math.Float32frombits(binary.LittleEndian.Uint32(v)))
+		case fgbDouble:
+			value = math.Float64frombits(binary.LittleEndian.Uint64(v))
+		default:
+			value = string(v)
+		}
+		properties.Values[r.columns[i].name] = value
+	}
+	properties.Name, _ = properties.Values["Name"].(string)
+	return properties, nil
+}
+
+// polygon reads a Polygon geometry, ends are rings' end indexes in points.
+func (r *FlatGeobufReader) polygon(geometry *fbTable) (*pb.Polygon, error) {
+	xy, err := geometry.vector(fgbGeometryXY, 8)
+	if err != nil {
+		return nil, err
+	}
+	ends, err := geometry.vector(fgbGeometryEnds, 4)
+	if err != nil {
+		return nil, err
+	}
+	numPoints := len(xy) / 16
+	if len(ends) == 0 {
+		ends = binary.LittleEndian.AppendUint32(nil, uint32(numPoints))
+	}
+	polygon := &pb.Polygon{Holes: make([]*pb.Polygon, 0)}
+	start := 0
+	for at := 0; at < len(ends); at += 4 {
+		end := int(binary.LittleEndian.Uint32(ends[at:]))
+		if end < start || end > numPoints {
+			return nil, fmt.Errorf("flatgeobuf: ring end %d out of [%d, %d]", end, start, numPoints)
+		}
+		ring := make([]*pb.Point, 0, end-start)
+		for i := start; i < end; i++ {
+			x := math.Float64frombits(binary.LittleEndian.Uint64(xy[i*16:]))
+			y := math.Float64frombits(binary.LittleEndian.Uint64(xy[i*16+8:]))
+			if r.projection != nil {
+				x, y = r.projection.Inverse(x, y)
+			}
+			ring = append(ring, &pb.Point{Lng: float32(x), Lat: float32(y)})
+		}
+		start = end
+		if at == 0 {
+			polygon.Points = ring
+			continue
+		}
+		polygon.Holes = append(polygon.Holes, &pb.Polygon{Points: ring})
+	}
+	return polygon, nil
+}
+
+// geometry reads a Polygon or MultiPolygon, typ is header's geometry type
+// which is 0 if every geometry has its own type.
+func (r *FlatGeobufReader) geometry(geometry *fbTable, typ int) ([]*pb.Polygon, error) {
+	if typ == 0 {
+		v, err := geometry.scalar(fgbGeometryType, 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		typ = int(v)
+	}
+	switch typ {
+	case fgbPolygon:
+		polygon, err := r.polygon(geometry)
+		if err != nil {
+			return nil, err
+		}
+		return []*pb.Polygon{polygon}, nil
+	case fgbMultiPolygon:
+		parts, err := geometry.tables(fgbGeometryParts)
+		if err != nil {
+			return nil, err
+		}
+		polygons := make([]*pb.Polygon, 0, len(parts))
+		for _, part := range parts {
+			polygon, err := r.polygon(part)
+			if err != nil {
+				return nil, err
+			}
+			polygons = append(polygons, polygon)
+		}
+		return polygons, nil
+	default:
+		return nil, unsupportedGeometry("flatgeobuf: geometry type %d is not Polygon or MultiPolygon", typ)
+	}
+}
+
+// ReadFlatGeobuf reads all features' locations, see [NewFlatGeobufReader].
+func ReadFlatGeobuf(r io.Reader, opts ...OptionFunc) (*pb.Locations, error) {
+	reader, err := NewFlatGeobufReader(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	output := &pb.Locations{Locations: make([]*pb.Location, 0)}
+	for {
+		location, err := reader.Next()
+		if err == io.EOF {
+			return output, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		output.Locations = append(output.Locations, location)
+	}
+}
@@ -0,0 +1,383 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/deslittle/pinpoint/pb"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Geobuf field numbers and geometry types, see
+// https://github.com/mapbox/geobuf/blob/master/geobuf.proto
+const (
+	geobufKeys              = 1
+	geobufDimensions        = 2
+	geobufPrecision         = 3
+	geobufFeatureCollection = 4
+	geobufFeature           = 5
+	geobufGeometry          = 6
+
+	geobufFeatures = 1
+
+	geobufFeatureGeometry   = 1
+	geobufFeatureID         = 11
+	geobufFeatureIntID      = 12
+	geobufFeatureValues     = 13
+	geobufFeatureProperties = 14
+
+	geobufGeometryType       = 1
+	geobufGeometryLengths    = 2
+	geobufGeometryCoords     = 3
+	geobufGeometryGeometries = 4
+
+	geobufPolygon            = 4
+	geobufMultiPolygon       = 5
+	geobufGeometryCollection = 6
+)
+
+var errGeobufTruncated = errors.New("geobuf: truncated")
+
+// geobufFields calls fn with every field of message, value is the field's
+// bytes for BytesType and varint or fixed value for others.
+func geobufFields(message []byte, fn func(num protowire.Number, typ protowire.Type, value uint64, b []byte) error) error {
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return errGeobufTruncated
+		}
+		message = message[n:]
+		var value uint64
+		var b []byte
+		switch typ {
+		case protowire.VarintType:
+			value, n = protowire.ConsumeVarint(message)
+		case protowire.Fixed64Type:
+			value, n = protowire.ConsumeFixed64(message)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(message)
+			value = uint64(v)
+		case protowire.BytesType:
+			b, n = protowire.ConsumeBytes(message)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, message)
+		}
+		if n < 0 {
+			return errGeobufTruncated
+		}
+		message = message[n:]
+		if err := fn(num, typ, value, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendPackedVarints appends a packed or single varint field to dst.
+func appendPackedVarints(dst []uint64, typ protowire.Type, value uint64, b []byte) ([]uint64, error) {
+	if typ == protowire.VarintType {
+		return append(dst, value), nil
+	}
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, errGeobufTruncated
+		}
+		dst = append(dst, v)
+		b = b[n:]
+	}
+	return dst, nil
+}
+
+type geobufReader struct {
+	keys       []string
+	dimensions int
+	precision  float64
+	opt        *Option
+}
+
+func (r *geobufReader) value(message []byte) (interface{}, error) {
+	var ret interface{}
+	err := geobufFields(message, func(num protowire.Number, typ protowire.Type, value uint64, b []byte) error {
+		switch num {
+		case 1, 6: // string and json
+			ret = string(b)
+		case 2:
+			ret = math.Float64frombits(value)
+		case 3:
+			ret = float64(value)
+		case 4:
+			ret = -float64(value)
+		case 5:
+			ret = value != 0
+		}
+		return nil
+	})
+	return ret, err
+}
+
+// featureError names a feature by its index and name if known.
+func featureError(index int, location *pb.Location, err error) error {
+	if location == nil {
+		return fmt.Errorf("feature %d: %w", index, err)
+	}
+	return fmt.Errorf("feature %d %q: %w", index, location.Name, err)
+}
+
+// ring converts delta-encoded coordinates to points, closed ring's last point
+// is omitted in Geobuf and added back.
+func (r *geobufReader) ring(coords []int64) []*pb.Point {
+	points := make([]*pb.Point, 0, len(coords)/r.dimensions+1)
+	sum := make([]int64, r.dimensions)
+	for i := 0; i+r.dimensions <= len(coords); i += r.dimensions {
+		for j := range sum {
+			sum[j] += coords[i+j]
+		}
+		x, y := float64(sum[0])/r.precision, float64(sum[1])/r.precision
+		if r.opt.Projection != nil {
+			x, y = r.opt.Projection.Inverse(x, y)
+		}
+		points = append(points, &pb.Point{Lng: float32(x), Lat: float32(y)})
+	}
+	if len(points) > 0 {
+		points = append(points, &pb.Point{Lng: points[0].Lng, Lat: points[0].Lat})
+	}
+	return points
+}
+
+func (r *geobufReader) geometry(message []byte) ([]*pb.Polygon, error) {
+	typ := 0
+	var lengths []uint64
+	var coords []int64
+	var members [][]byte
+	err := geobufFields(message, func(num protowire.Number, wireType protowire.Type, value uint64, b []byte) error {
+		var err error
+		switch num {
+		case geobufGeometryType:
+			typ = int(value)
+		case geobufGeometryLengths:
+			lengths, err = appendPackedVarints(lengths, wireType, value, b)
+		case geobufGeometryCoords:
+			var values []uint64
+			values, err = appendPackedVarints(nil, wireType, value, b)
+			for _, v := range values {
+				coords = append(coords, protowire.DecodeZigZag(v))
+			}
+		case geobufGeometryGeometries:
+			members = append(members, b)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// takeRing returns next ring of n points.
+	takeRing := func(n uint64) ([]*pb.Point, error) {
+		if n > uint64(len(coords)/r.dimensions) {
+			return nil, errGeobufTruncated
+		}
+		size := n * uint64(r.dimensions)
+		ring := r.ring(coords[:size])
+		coords = coords[size:]
+		return ring, nil
+	}
+	polygons := make([]*pb.Polygon, 0)
+	switch typ {
+	case geobufPolygon:
+		if len(lengths) == 0 {
+			lengths = []uint64{uint64(len(coords) / r.dimensions)}
+		}
+		polygon := &pb.Polygon{Holes: make([]*pb.Polygon, 0)}
+		for i, n := range lengths {
+			ring, err := takeRing(n)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				polygon.Points = ring
+				continue
+			}
+			polygon.Holes = append(polygon.Holes, &pb.Polygon{Points: ring})
+		}
+		polygons = append(polygons, polygon)
+	case geobufMultiPolygon:
+		// number of polygons, then every polygon's number of rings and
+		// rings' lengths, omitted for a single ring
+		if len(lengths) == 0 {
+			lengths = []uint64{1, 1, uint64(len(coords) / r.dimensions)}
+		}
+		next := func() (uint64, error) {
+			if len(lengths) == 0 {
+				return 0, errGeobufTruncated
+			}
+			v := lengths[0]
+			lengths = lengths[1:]
+			return v, nil
+		}
+		numPolygons, err := next()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < numPolygons; i++ {
+			numRings, err := next()
+			if err != nil {
+				return nil, err
+			}
+			polygon := &pb.Polygon{Holes: make([]*pb.Polygon, 0)}
+			for j := uint64(0); j < numRings; j++ {
+				n, err := next()
+				if err != nil {
+					return nil, err
+				}
+				ring, err := takeRing(n)
+				if err != nil {
+					return nil, err
+				}
+				if j == 0 {
+					polygon.Points = ring
+					continue
+				}
+				polygon.Holes = append(polygon.Holes, &pb.Polygon{Points: ring})
+			}
+			polygons = append(polygons, polygon)
+		}
+	case geobufGeometryCollection:
+		// like GeoJSON, members without polygons are ignored
+		for i, member := range members {
+			memberPolygons, err := r.geometry(member)
+			if errors.Is(err, ErrUnsupportedGeometry) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("geometries[%d]: %w", i, err)
+			}
+			polygons = append(polygons, memberPolygons...)
+		}
+		if len(polygons) == 0 {
+			return nil, unsupportedGeometry("geobuf: GeometryCollection has no Polygon or MultiPolygon")
+		}
+	default:
+		return nil, unsupportedGeometry("geobuf: geometry type %d is not Polygon, MultiPolygon or GeometryCollection", typ)
+	}
+	return polygons, nil
+}
+
+// feature returns feature's location, location is returned with error if
+// its properties are read.
+func (r *geobufReader) feature(message []byte) (*pb.Location, error) {
+	properties := &PropertiesDefine{Values: map[string]interface{}{}}
+	var geometry []byte
+	var values []interface{}
+	var pairs []uint64
+	var id interface{}
+	err := geobufFields(message, func(num protowire.Number, typ protowire.Type, value uint64, b []byte) error {
+		var err error
+		switch num {
+		case geobufFeatureGeometry:
+			geometry = b
+		case geobufFeatureID:
+			id = string(b)
+		case geobufFeatureIntID:
+			id = strconv.FormatInt(protowire.DecodeZigZag(value), 10)
+		case geobufFeatureValues:
+			var v interface{}
+			v, err = r.value(b)
+			values = append(values, v)
+		case geobufFeatureProperties:
+			pairs, err = appendPackedVarints(pairs, typ, value, b)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i] >= uint64(len(r.keys)) || pairs[i+1] >= uint64(len(values)) {
+			return nil, fmt.Errorf("geobuf: property index out of range")
+		}
+		properties.Values[r.keys[pairs[i]]] = values[pairs[i+1]]
+	}
+	if _, ok := properties.Values[RevertIDProperty]; !ok && id != nil {
+		properties.Values[RevertIDProperty] = id
+	}
+	properties.Name, _ = properties.Values["Name"].(string)
+
+	location := newLocation(properties, r.opt)
+	if geometry == nil {
+		return location, unsupportedGeometry("geobuf: feature has no geometry")
+	}
+	location.Polygons, err = r.geometry(geometry)
+	return location, err
+}
+
+// ReadGeobuf reads a Geobuf FeatureCollection, Feature or Geometry's
+// locations, see [Do] for opts.
+//
+// Like GeoJSON, feature's id is read as property "id" unless properties have
+// it.
+func ReadGeobuf(data []byte, opts ...OptionFunc) (*pb.Locations, error) {
+	r := &geobufReader{
+		dimensions: 2,
+		precision:  1e6,
+		opt:        newOption(opts...),
+	}
+	output := &pb.Locations{Locations: make([]*pb.Location, 0)}
+	err := geobufFields(data, func(num protowire.Number, typ protowire.Type, value uint64, b []byte) error {
+		switch num {
+		case geobufKeys:
+			r.keys = append(r.keys, string(b))
+		case geobufDimensions:
+			if value < 2 || value > 4 {
+				return fmt.Errorf("geobuf: invalid dimensions %d", value)
+			}
+			r.dimensions = int(value)
+		case geobufPrecision:
+			if value > 15 {
+				return fmt.Errorf("geobuf: invalid precision %d", value)
+			}
+			r.precision = math.Pow10(int(value))
+		case geobufFeatureCollection:
+			index := 0
+			return geobufFields(b, func(num protowire.Number, _ protowire.Type, _ uint64, b []byte) error {
+				if num != geobufFeatures {
+					return nil
+				}
+				i := index
+				index++
+				location, err := r.feature(b)
+				if location != nil && r.opt.skip(i, location.Name, err) {
+					return nil
+				}
+				if err != nil {
+					return featureError(i, location, err)
+				}
+				output.Locations = append(output.Locations, r.opt.repair(i, location))
+				return nil
+			})
+		case geobufFeature:
+			location, err := r.feature(b)
+			if location != nil && r.opt.skip(0, location.Name, err) {
+				return nil
+			}
+			if err != nil {
+				return featureError(0, location, err)
+			}
+			output.Locations = append(output.Locations, r.opt.repair(0, location))
+		case geobufGeometry:
+			polygons, err := r.geometry(b)
+			if err != nil {
+				return err
+			}
+			output.Locations = append(output.Locations, r.opt.repair(0, &pb.Location{Polygons: polygons}))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,106 @@
+package convert_test
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/crs"
+	"github.com/deslittle/pinpoint/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// testdata/squares.pbf and squares.fgb have the same features as
+// squares.json: a polygon with a hole, and a multipolygon.
+func squaresFixture(t *testing.T) *pb.Locations {
+	data, err := os.ReadFile("testdata/squares.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := convert.ReadAll(bytes.NewReader(data), convert.SetIDProperty("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expect
+}
+
+func TestReadGeobuf(t *testing.T) {
+	expect := squaresFixture(t)
+	data, err := os.ReadFile("testdata/squares.pbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := convert.ReadGeobuf(data, convert.SetIDProperty("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(expect, got) {
+		t.Errorf("got %v, want %v", got, expect)
+	}
+
+	// Geobuf has no count, truncated at a field's end loses features
+	for i := 0; i < len(data); i++ {
+		if got, err := convert.ReadGeobuf(data[:i], convert.SetIDProperty("id")); err == nil && proto.Equal(expect, got) {
+			t.Errorf("truncated at %d: got same locations", i)
+		}
+	}
+}
+
+func TestReadFlatGeobuf(t *testing.T) {
+	expect := squaresFixture(t)
+	data, err := os.ReadFile("testdata/squares.fgb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := convert.ReadFlatGeobuf(bytes.NewReader(data), convert.SetIDProperty("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(expect, got) {
+		t.Errorf("got %v, want %v", got, expect)
+	}
+
+	for i := 0; i < len(data); i++ {
+		if _, err := convert.ReadFlatGeobuf(bytes.NewReader(data[:i])); err == nil {
+			t.Errorf("truncated at %d: got no error", i)
+		}
+	}
+	if _, err := convert.ReadFlatGeobuf(strings.NewReader("not a flatgeobuf")); err == nil {
+		t.Error("invalid magic bytes: got no error")
+	}
+}
+
+// testdata/squares-3857.fgb is squares.fgb in Web Mercator with header's crs
+// EPSG:3857.
+func TestReadFlatGeobufCRS(t *testing.T) {
+	expect := squaresFixture(t)
+	data, err := os.ReadFile("testdata/squares-3857.fgb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := convert.ReadFlatGeobuf(bytes.NewReader(data), convert.SetIDProperty("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, location := range got.Locations {
+		for j, polygon := range location.Polygons {
+			want := expect.Locations[i].Polygons[j]
+			for k, point := range polygon.Points {
+				if math.Abs(float64(point.Lng-want.Points[k].Lng)) > 1e-5 || math.Abs(float64(point.Lat-want.Points[k].Lat)) > 1e-5 {
+					t.Errorf("%v: got %v, want %v", location.Name, point, want.Points[k])
+				}
+			}
+		}
+	}
+
+	got, err = convert.ReadFlatGeobuf(bytes.NewReader(data), convert.SetProjection(crs.LngLat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lng := got.Locations[0].Polygons[0].Points[1].Lng; lng < 4e5 {
+		t.Errorf("SetProjection: got lng %v, want header's crs overridden", lng)
+	}
+}
@@ -0,0 +1,223 @@
+package convert
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+type kmlPolygon struct {
+	Outer string `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	// Inner could have many LinearRings in an innerBoundaryIs, or many
+	// innerBoundaryIs.
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlMultiGeometry struct {
+	Polygons      []kmlPolygon       `xml:"Polygon"`
+	MultiGeometry []kmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+type kmlPlacemark struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name"`
+	Data []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	} `xml:"ExtendedData>Data"`
+	SimpleData []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"ExtendedData>SchemaData>SimpleData"`
+	kmlMultiGeometry
+}
+
+// allPolygons returns polygons of geometry and nested MultiGeometry.
+func (g *kmlMultiGeometry) allPolygons() []kmlPolygon {
+	ret := append([]kmlPolygon{}, g.Polygons...)
+	for i := range g.MultiGeometry {
+		ret = append(ret, g.MultiGeometry[i].allPolygons()...)
+	}
+	return ret
+}
+
+func (p *kmlPlacemark) properties() *PropertiesDefine {
+	properties := &PropertiesDefine{Values: map[string]interface{}{}}
+	for _, data := range p.Data {
+		properties.Values[data.Name] = strings.TrimSpace(data.Value)
+	}
+	for _, data := range p.SimpleData {
+		properties.Values[data.Name] = strings.TrimSpace(data.Value)
+	}
+	if _, ok := properties.Values[RevertIDProperty]; !ok && p.ID != "" {
+		properties.Values[RevertIDProperty] = p.ID
+	}
+	if name := strings.TrimSpace(p.Name); name != "" {
+		properties.Name = name
+		properties.Values["Name"] = name
+	} else {
+		properties.Name, _ = properties.Values["Name"].(string)
+	}
+	return properties
+}
+
+// parseKMLCoordinates parses "lng,lat[,alt]" tuples separated by spaces.
+func parseKMLCoordinates(s string) ([]*pb.Point, error) {
+	tuples := strings.Fields(s)
+	points := make([]*pb.Point, 0, len(tuples))
+	for _, tuple := range tuples {
+		values := strings.Split(tuple, ",")
+		if len(values) < 2 {
+			return nil, fmt.Errorf("kml: invalid coordinates %q", tuple)
+		}
+		lng, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("kml: invalid coordinates %q", tuple)
+		}
+		lat, err := strconv.ParseFloat(values[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("kml: invalid coordinates %q", tuple)
+		}
+		points = append(points, &pb.Point{Lng: float32(lng), Lat: float32(lat)})
+	}
+	return points, nil
+}
+
+func (p *kmlPlacemark) polygons() ([]*pb.Polygon, error) {
+	polygons := make([]*pb.Polygon, 0)
+	for _, polygon := range p.allPolygons() {
+		points, err := parseKMLCoordinates(polygon.Outer)
+		if err != nil {
+			return nil, err
+		}
+		newPolygon := &pb.Polygon{Points: points, Holes: make([]*pb.Polygon, 0)}
+		for _, inner := range polygon.Inner {
+			points, err := parseKMLCoordinates(inner)
+			if err != nil {
+				return nil, err
+			}
+			newPolygon.Holes = append(newPolygon.Holes, &pb.Polygon{Points: points})
+		}
+		polygons = append(polygons, newPolygon)
+	}
+	return polygons, nil
+}
+
+// KMLReader reads KML Placemarks with polygons one at a time, Placemarks in
+// any Document or Folder are read.
+//
+// Polygons in MultiGeometry are read and other geometries, like Point, are
+// ignored. A Placemark without polygon is [ErrUnsupportedGeometry], skipped
+// if [SetLenient] is set.
+type KMLReader struct {
+	dec *xml.Decoder
+	opt *Option
+	// index is the next Placemark's index, skipped ones included.
+	index int
+}
+
+// NewKMLReader returns a reader of r, see [Do] for opts.
+//
+// Location's name is Placemark's name, or a field of ExtendedData set by
+// [SetNameProperty], Placemark's id attribute is property "id".
+func NewKMLReader(r io.Reader, opts ...OptionFunc) *KMLReader {
+	return &KMLReader{
+		dec: xml.NewDecoder(r),
+		opt: newOption(opts...),
+	}
+}
+
+// Next returns the next Placemark's location, [io.EOF] after the last one.
+// Errors of a Placemark name its index.
+func (r *KMLReader) Next() (*pb.Location, error) {
+	for {
+		token, err := r.dec.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/convert: kml: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+
+		index := r.index
+		r.index++
+		placemark := &kmlPlacemark{}
+		if err := r.dec.DecodeElement(placemark, &start); err != nil {
+			return nil, fmt.Errorf("pinpoint/convert: placemark %d: kml: %w", index, err)
+		}
+		location := newLocation(placemark.properties(), r.opt)
+		if location.Polygons, err = placemark.polygons(); err == nil && len(location.Polygons) == 0 {
+			err = unsupportedGeometry("kml: placemark has no Polygon")
+		}
+		if err == nil {
+			return r.opt.repair(index, location), nil
+		}
+		if !r.opt.skip(index, location.Name, err) {
+			return nil, fmt.Errorf("pinpoint/convert: placemark %d %q: %w", index, location.Name, err)
+		}
+	}
+}
+
+// ReadKML reads all Placemarks' locations, see [NewKMLReader].
+func ReadKML(r io.Reader, opts ...OptionFunc) (*pb.Locations, error) {
+	reader := NewKMLReader(r, opts...)
+	output := &pb.Locations{Locations: make([]*pb.Location, 0)}
+	for {
+		location, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return output, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		output.Locations = append(output.Locations, location)
+	}
+}
+
+// OpenKMZDocument opens KMZ's main KML file, doc.kml at root or the first
+// .kml file like Google Earth does.
+func OpenKMZDocument(zr *zip.Reader) (io.ReadCloser, error) {
+	var doc *zip.File
+	for _, f := range zr.File {
+		if !strings.EqualFold(path.Ext(f.Name), ".kml") {
+			continue
+		}
+		if f.Name == "doc.kml" {
+			doc = f
+			break
+		}
+		if doc == nil {
+			doc = f
+		}
+	}
+	if doc == nil {
+		return nil, errors.New("pinpoint/convert: kmz has no .kml file")
+	}
+	return doc.Open()
+}
+
+// ReadKMZ reads all Placemarks' locations of a KMZ's main KML file, see
+// [OpenKMZDocument] and [NewKMLReader].
+func ReadKMZ(r io.ReaderAt, size int64, opts ...OptionFunc) (*pb.Locations, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: kmz: %w", err)
+	}
+	doc, err := OpenKMZDocument(zr)
+	if err != nil {
+		return nil, err
+	}
+	defer doc.Close()
+	return ReadKML(doc, opts...)
+}
@@ -0,0 +1,134 @@
+package convert_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/pb"
+)
+
+const territoriesKML = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+<Document>
+  <name>Territories</name>
+  <Folder>
+    <Placemark id="t1">
+      <name>North</name>
+      <ExtendedData>
+        <Data name="territory"><value>T-1</value></Data>
+      </ExtendedData>
+      <Polygon>
+        <outerBoundaryIs><LinearRing><coordinates>
+          0,0,0 4,0,0 4,4,0 0,4,0 0,0,0
+        </coordinates></LinearRing></outerBoundaryIs>
+        <innerBoundaryIs><LinearRing><coordinates>1,1 1,2 2,2 1,1</coordinates></LinearRing></innerBoundaryIs>
+      </Polygon>
+    </Placemark>
+    <Placemark>
+      <name>Office</name>
+      <Point><coordinates>1,1</coordinates></Point>
+    </Placemark>
+  </Folder>
+  <Placemark>
+    <ExtendedData>
+      <SchemaData schemaUrl="#s"><SimpleData name="territory">T-2</SimpleData></SchemaData>
+    </ExtendedData>
+    <MultiGeometry>
+      <Point><coordinates>10.5,0.5</coordinates></Point>
+      <Polygon><outerBoundaryIs><LinearRing><coordinates>10,0 11,0 11,1 10,0</coordinates></LinearRing></outerBoundaryIs></Polygon>
+      <MultiGeometry>
+        <Polygon><outerBoundaryIs><LinearRing><coordinates>12,0 13,0 13,1 12,0</coordinates></LinearRing></outerBoundaryIs></Polygon>
+      </MultiGeometry>
+    </MultiGeometry>
+  </Placemark>
+</Document>
+</kml>`
+
+func checkTerritories(t *testing.T, output *pb.Locations) {
+	expect := []struct {
+		id, name, wkt string
+	}{
+		{"t1", "T-1", "MULTIPOLYGON (((0 0, 4 0, 4 4, 0 4, 0 0), (1 1, 1 2, 2 2, 1 1)))"},
+		{"", "T-2", "MULTIPOLYGON (((10 0, 11 0, 11 1, 10 0)), ((12 0, 13 0, 13 1, 12 0)))"},
+	}
+	if len(output.Locations) != len(expect) {
+		t.Fatalf("got %d locations, want %d", len(output.Locations), len(expect))
+	}
+	for i, location := range output.Locations {
+		if location.Id != expect[i].id || location.Name != expect[i].name {
+			t.Errorf("location %d: got id %q name %q", i, location.Id, location.Name)
+		}
+		if got := convert.RevertWKT(location); got != expect[i].wkt {
+			t.Errorf("location %d: got %v, want %v", i, got, expect[i].wkt)
+		}
+	}
+}
+
+func TestReadKML(t *testing.T) {
+	var skipped []string
+	output, err := convert.ReadKML(strings.NewReader(territoriesKML),
+		convert.SetIDProperty("id"),
+		convert.SetNameProperty("territory"),
+		convert.SetLenient(func(f convert.SkippedFeature) {
+			skipped = append(skipped, f.String())
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTerritories(t, output)
+	if expect := `feature 1 "": kml: placemark has no Polygon`; strings.Join(skipped, "\n") != expect {
+		t.Errorf("got skipped %q, want %q", skipped, expect)
+	}
+
+	_, err = convert.ReadKML(strings.NewReader(territoriesKML))
+	if msg := `pinpoint/convert: placemark 1 "Office": kml: placemark has no Polygon`; err == nil || err.Error() != msg {
+		t.Errorf("got error %v, want %v", err, msg)
+	}
+
+	// Placemark's name is the default
+	output, err = convert.ReadKML(strings.NewReader(territoriesKML), convert.SetLenient(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Locations[0].Name != "North" || output.Locations[1].Name != "" {
+		t.Errorf("got names %q and %q", output.Locations[0].Name, output.Locations[1].Name)
+	}
+
+	_, err = convert.ReadKML(strings.NewReader(strings.Replace(territoriesKML, "4,4,0", "4;4", 1)))
+	if msg := `pinpoint/convert: placemark 0 "North": kml: invalid coordinates "4;4"`; err == nil || err.Error() != msg {
+		t.Errorf("got error %v, want %v", err, msg)
+	}
+}
+
+func TestReadKMZ(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range map[string]string{
+		"files/overlay.png": "png",
+		"doc.kml":           territoriesKML,
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	output, err := convert.ReadKMZ(bytes.NewReader(buf.Bytes()), int64(buf.Len()),
+		convert.SetIDProperty("id"),
+		convert.SetNameProperty("territory"),
+		convert.SetLenient(nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTerritories(t, output)
+}
@@ -0,0 +1,50 @@
+package convert
+
+import (
+	"flag"
+	"time"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// MetadataFlags are converters' flags of dataset's [pb.Metadata].
+type MetadataFlags struct {
+	Dataset   string
+	Version   string
+	SourceURL string
+	License   string
+}
+
+// NewMetadataFlags defines -dataset, -version, -source-url and -license on
+// fs.
+func NewMetadataFlags(fs *flag.FlagSet) *MetadataFlags {
+	f := &MetadataFlags{}
+	fs.StringVar(&f.Dataset, "dataset", "", "dataset name recorded in metadata, like us-states")
+	fs.StringVar(&f.Version, "version", "", "dataset version or vintage recorded in metadata, like 2022")
+	fs.StringVar(&f.SourceURL, "source-url", "", "where the source data came from, recorded in metadata")
+	fs.StringVar(&f.License, "license", "", "source data's license recorded in metadata")
+	return f
+}
+
+// Metadata returns metadata of the dataset created now, with a step of tool
+// and fs's flags.
+func (f *MetadataFlags) Metadata(tool string, fs *flag.FlagSet) *pb.Metadata {
+	metadata := pb.NewMetadata()
+	metadata.Name = f.Dataset
+	metadata.Version = f.Version
+	metadata.SourceUrl = f.SourceURL
+	metadata.License = f.License
+	metadata.Created = time.Now().Unix()
+	AddStepFromFlags(metadata, tool, fs)
+	return metadata
+}
+
+// AddStepFromFlags appends a step of tool ran now with every flag's value of
+// fs as parameters to m's steps.
+func AddStepFromFlags(m *pb.Metadata, tool string, fs *flag.FlagSet) {
+	parameters := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		parameters[f.Name] = f.Value.String()
+	})
+	m.AddStep(tool, parameters)
+}
@@ -0,0 +1,184 @@
+package convert
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/deslittle/pinpoint/crs"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/validate"
+)
+
+// DefaultNameProperty is the property [Do] reads name from if not set.
+const DefaultNameProperty = "Name"
+
+type Option struct {
+	// IDProperties are properties tried in order for location's stable ID,
+	// like "GEOID". Empty means no ID.
+	IDProperties []string
+	// NameProperties are properties tried in order for location's
+	// human-readable name, default is [DefaultNameProperty]. If none of them
+	// is present, ID is used as name.
+	NameProperties []string
+	// GeometryColumn is the CSV column with WKT or hex WKB geometry, default
+	// is [DefaultGeometryColumn].
+	GeometryColumn string
+	// Projection reprojects source coordinates to WGS84, nil means they are
+	// longitude and latitude unless the source names its CRS, like GeoJSON's
+	// crs member.
+	Projection crs.Projection
+	// Lenient skips features of [ErrUnsupportedGeometry] instead of failing,
+	// OnSkip is called with every skipped one if not nil.
+	Lenient bool
+	OnSkip  func(SkippedFeature)
+	// Repair is called with every location and its feature index before
+	// it's returned if not nil, see [SetRepair].
+	Repair func(index int, location *pb.Location)
+}
+
+type OptionFunc = func(opt *Option)
+
+func newOption(opts ...OptionFunc) *Option {
+	opt := &Option{
+		NameProperties: []string{DefaultNameProperty},
+		GeometryColumn: DefaultGeometryColumn,
+	}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+	return opt
+}
+
+// SetIDProperty sets properties tried in order for location's stable ID.
+func SetIDProperty(keys ...string) OptionFunc {
+	return func(opt *Option) {
+		opt.IDProperties = keys
+	}
+}
+
+// SetNameProperty sets properties tried in order for location's name.
+func SetNameProperty(keys ...string) OptionFunc {
+	return func(opt *Option) {
+		opt.NameProperties = keys
+	}
+}
+
+// SetGeometryColumn sets the CSV column [CSVReader] reads geometry from.
+func SetGeometryColumn(column string) OptionFunc {
+	return func(opt *Option) {
+		opt.GeometryColumn = column
+	}
+}
+
+// SetProjection sets source coordinates' projection, see [crs.Parse]. It
+// overrides the CRS named by the source.
+func SetProjection(p crs.Projection) OptionFunc {
+	return func(opt *Option) {
+		opt.Projection = p
+	}
+}
+
+// SetLenient skips features without polygons, like a LineString or null
+// geometry, instead of failing, onSkip is called with every skipped one if
+// not nil.
+func SetLenient(onSkip func(SkippedFeature)) OptionFunc {
+	return func(opt *Option) {
+		opt.Lenient = true
+		opt.OnSkip = onSkip
+	}
+}
+
+// SetLenientFlag is converters' -lenient flag, it sets [SetLenient] writing
+// every skipped feature to w if lenient, or does nothing.
+func SetLenientFlag(lenient bool, w io.Writer) OptionFunc {
+	if !lenient {
+		return func(*Option) {}
+	}
+	return SetLenient(func(skipped SkippedFeature) {
+		fmt.Fprintln(w, "skipped", skipped)
+	})
+}
+
+// FeatureFlags are converters' flags of how features are read.
+type FeatureFlags struct {
+	// IDProperty and NameProperty are comma separated properties.
+	IDProperty   string
+	NameProperty string
+	Lenient      bool
+}
+
+// NewFeatureFlags defines -id-property, -name-property and -lenient on fs,
+// properties is what source's properties are called in help, like "columns".
+func NewFeatureFlags(fs *flag.FlagSet, properties string) *FeatureFlags {
+	f := &FeatureFlags{}
+	fs.StringVar(&f.IDProperty, "id-property", "", "comma separated "+properties+" tried in order for location's stable ID, e.g. GEOID")
+	fs.StringVar(&f.NameProperty, "name-property", DefaultNameProperty, "comma separated "+properties+" tried in order for location's name, ID is used if none present")
+	fs.BoolVar(&f.Lenient, "lenient", false, "skip features without polygons, like a LineString or null geometry, and report them instead of failing")
+	return f
+}
+
+// Option sets [SetIDProperty], [SetNameProperty] and [SetLenientFlag] writing
+// every skipped feature to w by the flags.
+func (f *FeatureFlags) Option(w io.Writer) OptionFunc {
+	lenient := SetLenientFlag(f.Lenient, w)
+	return func(opt *Option) {
+		SetIDProperty(splitProperties(f.IDProperty)...)(opt)
+		SetNameProperty(splitProperties(f.NameProperty)...)(opt)
+		lenient(opt)
+	}
+}
+
+func splitProperties(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// skip reports if feature index failed with err is skipped in lenient mode.
+func (opt *Option) skip(index int, name string, err error) bool {
+	if !opt.Lenient || !errors.Is(err, ErrUnsupportedGeometry) {
+		return false
+	}
+	if opt.OnSkip != nil {
+		opt.OnSkip(SkippedFeature{Index: index, Name: name, Err: err})
+	}
+	return true
+}
+
+// SetRepair repairs every location's rings by [validate.RepairLocation] with
+// opts, onRepair is called with every repaired issue if not nil, whose
+// Location is feature's index.
+func SetRepair(onRepair func(issue *validate.Issue), opts ...validate.OptionFunc) OptionFunc {
+	return func(opt *Option) {
+		opt.Repair = func(index int, location *pb.Location) {
+			for _, issue := range validate.RepairLocation(index, location, opts...) {
+				if onRepair != nil {
+					onRepair(issue)
+				}
+			}
+		}
+	}
+}
+
+// SetRepairFlag is converters' -repair flag, it sets [SetRepair] with opts
+// writing every repaired issue to w if repair, or does nothing.
+func SetRepairFlag(repair bool, w io.Writer, opts ...validate.OptionFunc) OptionFunc {
+	if !repair {
+		return func(*Option) {}
+	}
+	return SetRepair(func(issue *validate.Issue) {
+		fmt.Fprintln(w, issue)
+	}, opts...)
+}
+
+// repair repairs location of feature index if [SetRepair] is set.
+func (opt *Option) repair(index int, location *pb.Location) *pb.Location {
+	if opt.Repair != nil {
+		opt.Repair(index, location)
+	}
+	return location
+}
@@ -0,0 +1,93 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PropertiesDefine is a feature's properties.
+//
+// Name is the "Name" property, all properties including Name are kept in
+// Values so [Do] could read ID and name from any property.
+type PropertiesDefine struct {
+	Name   string                 `json:"Name"`
+	Values map[string]interface{} `json:"-"`
+}
+
+func (p *PropertiesDefine) UnmarshalJSON(data []byte) error {
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	p.Values = values
+	p.Name, _ = values["Name"].(string)
+	return nil
+}
+
+func (p PropertiesDefine) MarshalJSON() ([]byte, error) {
+	values := make(map[string]interface{}, len(p.Values)+1)
+	for k, v := range p.Values {
+		values[k] = v
+	}
+	if p.Name != "" {
+		values["Name"] = p.Name
+	}
+	return json.Marshal(values)
+}
+
+// Get returns property key as string, numbers are formatted without
+// exponent, false if missing, null or empty.
+func (p *PropertiesDefine) Get(key string) (string, bool) {
+	if key == "Name" && p.Name != "" {
+		return p.Name, true
+	}
+	var ret string
+	switch v := p.Values[key].(type) {
+	case nil:
+		return "", false
+	case string:
+		ret = v
+	case float64:
+		ret = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		ret = v.String()
+	default:
+		ret = fmt.Sprint(v)
+	}
+	return ret, ret != ""
+}
+
+// GetFold is [PropertiesDefine.Get] falling back to a property whose key
+// equals key case-insensitively, like "Name" for "name". The first of such
+// keys in sorted order is used if there're many.
+func (p *PropertiesDefine) GetFold(key string) (string, bool) {
+	if v, ok := p.Get(key); ok {
+		return v, true
+	}
+	keys := make([]string, 0, len(p.Values))
+	for k := range p.Values {
+		if strings.EqualFold(k, key) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v, ok := p.Get(k); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// lookup returns the first present property of keys.
+func (p *PropertiesDefine) lookup(keys []string) (string, bool) {
+	for _, key := range keys {
+		if v, ok := p.Get(key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
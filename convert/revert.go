@@ -25,12 +25,21 @@ func FromPbPolygonToGeoMultipolygon(pbpoly []*pb.Polygon) MultiPolygonCoordinate
 	return res
 }
 
+// RevertIDProperty is the property [RevertItem] writes location's ID to.
+const RevertIDProperty = "id"
+
+func revertProperties(input *pb.Location) PropertiesDefine {
+	ret := PropertiesDefine{Name: input.Name}
+	if input.Id != "" {
+		ret.Values = map[string]interface{}{RevertIDProperty: input.Id}
+	}
+	return ret
+}
+
 func RevertItem(input *pb.Location) *FeatureItem {
 	return &FeatureItem{
-		Type: FeatureType,
-		Properties: PropertiesDefine{
-			Name: input.Name,
-		},
+		Type:       FeatureType,
+		Properties: revertProperties(input),
 		Geometry: GeometryDefine{
 			Type:        MultiPolygonType,
 			Coordinates: FromPbPolygonToGeoMultipolygon(input.Polygons),
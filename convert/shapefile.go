@@ -0,0 +1,451 @@
+package convert
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// Shapefile shape types with polygons, Z and M values are ignored.
+const (
+	shapeNull     = 0
+	shapePolygon  = 5
+	shapePolygonZ = 15
+	shapePolygonM = 25
+)
+
+const shpFileCode = 9994
+
+var errNullShape = unsupportedGeometry("null shape")
+
+// maxShpRecordBytes limits a record's size, corrupt headers could claim GBs.
+const maxShpRecordBytes = 1 << 30
+
+// ShapefileReader reads an ESRI Shapefile's polygon records and their DBF
+// attributes one at a time.
+//
+// Shapefile stores outer rings clockwise and holes counter-clockwise, every
+// hole is assigned to the outer ring containing it, and rings are reversed
+// to follow the right-hand rule. Null shapes, records without geometry, are
+// skipped, and reported in lenient mode.
+type ShapefileReader struct {
+	shp   *bufio.Reader
+	dbf   *dbfReader
+	opt   *Option
+	index int
+	// closers are files opened by OpenShapefileReader.
+	closers []io.Closer
+}
+
+// NewShapefileReader returns a reader of .shp and .dbf content, dbf could be
+// nil if there are no attributes. See [Do] for opts.
+func NewShapefileReader(shp io.Reader, dbf io.Reader, opts ...OptionFunc) (*ShapefileReader, error) {
+	r := &ShapefileReader{
+		shp: bufio.NewReader(shp),
+		opt: newOption(opts...),
+	}
+	header := make([]byte, 100)
+	if _, err := io.ReadFull(r.shp, header); err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: shp header: %w", err)
+	}
+	if code := binary.BigEndian.Uint32(header); code != shpFileCode {
+		return nil, fmt.Errorf("pinpoint/convert: shp file code %d, want %d", code, shpFileCode)
+	}
+	switch shapeType := binary.LittleEndian.Uint32(header[32:]); shapeType {
+	case shapePolygon, shapePolygonZ, shapePolygonM:
+	default:
+		return nil, fmt.Errorf("pinpoint/convert: shp shape type %d is not polygon", shapeType)
+	}
+	if dbf != nil {
+		var err error
+		if r.dbf, err = newDBFReader(dbf); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Next returns the next record's location, [io.EOF] after the last one.
+// Records deleted in DBF are skipped. Errors name the record index.
+func (r *ShapefileReader) Next() (*pb.Location, error) {
+	for {
+		index := r.index
+		r.index++
+		polygons, err := r.readRecord()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		// unsupported record is read fully, DBF record is read for its name
+		if err != nil && !errors.Is(err, ErrUnsupportedGeometry) {
+			return nil, fmt.Errorf("pinpoint/convert: shp record %d: %w", index, err)
+		}
+		properties := &PropertiesDefine{}
+		if r.dbf != nil {
+			deleted, err := r.dbf.next(properties)
+			if err != nil {
+				return nil, fmt.Errorf("pinpoint/convert: dbf record %d: %w", index, err)
+			}
+			if deleted {
+				continue
+			}
+		}
+		location := newLocation(properties, r.opt)
+		if err == errNullShape {
+			r.opt.skip(index, location.Name, err)
+			continue
+		}
+		if r.opt.skip(index, location.Name, err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/convert: shp record %d %q: %w", index, location.Name, err)
+		}
+		location.Polygons = polygons
+		return r.opt.repair(index, location), nil
+	}
+}
+
+func (r *ShapefileReader) readRecord() ([]*pb.Polygon, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r.shp, header); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint32(header[4:])) * 2
+	if length > maxShpRecordBytes {
+		return nil, fmt.Errorf("record length %d too large", length)
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r.shp, content); err != nil {
+		return nil, err
+	}
+	if len(content) < 4 {
+		return nil, errors.New("record too short")
+	}
+	switch shapeType := binary.LittleEndian.Uint32(content); shapeType {
+	case shapePolygon, shapePolygonZ, shapePolygonM:
+	case shapeNull:
+		return nil, errNullShape
+	default:
+		return nil, unsupportedGeometry("shape type %d is not polygon", shapeType)
+	}
+	// shape type, bbox, numParts and numPoints
+	if len(content) < 44 {
+		return nil, errors.New("record too short")
+	}
+	numParts := int(binary.LittleEndian.Uint32(content[36:]))
+	numPoints := int(binary.LittleEndian.Uint32(content[40:]))
+	pointsAt := 44 + numParts*4
+	if numParts < 0 || numPoints < 0 || pointsAt+numPoints*16 > len(content) {
+		return nil, fmt.Errorf("%d parts and %d points exceed record length %d", numParts, numPoints, len(content))
+	}
+
+	rings := make([][]*pb.Point, 0, numParts)
+	for i := 0; i < numParts; i++ {
+		start := int(binary.LittleEndian.Uint32(content[44+i*4:]))
+		end := numPoints
+		if i+1 < numParts {
+			end = int(binary.LittleEndian.Uint32(content[44+(i+1)*4:]))
+		}
+		if start < 0 || start > end || end > numPoints {
+			return nil, fmt.Errorf("part %d has invalid range [%d, %d)", i, start, end)
+		}
+		ring := make([]*pb.Point, 0, end-start)
+		for j := start; j < end; j++ {
+			at := pointsAt + j*16
+			x := math.Float64frombits(binary.LittleEndian.Uint64(content[at:]))
+			y := math.Float64frombits(binary.LittleEndian.Uint64(content[at+8:]))
+			if r.opt.Projection != nil {
+				x, y = r.opt.Projection.Inverse(x, y)
+			}
+			ring = append(ring, &pb.Point{Lng: float32(x), Lat: float32(y)})
+		}
+		rings = append(rings, ring)
+	}
+	return ringsToPolygons(rings, isClockwise), nil
+}
+
+// signedArea returns ring's planar area, positive if counter-clockwise.
+func signedArea(ring []*pb.Point) float64 {
+	area := 0.0
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		area += float64(a.Lng)*float64(b.Lat) - float64(b.Lng)*float64(a.Lat)
+	}
+	return area / 2
+}
+
+func isClockwise(ring []*pb.Point) bool {
+	return signedArea(ring) < 0
+}
+
+// ringLocate returns 1 if point is inside ring by ray casting, -1 if outside
+// or 0 if on ring's boundary.
+func ringLocate(ring []*pb.Point, point *pb.Point) int {
+	inside := false
+	x, y := float64(point.Lng), float64(point.Lat)
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := float64(ring[i].Lng), float64(ring[i].Lat)
+		xj, yj := float64(ring[j].Lng), float64(ring[j].Lat)
+		if (xj-xi)*(y-yi) == (x-xi)*(yj-yi) &&
+			math.Min(xi, xj) <= x && x <= math.Max(xi, xj) && math.Min(yi, yj) <= y && y <= math.Max(yi, yj) {
+			return 0
+		}
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	if inside {
+		return 1
+	}
+	return -1
+}
+
+// ringContains reports if ring inner is inside ring outer by its first vertex
+// not on outer's boundary, rings don't cross.
+func ringContains(outer, inner []*pb.Point) bool {
+	for _, point := range inner {
+		if r := ringLocate(outer, point); r != 0 {
+			return r > 0
+		}
+	}
+	return false
+}
+
+// orientRing reverses ring in place to make it clockwise, or
+// counter-clockwise if not clockwise.
+func orientRing(ring []*pb.Point, clockwise bool) {
+	if isClockwise(ring) == clockwise {
+		return
+	}
+	for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+		ring[i], ring[j] = ring[j], ring[i]
+	}
+}
+
+// ringsToPolygons groups rings into polygons, rings isOuter reports true are
+// exterior rings and others are holes of the smallest exterior ring
+// containing them. Holes outside every exterior ring become polygons. Rings
+// are oriented by the right-hand rule, exterior rings counter-clockwise and
+// holes clockwise.
+func ringsToPolygons(rings [][]*pb.Point, isOuter func(ring []*pb.Point) bool) []*pb.Polygon {
+	polygons := make([]*pb.Polygon, 0)
+	holes := [][]*pb.Point{}
+	for _, ring := range rings {
+		if len(ring) == 0 {
+			continue
+		}
+		if isOuter(ring) {
+			polygons = append(polygons, &pb.Polygon{Points: ring, Holes: make([]*pb.Polygon, 0)})
+			continue
+		}
+		holes = append(holes, ring)
+	}
+	for _, hole := range holes {
+		var owner *pb.Polygon
+		ownerArea := math.Inf(1)
+		for _, polygon := range polygons {
+			area := math.Abs(signedArea(polygon.Points))
+			if area < ownerArea && ringContains(polygon.Points, hole) {
+				owner, ownerArea = polygon, area
+			}
+		}
+		if owner == nil {
+			polygons = append(polygons, &pb.Polygon{Points: hole, Holes: make([]*pb.Polygon, 0)})
+			continue
+		}
+		owner.Holes = append(owner.Holes, &pb.Polygon{Points: hole})
+	}
+	for _, polygon := range polygons {
+		orientRing(polygon.Points, false)
+		for _, hole := range polygon.Holes {
+			orientRing(hole.Points, true)
+		}
+	}
+	return polygons
+}
+
+// ReadShapefile reads all records' locations, see [NewShapefileReader].
+func ReadShapefile(shp io.Reader, dbf io.Reader, opts ...OptionFunc) (*pb.Locations, error) {
+	reader, err := NewShapefileReader(shp, dbf, opts...)
+	if err != nil {
+		return nil, err
+	}
+	output := &pb.Locations{Locations: make([]*pb.Location, 0)}
+	for {
+		location, err := reader.Next()
+		if err == io.EOF {
+			return output, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		output.Locations = append(output.Locations, location)
+	}
+}
+
+// OpenShapefileReader returns a reader of a .shp file and the .dbf file next
+// to it if exists, like FOO.DBF of FOO.SHP, see [NewShapefileReader]. Close it
+// after reading.
+func OpenShapefileReader(path string, opts ...OptionFunc) (*ShapefileReader, error) {
+	shp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	closers := []io.Closer{shp}
+	var dbf io.Reader
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range []string{".dbf", ".DBF"} {
+		dbfFile, err := os.Open(base + ext)
+		if err == nil {
+			closers = append(closers, dbfFile)
+			dbf = dbfFile
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			shp.Close()
+			return nil, err
+		}
+	}
+	r, err := NewShapefileReader(shp, dbf, opts...)
+	if err != nil {
+		for _, closer := range closers {
+			closer.Close()
+		}
+		return nil, err
+	}
+	r.closers = closers
+	return r, nil
+}
+
+// HasAttributes reports if r reads a .dbf file, locations have no ID or
+// name without it.
+func (r *ShapefileReader) HasAttributes() bool {
+	return r.dbf != nil
+}
+
+// Close closes files opened by [OpenShapefileReader].
+func (r *ShapefileReader) Close() error {
+	var ret error
+	for _, closer := range r.closers {
+		if err := closer.Close(); err != nil && ret == nil {
+			ret = err
+		}
+	}
+	r.closers = nil
+	return ret
+}
+
+// OpenShapefile reads all records' locations, see [OpenShapefileReader].
+func OpenShapefile(path string, opts ...OptionFunc) (*pb.Locations, error) {
+	reader, err := OpenShapefileReader(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	output := &pb.Locations{Locations: make([]*pb.Location, 0)}
+	for {
+		location, err := reader.Next()
+		if err == io.EOF {
+			return output, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		output.Locations = append(output.Locations, location)
+	}
+}
+
+type dbfField struct {
+	name   string
+	typ    byte
+	length int
+}
+
+// dbfReader reads dBase III records as properties, values are trimmed
+// strings.
+type dbfReader struct {
+	r      *bufio.Reader
+	fields []dbfField
+	record []byte
+	left   int
+}
+
+func newDBFReader(r io.Reader) (*dbfReader, error) {
+	d := &dbfReader{r: bufio.NewReader(r)}
+	header := make([]byte, 32)
+	if _, err := io.ReadFull(d.r, header); err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: dbf header: %w", err)
+	}
+	d.left = int(binary.LittleEndian.Uint32(header[4:]))
+	headerLength := int(binary.LittleEndian.Uint16(header[8:]))
+	d.record = make([]byte, int(binary.LittleEndian.Uint16(header[10:])))
+
+	// field descriptors end with 0x0D
+	if headerLength < 33 {
+		return nil, fmt.Errorf("pinpoint/convert: dbf header length %d too short", headerLength)
+	}
+	descriptors := make([]byte, headerLength-32)
+	if _, err := io.ReadFull(d.r, descriptors); err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: dbf header: %w", err)
+	}
+	width := 1 // deletion flag
+	for i := 0; i+32 <= len(descriptors) && descriptors[i] != 0x0D; i += 32 {
+		desc := descriptors[i : i+32]
+		name := string(desc[:11])
+		if end := strings.IndexByte(name, 0); end >= 0 {
+			name = name[:end]
+		}
+		field := dbfField{name: name, typ: desc[11], length: int(desc[16])}
+		d.fields = append(d.fields, field)
+		width += field.length
+	}
+	if width > len(d.record) {
+		return nil, fmt.Errorf("pinpoint/convert: dbf fields width %d exceeds record length %d", width, len(d.record))
+	}
+	return d, nil
+}
+
+func decodeDBFText(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	// Latin-1 is dBase's common code page
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// next reads a record into properties, deleted is true if record is marked
+// deleted.
+func (d *dbfReader) next(properties *PropertiesDefine) (deleted bool, err error) {
+	if d.left <= 0 {
+		return false, errors.New("no record left")
+	}
+	d.left--
+	if _, err := io.ReadFull(d.r, d.record); err != nil {
+		return false, err
+	}
+	properties.Values = make(map[string]interface{}, len(d.fields))
+	at := 1
+	for _, field := range d.fields {
+		value := strings.TrimSpace(decodeDBFText(d.record[at : at+field.length]))
+		at += field.length
+		properties.Values[field.name] = value
+	}
+	properties.Name, _ = properties.Values["Name"].(string)
+	return d.record[0] == '*', nil
+}
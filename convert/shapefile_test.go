@@ -0,0 +1,170 @@
+package convert_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/validate"
+)
+
+// writeShp encodes records of rings as a Polygon shapefile, nil rings are
+// null shapes.
+func writeShp(records [][][][2]float64) []byte {
+	body := &bytes.Buffer{}
+	for i, rings := range records {
+		content := &bytes.Buffer{}
+		if rings == nil {
+			_ = binary.Write(content, binary.LittleEndian, int32(0))
+			_ = binary.Write(body, binary.BigEndian, int32(i+1))
+			_ = binary.Write(body, binary.BigEndian, int32(content.Len()/2))
+			body.Write(content.Bytes())
+			continue
+		}
+		numPoints := 0
+		for _, ring := range rings {
+			numPoints += len(ring)
+		}
+		_ = binary.Write(content, binary.LittleEndian, int32(5))
+		_ = binary.Write(content, binary.LittleEndian, [4]float64{})
+		_ = binary.Write(content, binary.LittleEndian, int32(len(rings)))
+		_ = binary.Write(content, binary.LittleEndian, int32(numPoints))
+		start := 0
+		for _, ring := range rings {
+			_ = binary.Write(content, binary.LittleEndian, int32(start))
+			start += len(ring)
+		}
+		for _, ring := range rings {
+			for _, p := range ring {
+				_ = binary.Write(content, binary.LittleEndian, p)
+			}
+		}
+		_ = binary.Write(body, binary.BigEndian, int32(i+1))
+		_ = binary.Write(body, binary.BigEndian, int32(content.Len()/2))
+		body.Write(content.Bytes())
+	}
+	header := make([]byte, 100)
+	binary.BigEndian.PutUint32(header, 9994)
+	binary.BigEndian.PutUint32(header[24:], uint32((100+body.Len())/2))
+	binary.LittleEndian.PutUint32(header[28:], 1000)
+	binary.LittleEndian.PutUint32(header[32:], 5)
+	return append(header, body.Bytes()...)
+}
+
+// writeDBF encodes records of one character field.
+func writeDBF(field string, width int, values []string, deleted map[int]bool) []byte {
+	buf := &bytes.Buffer{}
+	header := make([]byte, 32)
+	header[0] = 3
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(values)))
+	binary.LittleEndian.PutUint16(header[8:], 32+32+1)
+	binary.LittleEndian.PutUint16(header[10:], uint16(1+width))
+	buf.Write(header)
+	desc := make([]byte, 32)
+	copy(desc, field)
+	desc[11] = 'C'
+	desc[16] = byte(width)
+	buf.Write(desc)
+	buf.WriteByte(0x0D)
+	for i, v := range values {
+		if deleted[i] {
+			buf.WriteByte('*')
+		} else {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(v + strings.Repeat(" ", width-len(v)))
+	}
+	buf.WriteByte(0x1A)
+	return buf.Bytes()
+}
+
+func square(x, y, size float64, clockwise bool) [][2]float64 {
+	ring := [][2]float64{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}, {x, y}}
+	if clockwise {
+		for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+	}
+	return ring
+}
+
+func TestReadShapefile(t *testing.T) {
+	shp := writeShp([][][][2]float64{
+		// two outer rings, the hole is inside the second one
+		{square(0, 0, 1, true), square(10, 10, 4, true), square(11, 11, 1, false)},
+		{square(20, 20, 1, true)},
+		nil,
+		{square(30, 30, 1, true)},
+		// holes begin at the outer ring's vertex and on its edge
+		{square(0, 0, 4, true), {{0, 4}, {1, 2}, {2, 3}, {0, 4}}, {{4, 2}, {3, 3}, {3, 1}, {4, 2}}},
+	})
+	dbf := writeDBF("NAME", 12, []string{"Rhode Island", "deleted", "null", "Delaware", "Connecticut"}, map[int]bool{1: true})
+
+	output, err := convert.ReadShapefile(bytes.NewReader(shp), bytes.NewReader(dbf), convert.SetNameProperty("NAME"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Locations) != 3 {
+		t.Fatalf("got %v locations", len(output.Locations))
+	}
+	ri := output.Locations[0]
+	if ri.Name != "Rhode Island" || len(ri.Polygons) != 2 {
+		t.Fatalf("got %v with %v polygons", ri.Name, len(ri.Polygons))
+	}
+	if len(ri.Polygons[0].Holes) != 0 || len(ri.Polygons[1].Holes) != 1 {
+		t.Errorf("hole assigned to wrong polygon")
+	}
+	if output.Locations[1].Name != "Delaware" {
+		t.Errorf("got %v", output.Locations[1].Name)
+	}
+	if ct := output.Locations[2]; len(ct.Polygons) != 1 || len(ct.Polygons[0].Holes) != 2 {
+		t.Errorf("got %v polygons, holes on boundary assigned to wrong polygon", len(ct.Polygons))
+	}
+	if issues := validate.Check(output, validate.SetKinds(validate.Winding)); len(issues) != 0 {
+		t.Errorf("got winding issues %v", issues)
+	}
+
+	var skipped []string
+	_, err = convert.ReadShapefile(bytes.NewReader(shp), bytes.NewReader(dbf), convert.SetNameProperty("NAME"), convert.SetLenient(func(f convert.SkippedFeature) {
+		skipped = append(skipped, f.String())
+	}))
+	if expect := `feature 2 "null": null shape`; err != nil || strings.Join(skipped, "\n") != expect {
+		t.Errorf("lenient: got %v skipped %q, want %q", err, skipped, expect)
+	}
+}
+
+func TestReadShapefileCorrupt(t *testing.T) {
+	shp := writeShp([][][][2]float64{{square(0, 0, 1, true)}, {square(1, 1, 1, true)}})
+	if _, err := convert.ReadShapefile(bytes.NewReader(shp[:len(shp)-8]), nil); err == nil || !strings.Contains(err.Error(), "record 1") {
+		t.Errorf("got %v", err)
+	}
+	// points count larger than record
+	binary.LittleEndian.PutUint32(shp[100+8+40:], math.MaxInt32)
+	if _, err := convert.ReadShapefile(bytes.NewReader(shp), nil); err == nil || !strings.Contains(err.Error(), "record 0") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestOpenShapefile(t *testing.T) {
+	dir := t.TempDir()
+	shp := writeShp([][][][2]float64{{square(0, 0, 1, true)}})
+	dbf := writeDBF("NAME", 12, []string{"Rhode Island"}, nil)
+	if err := os.WriteFile(filepath.Join(dir, "STATES.SHP"), shp, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "STATES.DBF"), dbf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	output, err := convert.OpenShapefile(filepath.Join(dir, "STATES.SHP"), convert.SetNameProperty("NAME"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Locations) != 1 || output.Locations[0].Name != "Rhode Island" {
+		t.Errorf("got %v", output.Locations)
+	}
+}
@@ -0,0 +1,270 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/deslittle/pinpoint/crs"
+	"github.com/deslittle/pinpoint/pb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// rawGeometry is a geometry whose coordinates are decoded after its type is
+// known, without going through interface{}.
+type rawGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometries  []*rawGeometry  `json:"geometries"`
+}
+
+// coordinates returns g's polygons, see geometryCoordinates.
+func (g *rawGeometry) coordinates() (MultiPolygonCoordinates, error) {
+	if g == nil {
+		return nil, unsupportedGeometry("geometry is null")
+	}
+	return geometryCoordinates(g.Type, len(g.Geometries),
+		func(v interface{}) error {
+			return json.Unmarshal(g.Coordinates, v)
+		},
+		func(i int) (MultiPolygonCoordinates, error) {
+			return g.Geometries[i].coordinates()
+		},
+	)
+}
+
+type rawFeature struct {
+	Type       string           `json:"type"`
+	Properties PropertiesDefine `json:"properties"`
+	Geometry   *rawGeometry     `json:"geometry"`
+}
+
+func (f *rawFeature) toLocation(opt *Option, projection crs.Projection) (*pb.Location, error) {
+	geometry := f.Geometry
+	switch f.Type {
+	case FeatureType:
+	case MultiPolygonType, PolygonType:
+		// feature typed by its geometry, kept for old files like [Do]
+		if geometry != nil {
+			geometry = &rawGeometry{Type: f.Type, Coordinates: geometry.Coordinates}
+		}
+	default:
+		return nil, fmt.Errorf("got type %v, want %v", f.Type, FeatureType)
+	}
+	location := newLocation(&f.Properties, opt)
+	coordinates, err := geometry.coordinates()
+	if err != nil {
+		return nil, err
+	}
+	project(coordinates, projection)
+	location.Polygons = FromGeoMultipolygonToPbPolygon(coordinates)
+	return location, nil
+}
+
+// Reader reads a GeoJSON FeatureCollection one feature at a time, memory
+// used is bounded by the largest feature instead of the whole file.
+type Reader struct {
+	dec *json.Decoder
+	opt *Option
+	// index is the next feature's index.
+	index      int
+	started    bool
+	inFeatures bool
+	done       bool
+	typ        string
+	// projection is opt's or crs member's projection.
+	projection crs.Projection
+}
+
+// NewReader returns a Reader of r, see [Do] for opts. A crs member is
+// honored only if it's before features, a projected one after features is
+// an error unless [SetProjection] is set.
+func NewReader(r io.Reader, opts ...OptionFunc) *Reader {
+	opt := newOption(opts...)
+	return &Reader{
+		dec:        json.NewDecoder(r),
+		opt:        opt,
+		projection: opt.Projection,
+	}
+}
+
+func (r *Reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("pinpoint/convert: "+format, args...)
+}
+
+func (r *Reader) expectDelim(delim json.Delim) error {
+	token, err := r.dec.Token()
+	if err != nil {
+		return r.errorf("%w", err)
+	}
+	if token != delim {
+		return r.errorf("got %v, want %v", token, delim)
+	}
+	return nil
+}
+
+// seekFeatures reads top level members until features array begins, false if
+// the collection ends.
+func (r *Reader) seekFeatures() (bool, error) {
+	for r.dec.More() {
+		token, err := r.dec.Token()
+		if err != nil {
+			return false, r.errorf("%w", err)
+		}
+		key, _ := token.(string)
+		switch key {
+		case "features":
+			if err := r.expectDelim('['); err != nil {
+				return false, err
+			}
+			return true, nil
+		case "type":
+			if err := r.dec.Decode(&r.typ); err != nil {
+				return false, r.errorf("type: %w", err)
+			}
+		case "crs":
+			var define *CRSDefine
+			if err := r.dec.Decode(&define); err != nil {
+				return false, r.errorf("crs: %w", err)
+			}
+			projection, err := define.projection(r.opt)
+			if err != nil {
+				return false, err
+			}
+			if r.index > 0 && r.opt.Projection == nil && projection != nil && projection != crs.LngLat {
+				// features read already are in wrong coordinates
+				return false, r.errorf("crs %v after features, set its projection like -crs instead", define.Properties.Name)
+			}
+			r.projection = projection
+		default:
+			var skip json.RawMessage
+			if err := r.dec.Decode(&skip); err != nil {
+				return false, r.errorf("%v: %w", key, err)
+			}
+		}
+	}
+	if err := r.expectDelim('}'); err != nil {
+		return false, err
+	}
+	if r.typ != "FeatureCollection" {
+		return false, r.errorf("got type %q, want FeatureCollection", r.typ)
+	}
+	return false, nil
+}
+
+// Next returns the next feature's location, [io.EOF] after the last one.
+// Errors of a feature name its index, see [Do] for supported geometries.
+func (r *Reader) Next() (*pb.Location, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if !r.started {
+		if err := r.expectDelim('{'); err != nil {
+			return nil, err
+		}
+		r.started = true
+	}
+	for {
+		more, err := r.nextFeature()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			r.done = true
+			return nil, io.EOF
+		}
+
+		index := r.index
+		r.index++
+		feature := &rawFeature{}
+		if err := r.dec.Decode(feature); err != nil {
+			return nil, r.errorf("feature %d: %w", index, err)
+		}
+		location, err := feature.toLocation(r.opt, r.projection)
+		if err == nil {
+			return r.opt.repair(index, location), nil
+		}
+		name := newLocation(&feature.Properties, r.opt).Name
+		if !r.opt.skip(index, name, err) {
+			return nil, r.errorf("feature %d %q: %w", index, name, err)
+		}
+	}
+}
+
+// nextFeature moves to the next feature of features arrays, false if the
+// collection ends.
+func (r *Reader) nextFeature() (bool, error) {
+	for {
+		if !r.inFeatures {
+			found, err := r.seekFeatures()
+			if err != nil || !found {
+				return false, err
+			}
+			r.inFeatures = true
+		}
+		if r.dec.More() {
+			return true, nil
+		}
+		// features array ends, there may be members after it
+		if err := r.expectDelim(']'); err != nil {
+			return false, err
+		}
+		r.inFeatures = false
+	}
+}
+
+// ReadAll reads all features' locations from r.
+func ReadAll(r io.Reader, opts ...OptionFunc) (*pb.Locations, error) {
+	reader := NewReader(r, opts...)
+	output := &pb.Locations{Locations: make([]*pb.Location, 0)}
+	for {
+		location, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return output, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		output.Locations = append(output.Locations, location)
+	}
+}
+
+var (
+	locationsField = (&pb.Locations{}).ProtoReflect().Descriptor().Fields().ByName("locations").Number()
+	metadataField  = (&pb.Locations{}).ProtoReflect().Descriptor().Fields().ByName("metadata").Number()
+)
+
+// LocationsWriter writes locations one at a time as a [pb.Locations]
+// message, so all locations are not kept in memory.
+type LocationsWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func NewLocationsWriter(w io.Writer) *LocationsWriter {
+	return &LocationsWriter{w: w}
+}
+
+// Write appends location to the message's locations field.
+func (w *LocationsWriter) Write(location *pb.Location) error {
+	return w.writeField(locationsField, location)
+}
+
+// WriteMetadata sets the message's metadata, it could be written before or
+// after locations but only once.
+func (w *LocationsWriter) WriteMetadata(metadata *pb.Metadata) error {
+	return w.writeField(metadataField, metadata)
+}
+
+func (w *LocationsWriter) writeField(field protowire.Number, m proto.Message) error {
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	w.buf = protowire.AppendTag(w.buf[:0], field, protowire.BytesType)
+	w.buf = protowire.AppendBytes(w.buf, data)
+	_, err = w.w.Write(w.buf)
+	return err
+}
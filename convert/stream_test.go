@@ -0,0 +1,125 @@
+package convert_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	usstates "github.com/deslittle/pinpoint-us-states"
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/crs"
+	"github.com/deslittle/pinpoint/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func liteGeoJSON(t *testing.T) []byte {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.LiteData, input); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(convert.Revert(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestReadAllSameAsDo(t *testing.T) {
+	data := liteGeoJSON(t)
+	expect, err := convert.Do(parse(t, string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := convert.ReadAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(expect, got) {
+		t.Error("got different locations")
+	}
+
+	buf := &bytes.Buffer{}
+	writer := convert.NewLocationsWriter(buf)
+	expect.Metadata = pb.NewMetadata()
+	expect.Metadata.Name = "us-states"
+	expect.Metadata.AddStep("geojson2locpb", map[string]string{"name-property": "NAME"})
+	if err := writer.WriteMetadata(expect.Metadata); err != nil {
+		t.Fatal(err)
+	}
+	for _, location := range got.Locations {
+		if err := writer.Write(location); err != nil {
+			t.Fatal(err)
+		}
+	}
+	written := &pb.Locations{}
+	if err := proto.Unmarshal(buf.Bytes(), written); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(expect, written) {
+		t.Error("got different locations from LocationsWriter")
+	}
+}
+
+func TestReader(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		count  int
+		expect string // error substring, empty for no error
+	}{
+		{"members around features", `{"bbox": [0, 0, 2, 1], "features": [` + feature("a") + `], "type": "FeatureCollection", "crs": null}`, 1, ""},
+		{"empty", `{"type": "FeatureCollection", "features": []}`, 0, ""},
+		{"not collection", `{"type": "Feature"}`, 0, "want FeatureCollection"},
+		{"bad feature", `{"type": "FeatureCollection", "features": [` + feature("a") + `, {"type": "Feature", "properties": {"Name": "b"}, "geometry": {"type": "Point", "coordinates": [0, 0]}}]}`, 1, `feature 1 "b"`},
+		{"typed by geometry", `{"type": "FeatureCollection", "features": [{"type": "Polygon", "properties": {"Name": "a"}, "geometry": {"coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}]}`, 1, ""},
+		{"bad type", `{"type": "FeatureCollection", "features": [{"type": "Point", "properties": {"Name": "b"}, "geometry": {"coordinates": [0, 0]}}]}`, 0, `feature 0 "b": got type Point`},
+		{"truncated", `{"type": "FeatureCollection", "features": [` + feature("a") + `, {"type": "Fea`, 1, "feature 1"},
+	}
+	for _, c := range cases {
+		reader := convert.NewReader(strings.NewReader(c.input))
+		count := 0
+		var err error
+		for {
+			var location *pb.Location
+			location, err = reader.Next()
+			if err != nil {
+				break
+			}
+			count++
+			if location.Name != "a" {
+				t.Errorf("%v: got name %v", c.name, location.Name)
+			}
+		}
+		if count != c.count {
+			t.Errorf("%v: got %v locations, want %v", c.name, count, c.count)
+		}
+		if c.expect == "" && err.Error() != "EOF" || c.expect != "" && !strings.Contains(err.Error(), c.expect) {
+			t.Errorf("%v: got error %v, want %q", c.name, err, c.expect)
+		}
+	}
+}
+
+func TestReaderCRSAfterFeatures(t *testing.T) {
+	input := `{"type": "FeatureCollection", "features": [` + feature("a") + `], "crs": {"type": "name", "properties": {"name": "EPSG:3857"}}}`
+	_, err := convert.ReadAll(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "after features") {
+		t.Errorf("got error %v", err)
+	}
+	output, err := convert.ReadAll(strings.NewReader(input), convert.SetProjection(crs.WebMercator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Locations) != 1 {
+		t.Errorf("got %v locations", len(output.Locations))
+	}
+
+	wgs84 := strings.Replace(input, "EPSG:3857", "EPSG:4326", 1)
+	if _, err := convert.ReadAll(strings.NewReader(wgs84)); err != nil {
+		t.Error(err)
+	}
+}
+
+func feature(name string) string {
+	return `{"type": "Feature", "properties": {"Name": "` + name + `"}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}`
+}
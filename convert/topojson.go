@@ -0,0 +1,319 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/deslittle/pinpoint/crs"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/topology"
+)
+
+const (
+	TopologyType           = "Topology"
+	GeometryCollectionType = "GeometryCollection"
+)
+
+// TopoJSON is a TopoJSON topology.
+//
+// Rings are lists of arc references, i means Arcs[i] and ^i means Arcs[i]
+// reversed, see [topology.Ring]. With Transform, arcs' positions are
+// quantized integers and delta-encoded.
+type TopoJSON struct {
+	Type      string                   `json:"type"`
+	BBox      []float64                `json:"bbox,omitempty"`
+	Transform *TopoTransform           `json:"transform,omitempty"`
+	Objects   map[string]*TopoGeometry `json:"objects"`
+	Arcs      [][][]float64            `json:"arcs"`
+}
+
+type TopoTransform struct {
+	Scale     [2]float64 `json:"scale"`
+	Translate [2]float64 `json:"translate"`
+}
+
+// TopoGeometry is a TopoJSON geometry object. Arcs are [][]int for Polygon
+// and [][][]int for MultiPolygon.
+type TopoGeometry struct {
+	Type       string            `json:"type"`
+	ID         interface{}       `json:"id,omitempty"`
+	Properties *PropertiesDefine `json:"properties,omitempty"`
+	Arcs       json.RawMessage   `json:"arcs,omitempty"`
+	Geometries []*TopoGeometry   `json:"geometries,omitempty"`
+}
+
+// decodeArcs returns arcs' positions, decoded by transform if present and
+// reprojected by projection if not nil.
+func (t *TopoJSON) decodeArcs(projection crs.Projection) ([][]*pb.Point, error) {
+	arcs := make([][]*pb.Point, len(t.Arcs))
+	for i, arc := range t.Arcs {
+		points := make([]*pb.Point, 0, len(arc))
+		var x, y float64
+		for j, position := range arc {
+			if len(position) < 2 {
+				return nil, fmt.Errorf("arc %d position %d has %d values", i, j, len(position))
+			}
+			lng, lat := position[0], position[1]
+			if t.Transform != nil {
+				x, y = x+position[0], y+position[1]
+				lng = x*t.Transform.Scale[0] + t.Transform.Translate[0]
+				lat = y*t.Transform.Scale[1] + t.Transform.Translate[1]
+			}
+			if projection != nil {
+				lng, lat = projection.Inverse(lng, lat)
+			}
+			points = append(points, &pb.Point{Lng: float32(lng), Lat: float32(lat)})
+		}
+		arcs[i] = points
+	}
+	return arcs, nil
+}
+
+func (g *TopoGeometry) properties() *PropertiesDefine {
+	properties := &PropertiesDefine{Values: map[string]interface{}{}}
+	if g == nil {
+		return properties
+	}
+	if g.Properties != nil {
+		properties.Name = g.Properties.Name
+		for k, v := range g.Properties.Values {
+			properties.Values[k] = v
+		}
+	}
+	if _, ok := properties.Values[RevertIDProperty]; !ok && g.ID != nil {
+		properties.Values[RevertIDProperty] = g.ID
+	}
+	return properties
+}
+
+// polygons decodes geometry's arc references to polygons, see
+// geometryCoordinates for GeometryCollection.
+func (g *TopoGeometry) polygons(numArcs int) ([]*topology.Polygon, error) {
+	if g == nil {
+		return nil, unsupportedGeometry("geometry is null")
+	}
+	var refs [][][]int
+	switch g.Type {
+	case PolygonType:
+		var polygon [][]int
+		if err := json.Unmarshal(g.Arcs, &polygon); err != nil {
+			return nil, err
+		}
+		refs = append(refs, polygon)
+	case MultiPolygonType:
+		if err := json.Unmarshal(g.Arcs, &refs); err != nil {
+			return nil, err
+		}
+	case GeometryCollectionType:
+		// like GeoJSON, members without polygons are ignored
+		var polygons []*topology.Polygon
+		for i, member := range g.Geometries {
+			memberPolygons, err := member.polygons(numArcs)
+			if errors.Is(err, ErrUnsupportedGeometry) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("geometries[%d]: %w", i, err)
+			}
+			polygons = append(polygons, memberPolygons...)
+		}
+		if len(polygons) == 0 {
+			return nil, unsupportedGeometry("GeometryCollection has no Polygon or MultiPolygon")
+		}
+		return polygons, nil
+	case "":
+		return nil, unsupportedGeometry("geometry is null")
+	default:
+		return nil, unsupportedGeometry("geometry type %q is not Polygon, MultiPolygon or GeometryCollection", g.Type)
+	}
+	polygons := make([]*topology.Polygon, 0, len(refs))
+	for _, polygon := range refs {
+		newPolygon := &topology.Polygon{}
+		for _, ring := range polygon {
+			for _, ref := range ring {
+				if ref >= numArcs || ^ref >= numArcs {
+					return nil, fmt.Errorf("arc reference %d out of %d arcs", ref, numArcs)
+				}
+			}
+			newPolygon.Rings = append(newPolygon.Rings, &topology.Ring{Arcs: ring, Closed: true})
+		}
+		if len(newPolygon.Rings) > 0 {
+			polygons = append(polygons, newPolygon)
+		}
+	}
+	return polygons, nil
+}
+
+// DoTopoJSON converts an object of TopoJSON topology to locations, object
+// could be empty if topology has only one. A GeometryCollection object's
+// geometries are locations, other objects are a single location.
+//
+// Geometry's id member is read as property "id" unless properties have it,
+// see [SetIDProperty] and [SetNameProperty].
+func DoTopoJSON(input *TopoJSON, object string, opts ...OptionFunc) (*pb.Locations, error) {
+	opt := newOption(opts...)
+	if input.Type != TopologyType {
+		return nil, fmt.Errorf("pinpoint/convert: got type %q, want %v", input.Type, TopologyType)
+	}
+	if object == "" && len(input.Objects) == 1 {
+		for name := range input.Objects {
+			object = name
+		}
+	}
+	root, ok := input.Objects[object]
+	if !ok {
+		return nil, fmt.Errorf("pinpoint/convert: topojson has no object %q", object)
+	}
+	geometries := []*TopoGeometry{root}
+	if root.Type == GeometryCollectionType {
+		geometries = root.Geometries
+	}
+
+	arcs, err := input.decodeArcs(opt.Projection)
+	if err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: %w", err)
+	}
+	t := &topology.Topology{Arcs: arcs}
+	// indices are geometries' indices of t.Locations
+	indices := make([]int, 0, len(geometries))
+	for index, geometry := range geometries {
+		location := newLocation(geometry.properties(), opt)
+		polygons, err := geometry.polygons(len(arcs))
+		if opt.skip(index, location.Name, err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/convert: geometry %d %q: %w", index, location.Name, err)
+		}
+		t.Locations = append(t.Locations, &topology.Location{
+			Name:     location.Name,
+			ID:       location.Id,
+			Polygons: polygons,
+		})
+		indices = append(indices, index)
+	}
+	output := t.ToLocations()
+	for i, location := range output.Locations {
+		opt.repair(indices[i], location)
+	}
+	if output.Locations == nil {
+		output.Locations = make([]*pb.Location, 0)
+	}
+	return output, nil
+}
+
+// quantizer maps coordinates to integers in [0, n-1] over bbox, like
+// TopoJSON's quantization.
+type quantizer struct {
+	transform TopoTransform
+}
+
+func newQuantizer(bbox []float64, n int) *quantizer {
+	q := &quantizer{}
+	for i := 0; i < 2; i++ {
+		q.transform.Translate[i] = bbox[i]
+		q.transform.Scale[i] = 1
+		if size := bbox[i+2] - bbox[i]; size > 0 {
+			q.transform.Scale[i] = size / float64(n-1)
+		}
+	}
+	return q
+}
+
+// arc quantizes and delta-encodes points, consecutive duplicates are dropped
+// but an arc always keeps 2 positions.
+func (q *quantizer) arc(points []*pb.Point) [][]float64 {
+	ret := make([][]float64, 0, len(points))
+	var lastX, lastY float64
+	for i, point := range points {
+		x := math.Round((float64(point.Lng) - q.transform.Translate[0]) / q.transform.Scale[0])
+		y := math.Round((float64(point.Lat) - q.transform.Translate[1]) / q.transform.Scale[1])
+		if i > 0 && x == lastX && y == lastY {
+			continue
+		}
+		ret = append(ret, []float64{x - lastX, y - lastY})
+		lastX, lastY = x, y
+	}
+	if len(ret) == 1 {
+		ret = append(ret, []float64{0, 0})
+	}
+	return ret
+}
+
+func pointsBBox(arcs [][]*pb.Point) []float64 {
+	bbox := []float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, arc := range arcs {
+		for _, point := range arc {
+			bbox[0] = math.Min(bbox[0], float64(point.Lng))
+			bbox[1] = math.Min(bbox[1], float64(point.Lat))
+			bbox[2] = math.Max(bbox[2], float64(point.Lng))
+			bbox[3] = math.Max(bbox[3], float64(point.Lat))
+		}
+	}
+	if math.IsInf(bbox[0], 0) {
+		return nil
+	}
+	return bbox
+}
+
+// RevertTopoJSON converts locations to a TopoJSON topology with one
+// GeometryCollection object, borders shared by neighbors are stored once,
+// see [topology.Build].
+//
+// Arcs are quantized to quantization positions per axis if it's larger than
+// 1, which is lossy, points move up to half of bbox's size / quantization.
+func RevertTopoJSON(input *pb.Locations, object string, quantization int) (*TopoJSON, error) {
+	t := topology.Build(input)
+	output := &TopoJSON{
+		Type:    TopologyType,
+		BBox:    pointsBBox(t.Arcs),
+		Objects: map[string]*TopoGeometry{},
+		Arcs:    make([][][]float64, 0, len(t.Arcs)),
+	}
+
+	var q *quantizer
+	if quantization > 1 && output.BBox != nil {
+		q = newQuantizer(output.BBox, quantization)
+		output.Transform = &q.transform
+	}
+	for _, arc := range t.Arcs {
+		if q != nil {
+			output.Arcs = append(output.Arcs, q.arc(arc))
+			continue
+		}
+		positions := make([][]float64, 0, len(arc))
+		for _, point := range arc {
+			positions = append(positions, []float64{float64(point.Lng), float64(point.Lat)})
+		}
+		output.Arcs = append(output.Arcs, positions)
+	}
+
+	collection := &TopoGeometry{Type: GeometryCollectionType, Geometries: make([]*TopoGeometry, 0)}
+	for _, location := range t.Locations {
+		refs := make([][][]int, 0, len(location.Polygons))
+		for _, polygon := range location.Polygons {
+			rings := make([][]int, 0, len(polygon.Rings))
+			for _, ring := range polygon.Rings {
+				rings = append(rings, ring.Arcs)
+			}
+			refs = append(refs, rings)
+		}
+		arcs, err := json.Marshal(refs)
+		if err != nil {
+			return nil, err
+		}
+		geometry := &TopoGeometry{
+			Type:       MultiPolygonType,
+			Properties: &PropertiesDefine{Name: location.Name},
+			Arcs:       arcs,
+		}
+		if location.ID != "" {
+			geometry.ID = location.ID
+		}
+		collection.Geometries = append(collection.Geometries, geometry)
+	}
+	output.Objects[object] = collection
+	return output, nil
+}
@@ -0,0 +1,132 @@
+package convert_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	usstates "github.com/deslittle/pinpoint-us-states"
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/topology"
+	"google.golang.org/protobuf/proto"
+)
+
+func topoJSONRoundTrip(t *testing.T, input *pb.Locations, quantization int) (*convert.TopoJSON, *pb.Locations) {
+	topo, err := convert.RevertTopoJSON(input, "states", quantization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(topo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &convert.TopoJSON{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	output, err := convert.DoTopoJSON(decoded, "", convert.SetIDProperty("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return topo, output
+}
+
+func TestTopoJSONRoundTrip(t *testing.T) {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.LiteData, input); err != nil {
+		t.Fatal(err)
+	}
+	for _, location := range input.Locations {
+		location.Id = location.Name
+	}
+
+	// rings start at arcs' start after topology.Build
+	expect := topology.Build(input).ToLocations()
+	topo, output := topoJSONRoundTrip(t, input, 0)
+	if !proto.Equal(expect, output) {
+		t.Error("got different locations")
+	}
+	if topo.Transform != nil {
+		t.Error("got transform without quantization")
+	}
+
+	topo, output = topoJSONRoundTrip(t, input, 1e6)
+	maxDrift := math.Max(topo.Transform.Scale[0], topo.Transform.Scale[1])
+	for i, location := range output.Locations {
+		if location.Id != expect.Locations[i].Id || location.Name != expect.Locations[i].Name {
+			t.Fatalf("location %d: got %v %v", i, location.Id, location.Name)
+		}
+		for j, polygon := range location.Polygons {
+			points := expect.Locations[i].Polygons[j].Points
+			// consecutive points quantized to the same position are dropped
+			if len(polygon.Points) > len(points) {
+				t.Errorf("location %d polygon %d: got %d points, want <= %d", i, j, len(polygon.Points), len(points))
+			}
+			// first point is an arc's start and never dropped
+			for axis, drift := range []float32{polygon.Points[0].Lng - points[0].Lng, polygon.Points[0].Lat - points[0].Lat} {
+				if math.Abs(float64(drift)) > maxDrift {
+					t.Errorf("location %d polygon %d axis %d: moved %v, want <= %v", i, j, axis, drift, maxDrift)
+				}
+			}
+		}
+	}
+}
+
+func TestDoTopoJSON(t *testing.T) {
+	// two unit squares sharing arc 0, quantized to 0..2
+	input := `{
+		"type": "Topology",
+		"transform": {"scale": [0.5, 0.5], "translate": [10, 20]},
+		"objects": {
+			"a": {"type": "GeometryCollection", "geometries": [
+				{"type": "Polygon", "id": 44, "properties": {"name": "left"}, "arcs": [[0, 1]]},
+				{"type": "MultiPolygon", "id": "36", "properties": {"name": "right"}, "arcs": [[[-1, 2]]]},
+				{"type": "LineString", "properties": {"name": "road"}, "arcs": [0]}
+			]},
+			"b": {"type": "Polygon", "arcs": [[3]]}
+		},
+		"arcs": [
+			[[1, 0], [0, 2]],
+			[[1, 2], [-1, 0], [0, -2], [1, 0]],
+			[[1, 0], [1, 0], [0, 2], [-1, 0]],
+			[[0, 0], [1, 0], [0, 1], [-1, -1]]
+		]
+	}`
+	topo := &convert.TopoJSON{}
+	if err := json.Unmarshal([]byte(input), topo); err != nil {
+		t.Fatal(err)
+	}
+	topo.Objects["a"].Geometries = topo.Objects["a"].Geometries[:2]
+	output, err := convert.DoTopoJSON(topo, "a", convert.SetIDProperty("id"), convert.SetNameProperty("name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"MULTIPOLYGON (((10.5 20, 10.5 21, 10 21, 10 20, 10.5 20)))",
+		"MULTIPOLYGON (((10.5 21, 10.5 20, 11 20, 11 21, 10.5 21)))",
+	}
+	for i, location := range output.Locations {
+		if got := convert.RevertWKT(location); got != expect[i] {
+			t.Errorf("location %d: got %v, want %v", i, got, expect[i])
+		}
+	}
+	if output.Locations[0].Id != "44" || output.Locations[1].Name != "right" {
+		t.Errorf("got %v", output.Locations)
+	}
+
+	if _, err := convert.DoTopoJSON(topo, ""); err == nil {
+		t.Error("ambiguous object: got no error")
+	}
+	if err := json.Unmarshal([]byte(input), topo); err != nil {
+		t.Fatal(err)
+	}
+	_, err = convert.DoTopoJSON(topo, "a", convert.SetNameProperty("name"))
+	if msg := `pinpoint/convert: geometry 2 "road": geometry type "LineString" is not Polygon, MultiPolygon or GeometryCollection`; err == nil || err.Error() != msg {
+		t.Errorf("got error %v, want %v", err, msg)
+	}
+	topo.Objects["b"].Arcs = json.RawMessage("[[4]]")
+	if _, err := convert.DoTopoJSON(topo, "b"); err == nil {
+		t.Error("arc reference out of range: got no error")
+	}
+}
@@ -0,0 +1,223 @@
+package convert
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// WKB geometry types with polygons.
+const (
+	wkbPolygon      = 3
+	wkbMultiPolygon = 6
+)
+
+// EWKB flags on geometry type, used by PostGIS.
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+var errWKBTruncated = errors.New("wkb: truncated")
+
+type wkbReader struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+func (r *wkbReader) uint32() (uint32, error) {
+	if len(r.b) < 4 {
+		return 0, errWKBTruncated
+	}
+	v := r.order.Uint32(r.b)
+	r.b = r.b[4:]
+	return v, nil
+}
+
+func (r *wkbReader) float64() (float64, error) {
+	if len(r.b) < 8 {
+		return 0, errWKBTruncated
+	}
+	v := math.Float64frombits(r.order.Uint64(r.b))
+	r.b = r.b[8:]
+	return v, nil
+}
+
+// count reads a count of items at least size bytes each, counts larger than
+// bytes left are rejected before allocating.
+func (r *wkbReader) count(size int) (int, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n)*uint64(size) > uint64(len(r.b)) {
+		return 0, errWKBTruncated
+	}
+	return int(n), nil
+}
+
+// header reads byte order and geometry type, returns base type and how many
+// values every point has.
+func (r *wkbReader) header() (typ uint32, dims int, err error) {
+	if len(r.b) < 1 {
+		return 0, 0, errWKBTruncated
+	}
+	switch r.b[0] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return 0, 0, fmt.Errorf("wkb: invalid byte order %d", r.b[0])
+	}
+	r.b = r.b[1:]
+	if typ, err = r.uint32(); err != nil {
+		return 0, 0, err
+	}
+	dims = 2
+	if typ&ewkbZ != 0 {
+		dims++
+	}
+	if typ&ewkbM != 0 {
+		dims++
+	}
+	if typ&ewkbSRID != 0 {
+		if _, err := r.uint32(); err != nil {
+			return 0, 0, err
+		}
+	}
+	typ &^= ewkbZ | ewkbM | ewkbSRID
+	// ISO WKB adds 1000 for Z, 2000 for M and 3000 for ZM
+	switch typ / 1000 {
+	case 1, 2:
+		dims++
+	case 3:
+		dims += 2
+	}
+	return typ % 1000, dims, nil
+}
+
+func (r *wkbReader) polygon(dims int) (PolygonCoordinates, error) {
+	numRings, err := r.count(4)
+	if err != nil {
+		return nil, err
+	}
+	polygon := make(PolygonCoordinates, 0, numRings)
+	for i := 0; i < numRings; i++ {
+		numPoints, err := r.count(dims * 8)
+		if err != nil {
+			return nil, err
+		}
+		ring := make([][2]float64, numPoints)
+		for j := range ring {
+			for k := 0; k < dims; k++ {
+				v, err := r.float64()
+				if err != nil {
+					return nil, err
+				}
+				if k < 2 {
+					ring[j][k] = v
+				}
+			}
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon, nil
+}
+
+func (r *wkbReader) parse() (MultiPolygonCoordinates, error) {
+	typ, dims, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+	var coordinates MultiPolygonCoordinates
+	switch typ {
+	case wkbPolygon:
+		polygon, err := r.polygon(dims)
+		if err != nil {
+			return nil, err
+		}
+		coordinates = append(coordinates, polygon)
+	case wkbMultiPolygon:
+		// 9 bytes is the smallest polygon header
+		numPolygons, err := r.count(9)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < numPolygons; i++ {
+			typ, dims, err := r.header()
+			if err != nil {
+				return nil, err
+			}
+			if typ != wkbPolygon {
+				return nil, fmt.Errorf("wkb: multipolygon member %d has type %d", i, typ)
+			}
+			polygon, err := r.polygon(dims)
+			if err != nil {
+				return nil, err
+			}
+			coordinates = append(coordinates, polygon)
+		}
+	default:
+		return nil, unsupportedGeometry("wkb: geometry type %d is not Polygon or MultiPolygon", typ)
+	}
+	if len(r.b) != 0 {
+		return nil, fmt.Errorf("wkb: %d trailing bytes", len(r.b))
+	}
+	return coordinates, nil
+}
+
+func parseWKB(b []byte) (MultiPolygonCoordinates, error) {
+	r := &wkbReader{b: b}
+	return r.parse()
+}
+
+// ParseWKB parses WKB, EWKB or ISO WKB Polygon and MultiPolygon to polygons,
+// SRID and Z/M values are ignored.
+func ParseWKB(b []byte) ([]*pb.Polygon, error) {
+	coordinates, err := parseWKB(b)
+	if err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: %w", err)
+	}
+	return FromGeoMultipolygonToPbPolygon(coordinates), nil
+}
+
+// ParseHexWKB parses hex encoded WKB, like PostGIS's text output.
+func ParseHexWKB(s string) ([]*pb.Polygon, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: wkb: %w", err)
+	}
+	return ParseWKB(b)
+}
+
+func appendWKBRing(b []byte, points []*pb.Point) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(points)))
+	for _, point := range points {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(float64(point.Lng)))
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(float64(point.Lat)))
+	}
+	return b
+}
+
+// RevertWKB encodes location's polygons as a little endian WKB MultiPolygon.
+func RevertWKB(input *pb.Location) []byte {
+	b := []byte{1}
+	b = binary.LittleEndian.AppendUint32(b, wkbMultiPolygon)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(input.Polygons)))
+	for _, polygon := range input.Polygons {
+		b = append(b, 1)
+		b = binary.LittleEndian.AppendUint32(b, wkbPolygon)
+		b = binary.LittleEndian.AppendUint32(b, uint32(1+len(polygon.Holes)))
+		b = appendWKBRing(b, polygon.Points)
+		for _, hole := range polygon.Holes {
+			b = appendWKBRing(b, hole.Points)
+		}
+	}
+	return b
+}
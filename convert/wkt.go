@@ -0,0 +1,226 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// wktParser parses WKT Polygon and MultiPolygon, EWKT's SRID prefix and
+// Z/M values are accepted and ignored.
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) skipSpaces() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *wktParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("position %d: %v", p.pos, fmt.Sprintf(format, args...))
+}
+
+// word reads a keyword in upper case.
+func (p *wktParser) word() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			break
+		}
+		p.pos++
+	}
+	return strings.ToUpper(p.s[start:p.pos])
+}
+
+func (p *wktParser) peek() byte {
+	p.skipSpaces()
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *wktParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("want %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *wktParser) number() (float64, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		return 0, p.errorf("invalid number %q", p.s[start:p.pos])
+	}
+	return v, nil
+}
+
+// list parses "(item, item, ...)".
+func (p *wktParser) list(item func() error) error {
+	if err := p.expect('('); err != nil {
+		return err
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	return p.expect(')')
+}
+
+func (p *wktParser) ring() ([][2]float64, error) {
+	ring := [][2]float64{}
+	err := p.list(func() error {
+		x, err := p.number()
+		if err != nil {
+			return err
+		}
+		y, err := p.number()
+		if err != nil {
+			return err
+		}
+		// Z and M values
+		for c := p.peek(); c != ',' && c != ')' && c != 0; c = p.peek() {
+			if _, err := p.number(); err != nil {
+				return err
+			}
+		}
+		ring = append(ring, [2]float64{x, y})
+		return nil
+	})
+	return ring, err
+}
+
+func (p *wktParser) polygon() (PolygonCoordinates, error) {
+	polygon := PolygonCoordinates{}
+	err := p.list(func() error {
+		ring, err := p.ring()
+		polygon = append(polygon, ring)
+		return err
+	})
+	return polygon, err
+}
+
+func (p *wktParser) parse() (MultiPolygonCoordinates, error) {
+	typ := p.word()
+	if typ == "SRID" {
+		end := strings.IndexByte(p.s, ';')
+		if end < 0 {
+			return nil, p.errorf("SRID without ;")
+		}
+		p.pos = end + 1
+		typ = p.word()
+	}
+	if typ != "POLYGON" && typ != "MULTIPOLYGON" {
+		return nil, unsupportedGeometry("geometry type %q is not Polygon or MultiPolygon", typ)
+	}
+	// dimension keyword, like POLYGON Z
+	word := p.word()
+	switch word {
+	case "Z", "M", "ZM":
+		word = p.word()
+	}
+	switch word {
+	case "EMPTY":
+		return MultiPolygonCoordinates{}, nil
+	case "":
+	default:
+		return nil, p.errorf("unknown keyword %v", word)
+	}
+
+	var coordinates MultiPolygonCoordinates
+	if typ == "POLYGON" {
+		polygon, err := p.polygon()
+		if err != nil {
+			return nil, err
+		}
+		coordinates = append(coordinates, polygon)
+	} else {
+		err := p.list(func() error {
+			polygon, err := p.polygon()
+			coordinates = append(coordinates, polygon)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.skipSpaces(); p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return coordinates, nil
+}
+
+func parseWKT(s string) (MultiPolygonCoordinates, error) {
+	p := &wktParser{s: s}
+	coordinates, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("wkt: %w", err)
+	}
+	return coordinates, nil
+}
+
+// ParseWKT parses WKT or EWKT Polygon and MultiPolygon to polygons, SRID
+// and Z/M values are ignored.
+func ParseWKT(s string) ([]*pb.Polygon, error) {
+	coordinates, err := parseWKT(s)
+	if err != nil {
+		return nil, fmt.Errorf("pinpoint/convert: %w", err)
+	}
+	return FromGeoMultipolygonToPbPolygon(coordinates), nil
+}
+
+func appendWKTFloat(b []byte, v float32) []byte {
+	return strconv.AppendFloat(b, float64(v), 'f', -1, 32)
+}
+
+func appendWKTRing(b []byte, points []*pb.Point) []byte {
+	b = append(b, '(')
+	for i, point := range points {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = appendWKTFloat(b, point.Lng)
+		b = append(b, ' ')
+		b = appendWKTFloat(b, point.Lat)
+	}
+	return append(b, ')')
+}
+
+// RevertWKT formats location's polygons as a WKT MultiPolygon.
+func RevertWKT(input *pb.Location) string {
+	if len(input.Polygons) == 0 {
+		return "MULTIPOLYGON EMPTY"
+	}
+	b := []byte("MULTIPOLYGON (")
+	for i, polygon := range input.Polygons {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = append(b, '(')
+		b = appendWKTRing(b, polygon.Points)
+		for _, hole := range polygon.Holes {
+			b = append(b, ", "...)
+			b = appendWKTRing(b, hole.Points)
+		}
+		b = append(b, ')')
+	}
+	return string(append(b, ')'))
+}
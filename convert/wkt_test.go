@@ -0,0 +1,144 @@
+package convert_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+
+	usstates "github.com/deslittle/pinpoint-us-states"
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func polygonsString(polygons []*pb.Polygon) string {
+	return convert.RevertWKT(&pb.Location{Polygons: polygons})
+}
+
+func TestParseWKT(t *testing.T) {
+	cases := []struct {
+		input  string
+		expect string
+		err    string
+	}{
+		{
+			input:  "POLYGON ((0 0, 4 0, 4 4, 0 4, 0 0), (1 1, 1 2, 2 2, 1 1))",
+			expect: "MULTIPOLYGON (((0 0, 4 0, 4 4, 0 4, 0 0), (1 1, 1 2, 2 2, 1 1)))",
+		},
+		{
+			input:  "multipolygon(((0 0,1 0,1 1,0 0)),((5.5 5,6 5,6 6,5.5 5)))",
+			expect: "MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)), ((5.5 5, 6 5, 6 6, 5.5 5)))",
+		},
+		{
+			input:  "SRID=4326;POLYGON Z ((0 0 10, 1 0 10, 1 1 10, 0 0 10))",
+			expect: "MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)))",
+		},
+		{input: "POLYGON EMPTY", expect: "MULTIPOLYGON EMPTY"},
+		{input: "POINT (1 2)", err: `wkt: geometry type "POINT" is not Polygon or MultiPolygon`},
+		{input: "POLYGON ((0 0, 1 0, 1 1, 0 0)", err: "wkt: position 29: want ')'"},
+		{input: "POLYGON ((0 0, 1 x))", err: `wkt: position 17: invalid number ""`},
+		{input: "POLYGON ((0 0, 1 0, 1 1, 0 0)) x", err: `wkt: position 31: unexpected "x"`},
+	}
+	for _, c := range cases {
+		polygons, err := convert.ParseWKT(c.input)
+		if c.err != "" {
+			if err == nil || err.Error() != "pinpoint/convert: "+c.err {
+				t.Errorf("%v: got error %v, want %v", c.input, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", c.input, err)
+			continue
+		}
+		if got := polygonsString(polygons); got != c.expect {
+			t.Errorf("%v: got %v, want %v", c.input, got, c.expect)
+		}
+	}
+}
+
+func TestParseWKB(t *testing.T) {
+	// big endian EWKB Polygon Z with SRID 4326, like PostGIS
+	b := []byte{0}
+	b = binary.BigEndian.AppendUint32(b, 0x80000000|0x20000000|3)
+	b = binary.BigEndian.AppendUint32(b, 4326)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint32(b, 4)
+	for _, xyz := range [][3]float64{{0, 0, 9}, {1, 0, 9}, {1, 1, 9}, {0, 0, 9}} {
+		for _, v := range xyz {
+			b = binary.BigEndian.AppendUint64(b, math.Float64bits(v))
+		}
+	}
+	polygons, err := convert.ParseHexWKB(hex.EncodeToString(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expect := polygonsString(polygons), "MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)))"; got != expect {
+		t.Errorf("got %v, want %v", got, expect)
+	}
+
+	for i := 0; i < len(b); i++ {
+		if _, err := convert.ParseWKB(b[:i]); err == nil {
+			t.Errorf("truncated at %d: got no error", i)
+		}
+	}
+	if _, err := convert.ParseWKB(append(b, 0)); err == nil {
+		t.Error("trailing byte: got no error")
+	}
+}
+
+func TestRevertCSV(t *testing.T) {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.LiteData, input); err != nil {
+		t.Fatal(err)
+	}
+	for i, location := range input.Locations {
+		location.Id = strings.Repeat("0", i%2) + location.Name
+	}
+	for _, encoding := range []convert.GeometryEncoding{convert.WKT, convert.HexWKB} {
+		buf := &bytes.Buffer{}
+		if err := convert.RevertCSV(buf, input, encoding); err != nil {
+			t.Fatal(err)
+		}
+		got, err := convert.ReadCSV(buf, convert.SetIDProperty("id"), convert.SetNameProperty("name"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(input, got) {
+			t.Errorf("encoding %v: got different locations", encoding)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	input := "GEOID,NAME,geom\n" +
+		"44,Rhode Island,\"POLYGON ((0 0, 1 0, 1 1, 0 0))\"\n" +
+		"36,New York,\"LINESTRING (0 0, 1 1)\"\n"
+	reader, err := convert.NewCSVReader(strings.NewReader(input),
+		convert.SetGeometryColumn("geom"),
+		convert.SetIDProperty("GEOID"),
+		convert.SetNameProperty("NAME"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Id != "44" || location.Name != "Rhode Island" || len(location.Polygons) != 1 {
+		t.Errorf("got %v", location)
+	}
+	_, err = reader.Next()
+	expect := `pinpoint/convert: csv row 1 "New York": wkt: geometry type "LINESTRING" is not Polygon or MultiPolygon`
+	if err == nil || err.Error() != expect {
+		t.Errorf("got error %v, want %v", err, expect)
+	}
+
+	if _, err := convert.NewCSVReader(strings.NewReader(input)); err == nil {
+		t.Error("missing geometry column: got no error")
+	}
+}
@@ -0,0 +1,213 @@
+// Package coverage finds where locations' boundaries don't tile: overlaps,
+// covered by two or more locations, where a lookup could return either, and
+// gaps, covered by none but enclosed by locations, where a lookup returns
+// nothing.
+//
+// Area outside of all locations connected to the dataset's outer boundary,
+// like oceans and other countries, is not a gap, but an uncovered lake
+// enclosed by locations is.
+//
+// Regions are exact polygons computed by sweeping vertical slabs between
+// every vertex and crossing of rings, areas are in square meters scaled at
+// every slab piece's latitude.
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+const metersPerDegree = orb.EarthRadius * math.Pi / 180
+
+// Kind is a kind of region.
+type Kind int
+
+const (
+	// Overlap is a region covered by two or more locations.
+	Overlap Kind = iota + 1
+	// Gap is a region covered by no location and enclosed by locations.
+	Gap
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Overlap:
+		return "overlap"
+	case Gap:
+		return "gap"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Region is a connected overlap or gap.
+type Region struct {
+	Kind Kind `json:"kind"`
+	// Locations are indexes of locations covering an overlap, or around a
+	// gap, in ascending order.
+	Locations []int    `json:"locations"`
+	Names     []string `json:"names"`
+	// Area is in square meters.
+	Area     float64          `json:"area"`
+	Geometry orb.MultiPolygon `json:"-"`
+}
+
+func (r *Region) String() string {
+	return fmt.Sprintf("%v of %v: %.1f m²", r.Kind, strings.Join(r.Names, ", "), r.Area)
+}
+
+// Report is regions found in a dataset, larger ones first.
+type Report struct {
+	Regions []*Region
+}
+
+// Check returns overlaps and gaps of input's locations. Rings are read by
+// even-odd rule, so a location's polygons overlapping each other look like
+// a hole, check them by package validate first.
+func Check(input *pb.Locations, opts ...OptionFunc) *Report {
+	opt := newOption(opts...)
+	edges, verticals := newEdges(input)
+	s := newSweep(len(input.Locations))
+	s.run(edges)
+
+	report := &Report{Regions: make([]*Region, 0)}
+	for _, group := range s.groups() {
+		region := &Region{Kind: Gap}
+		locations := map[int32]bool{}
+		if class := group[0].class; class > 0 {
+			region.Kind = Overlap
+			for _, location := range s.sets[class] {
+				locations[location] = true
+			}
+		}
+		for _, t := range group {
+			region.Area += t.area()
+			if region.Kind == Gap {
+				locations[t.bottom] = true
+				locations[t.top] = true
+			}
+		}
+		if region.Area < opt.MinArea {
+			continue
+		}
+		region.Geometry = outline(group)
+		if region.Kind == Gap {
+			// gap's vertical sides are vertical edges, not in slabs
+			for _, location := range verticalNeighbors(region.Geometry, verticals) {
+				locations[location] = true
+			}
+		}
+		for location := range locations {
+			region.Locations = append(region.Locations, int(location))
+		}
+		sort.Ints(region.Locations)
+		for _, location := range region.Locations {
+			region.Names = append(region.Names, input.Locations[location].Name)
+		}
+		report.Regions = append(report.Regions, region)
+	}
+	sort.SliceStable(report.Regions, func(i, j int) bool {
+		return report.Regions[i].Area > report.Regions[j].Area
+	})
+	return report
+}
+
+// verticalNeighbors returns locations of vertical edges overlapping
+// geometry's vertical segments.
+func verticalNeighbors(geometry orb.MultiPolygon, verticals map[float64][]*edge) []int32 {
+	ret := make([]int32, 0)
+	for _, polygon := range geometry {
+		for _, ring := range polygon {
+			for i := 1; i < len(ring); i++ {
+				a, b := ring[i-1], ring[i]
+				if a[0] != b[0] {
+					continue
+				}
+				lo, hi := math.Min(a[1], b[1]), math.Max(a[1], b[1])
+				for _, e := range verticals[a[0]] {
+					if math.Min(hi, e.y1) > math.Max(lo, e.y0) {
+						ret = append(ret, e.location)
+					}
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// Count returns number of regions of kind.
+func (r *Report) Count(kind Kind) int {
+	n := 0
+	for _, region := range r.Regions {
+		if region.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+var kindColors = map[Kind]string{
+	Overlap: "#e41a1c",
+	Gap:     "#377eb8",
+}
+
+// GeoJSON dumps regions as a GeoJSON FeatureCollection for review on maps
+// like geojson.io, overlaps are red and gaps are blue.
+func (r *Report) GeoJSON() []byte {
+	fc := geojson.NewFeatureCollection()
+	for _, region := range r.Regions {
+		feature := geojson.NewFeature(region.Geometry)
+		feature.Properties["kind"] = region.Kind.String()
+		feature.Properties["locations"] = region.Locations
+		feature.Properties["names"] = region.Names
+		feature.Properties["area"] = region.Area
+		feature.Properties["fill"] = kindColors[region.Kind]
+		feature.Properties["stroke"] = kindColors[region.Kind]
+		fc.Append(feature)
+	}
+	b, _ := json.Marshal(fc)
+	return b
+}
+
+// WriteTable writes a summary table of regions grouped by kind and
+// locations, larger total area first.
+func (r *Report) WriteTable(w io.Writer) error {
+	type row struct {
+		kind    Kind
+		names   string
+		regions int
+		area    float64
+	}
+	rows := make([]*row, 0)
+	index := map[string]*row{}
+	for _, region := range r.Regions {
+		names := strings.Join(region.Names, ", ")
+		key := region.Kind.String() + "\x00" + fmt.Sprint(region.Locations)
+		if index[key] == nil {
+			index[key] = &row{kind: region.Kind, names: names}
+			rows = append(rows, index[key])
+		}
+		index[key].regions++
+		index[key].area += region.Area
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].area > rows[j].area })
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "kind\tlocations\tregions\tarea(m²)\t")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%v\t%v\t%d\t%.1f\t\n", row.kind, row.names, row.regions, row.area)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,121 @@
+package coverage_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	usstates "github.com/deslittle/pinpoint-us-states"
+	"github.com/deslittle/pinpoint/coverage"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"google.golang.org/protobuf/proto"
+)
+
+func location(name string, coordinates ...float32) *pb.Location {
+	points := make([]*pb.Point, 0, len(coordinates)/2+1)
+	for i := 0; i+1 < len(coordinates); i += 2 {
+		points = append(points, &pb.Point{Lng: coordinates[i], Lat: coordinates[i+1]})
+	}
+	points = append(points, points[0])
+	return &pb.Location{Name: name, Polygons: []*pb.Polygon{{Points: points}}}
+}
+
+func box(name string, minX, minY, maxX, maxY float32) *pb.Location {
+	return location(name, minX, minY, maxX, minY, maxX, maxY, minX, maxY)
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     []*pb.Location
+		expect    []string
+		expectBox []orb.Bound
+	}{
+		{"tiled", []*pb.Location{box("a", 0, 0, 1, 1), box("b", 1, 0, 2, 1)}, nil, nil},
+		{
+			"overlap", []*pb.Location{box("a", 0, 0, 2, 2), box("b", 1, 0, 3, 2)},
+			[]string{"overlap [0 1]"}, []orb.Bound{{Min: orb.Point{1, 0}, Max: orb.Point{2, 2}}},
+		},
+		{
+			"crossing", []*pb.Location{box("a", 0, 0, 2, 2), location("b", 1, 1, 2, 0, 3, 1, 2, 2)},
+			[]string{"overlap [0 1]"}, []orb.Bound{{Min: orb.Point{1, 0}, Max: orb.Point{2, 2}}},
+		},
+		{
+			"gap", []*pb.Location{box("a", 0, 0, 1, 3), box("b", 2, 0, 3, 3), box("c", 0, 3, 3, 4), box("d", 0, -1, 3, 0)},
+			[]string{"gap [0 1 2 3]"}, []orb.Bound{{Min: orb.Point{1, 0}, Max: orb.Point{2, 3}}},
+		},
+		{"bay", []*pb.Location{box("a", 0, 0, 1, 3), box("b", 2, 0, 3, 3), box("c", 0, 3, 3, 4)}, nil, nil},
+	}
+	for _, c := range cases {
+		report := coverage.Check(&pb.Locations{Locations: c.input})
+		got := make([]string, 0)
+		for i, region := range report.Regions {
+			got = append(got, fmt.Sprintf("%v %v", region.Kind, region.Locations))
+			if i < len(c.expectBox) && region.Geometry.Bound() != c.expectBox[i] {
+				t.Errorf("%v: got bound %v, want %v", c.name, region.Geometry.Bound(), c.expectBox[i])
+			}
+			if area := geo.Area(region.Geometry); math.Abs(area-region.Area) > 0.01*area {
+				t.Errorf("%v: got area %v, geometry's area %v", c.name, region.Area, area)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.expect) {
+			t.Errorf("%v: got %v, want %v", c.name, got, c.expect)
+		}
+	}
+}
+
+func TestCheckUSStates(t *testing.T) {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.LiteData, input); err != nil {
+		t.Fatal(err)
+	}
+	report := coverage.Check(input)
+	// reduced borders don't match anymore
+	if report.Count(coverage.Overlap) == 0 || report.Count(coverage.Gap) == 0 {
+		t.Fatalf("got %d overlaps and %d gaps, want some", report.Count(coverage.Overlap), report.Count(coverage.Gap))
+	}
+	for _, region := range report.Regions {
+		// a gap could be an uncovered hole of one location
+		if (len(region.Locations) < 2 && region.Kind == coverage.Overlap) || region.Area < coverage.DefaultMinArea {
+			t.Errorf("got region %v", region)
+		}
+		if area := geo.Area(region.Geometry); math.Abs(area-region.Area) > 0.01*area+1 {
+			t.Errorf("got region %v, geometry's area %v", region, area)
+		}
+	}
+
+	fc := struct {
+		Features []json.RawMessage `json:"features"`
+	}{}
+	if err := json.Unmarshal(report.GeoJSON(), &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) != len(report.Regions) {
+		t.Errorf("got %d features, want %d", len(fc.Features), len(report.Regions))
+	}
+	b := &bytes.Buffer{}
+	if err := report.WriteTable(b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "kind") {
+		t.Errorf("got table\n%v", b.String())
+	}
+}
+
+func TestCheckUSStatesFull(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.FullData, input); err != nil {
+		t.Fatal(err)
+	}
+	if report := coverage.Check(input); len(report.Regions) > 0 {
+		t.Errorf("got %d regions, first %v", len(report.Regions), report.Regions[0])
+	}
+}
@@ -0,0 +1,29 @@
+package coverage
+
+type Option struct {
+	// MinArea is the smallest area in square meters of reported regions,
+	// smaller ones are usually rounding noise of shared borders.
+	MinArea float64
+}
+
+type OptionFunc = func(opt *Option)
+
+// DefaultMinArea is [Option.MinArea] if not set.
+const DefaultMinArea = 1.0
+
+func newOption(opts ...OptionFunc) *Option {
+	opt := &Option{
+		MinArea: DefaultMinArea,
+	}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+	return opt
+}
+
+// SetMinArea sets the smallest area in square meters of reported regions.
+func SetMinArea(area float64) OptionFunc {
+	return func(opt *Option) {
+		opt.MinArea = area
+	}
+}
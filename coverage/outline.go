@@ -0,0 +1,154 @@
+package coverage
+
+import (
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+type segment struct {
+	a, b orb.Point
+}
+
+// sides are y ranges of trapezoids' vertical sides at an x.
+type sides struct {
+	// ends are right sides of trapezoids ending at x.
+	ends [][2]float64
+	// starts are left sides of trapezoids starting at x.
+	starts [][2]float64
+}
+
+func covers(ranges [][2]float64, lo, hi float64) bool {
+	for _, r := range ranges {
+		if r[0] <= lo && hi <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// outline dissolves connected trapezoids to polygons. Every boundary
+// segment is directed with the region on its left, so exterior rings are
+// counterclockwise and holes clockwise.
+func outline(trapezoids []*trapezoid) orb.MultiPolygon {
+	segments := make([]segment, 0, 2*len(trapezoids))
+	verticals := map[float64]*sides{}
+	at := func(x float64) *sides {
+		if verticals[x] == nil {
+			verticals[x] = &sides{}
+		}
+		return verticals[x]
+	}
+	for _, t := range trapezoids {
+		segments = append(segments,
+			segment{orb.Point{t.x0, t.b0}, orb.Point{t.x1, t.b1}},
+			segment{orb.Point{t.x1, t.t1}, orb.Point{t.x0, t.t0}},
+		)
+		at(t.x1).ends = append(at(t.x1).ends, [2]float64{t.b1, t.t1})
+		at(t.x0).starts = append(at(t.x0).starts, [2]float64{t.b0, t.t0})
+	}
+	// vertical sides are kept where only one side has a trapezoid, split
+	// at every corner so segments meet end to end
+	xs := make([]float64, 0, len(verticals))
+	for x := range verticals {
+		xs = append(xs, x)
+	}
+	sort.Float64s(xs)
+	for _, x := range xs {
+		side := verticals[x]
+		ys := make([]float64, 0, 2*(len(side.ends)+len(side.starts)))
+		for _, r := range append(side.ends, side.starts...) {
+			ys = append(ys, r[0], r[1])
+		}
+		sort.Float64s(ys)
+		for i := 1; i < len(ys); i++ {
+			lo, hi := ys[i-1], ys[i]
+			if lo == hi {
+				continue
+			}
+			end, start := covers(side.ends, lo, hi), covers(side.starts, lo, hi)
+			switch {
+			case end && !start:
+				segments = append(segments, segment{orb.Point{x, lo}, orb.Point{x, hi}})
+			case start && !end:
+				segments = append(segments, segment{orb.Point{x, hi}, orb.Point{x, lo}})
+			}
+		}
+	}
+
+	// chain segments to rings
+	froms := map[orb.Point][]int{}
+	for i, s := range segments {
+		if s.a != s.b {
+			froms[s.a] = append(froms[s.a], i)
+		}
+	}
+	used := make([]bool, len(segments))
+	next := func(p orb.Point) (int, bool) {
+		for _, i := range froms[p] {
+			if !used[i] {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+	rings := make([]orb.Ring, 0)
+	for i, s := range segments {
+		if used[i] || s.a == s.b {
+			continue
+		}
+		used[i] = true
+		ring := orb.Ring{s.a}
+		p := s.b
+		for p != ring[0] {
+			ring = append(ring, p)
+			j, ok := next(p)
+			if !ok {
+				break
+			}
+			used[j] = true
+			p = segments[j].b
+		}
+		ring = append(ring, ring[0])
+		if ring = mergeVerticals(ring); len(ring) >= 4 {
+			rings = append(rings, ring)
+		}
+	}
+
+	polygons := make(orb.MultiPolygon, 0)
+	holes := make([]orb.Ring, 0)
+	for _, ring := range rings {
+		if ring.Orientation() == orb.CCW {
+			polygons = append(polygons, orb.Polygon{ring})
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+	for _, hole := range holes {
+		owner, ownerArea := -1, 0.0
+		for i, polygon := range polygons {
+			area := planar.Area(polygon[0])
+			if (owner < 0 || area < ownerArea) && planar.RingContains(polygon[0], hole[0]) {
+				owner, ownerArea = i, area
+			}
+		}
+		if owner >= 0 {
+			polygons[owner] = append(polygons[owner], hole)
+		}
+	}
+	return polygons
+}
+
+// mergeVerticals removes middle points of consecutive vertical segments of
+// a closed ring.
+func mergeVerticals(ring orb.Ring) orb.Ring {
+	ret := make(orb.Ring, 0, len(ring))
+	for i, p := range ring {
+		if i > 0 && i < len(ring)-1 && ring[i-1][0] == p[0] && p[0] == ring[i+1][0] {
+			continue
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
@@ -0,0 +1,367 @@
+package coverage
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// edge is a non-vertical ring segment from left to right.
+type edge struct {
+	x0, y0, x1, y1 float64
+	location       int32
+}
+
+// y returns edge's y at x, exact at its ends so shared vertices of
+// different edges are equal.
+func (e *edge) y(x float64) float64 {
+	switch x {
+	case e.x0:
+		return e.y0
+	case e.x1:
+		return e.y1
+	}
+	return e.y0 + (e.y1-e.y0)*(x-e.x0)/(e.x1-e.x0)
+}
+
+// crossX returns x where a and b cross.
+func crossX(a, b *edge) (float64, bool) {
+	ka := (a.y1 - a.y0) / (a.x1 - a.x0)
+	kb := (b.y1 - b.y0) / (b.x1 - b.x0)
+	if ka == kb {
+		return 0, false
+	}
+	// a.y0 + ka*(x-a.x0) == b.y0 + kb*(x-b.x0)
+	return (b.y0 - a.y0 + ka*a.x0 - kb*b.x0) / (ka - kb), true
+}
+
+// newEdges returns non-vertical edges sorted by x0, and vertical edges by
+// their x, whose y0 <= y1.
+func newEdges(input *pb.Locations) ([]*edge, map[float64][]*edge) {
+	edges := make([]*edge, 0)
+	verticals := map[float64][]*edge{}
+	addRing := func(location int32, points []*pb.Point) {
+		n := len(points)
+		if n > 1 && points[0].Lng == points[n-1].Lng && points[0].Lat == points[n-1].Lat {
+			n--
+		}
+		for i := 0; i < n; i++ {
+			a, b := points[i], points[(i+1)%n]
+			e := &edge{float64(a.Lng), float64(a.Lat), float64(b.Lng), float64(b.Lat), location}
+			if e.x0 == e.x1 {
+				if e.y0 > e.y1 {
+					e.y0, e.y1 = e.y1, e.y0
+				}
+				verticals[e.x0] = append(verticals[e.x0], e)
+				continue
+			}
+			if e.x0 > e.x1 {
+				e.x0, e.y0, e.x1, e.y1 = e.x1, e.y1, e.x0, e.y0
+			}
+			edges = append(edges, e)
+		}
+	}
+	for i, location := range input.Locations {
+		for _, polygon := range location.Polygons {
+			addRing(int32(i), polygon.Points)
+			for _, hole := range polygon.Holes {
+				addRing(int32(i), hole.Points)
+			}
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].x0 < edges[j].x0 })
+	return edges, verticals
+}
+
+// trapezoid is a piece of a slab between two edges, bottom from (x0, b0) to
+// (x1, b1) and top from (x0, t0) to (x1, t1).
+type trapezoid struct {
+	x0, x1, b0, b1, t0, t1 float64
+	// class is 0 for uncovered pieces, i for pieces covered by sets[i].
+	class int32
+	// bottom and top are locations of bottom and top edges.
+	bottom, top int32
+}
+
+// area returns trapezoid's area in square meters, scaled at its center.
+func (t *trapezoid) area() float64 {
+	lat := (t.b0 + t.b1 + t.t0 + t.t1) / 4
+	deg2 := (t.x1 - t.x0) * ((t.t0 - t.b0) + (t.t1 - t.b1)) / 2
+	return deg2 * metersPerDegree * metersPerDegree * math.Cos(lat*math.Pi/180)
+}
+
+// interval is a piece of a slab between two edges, which is outside of all
+// locations if id is 0.
+type interval struct {
+	b0, t0, b1, t1 float64
+	class          int32
+	id             int32
+	bottom, top    int32
+}
+
+// maxSplits limits how many times a slab is split at crossings, rounding
+// could make a crossing look like it's never passed.
+const maxSplits = 8
+
+// sweep splits plane into vertical slabs at every vertex and crossing, in
+// which edges don't cross, and pieces between edges not covered by exactly
+// one location are kept as trapezoids. Connected pieces of the same class
+// are joined by a union-find, pieces connected to area outside of all
+// locations are joined to 0.
+type sweep struct {
+	active   []*edge
+	keys     []float64
+	parity   []bool
+	members  []int32
+	prev     []interval
+	cur      []interval
+	pairs    [][2]int32
+	sets     [][]int32
+	setIndex map[string]int32
+
+	// trapezoids[i-1] is trapezoid of id i.
+	trapezoids []trapezoid
+	parents    []int32
+}
+
+func newSweep(locations int) *sweep {
+	return &sweep{
+		parity:   make([]bool, locations),
+		sets:     [][]int32{nil},
+		setIndex: map[string]int32{},
+		prev:     []interval{{b0: math.Inf(-1), t0: math.Inf(1), b1: math.Inf(-1), t1: math.Inf(1)}},
+		parents:  []int32{0},
+	}
+}
+
+func (s *sweep) run(edges []*edge) {
+	if len(edges) == 0 {
+		return
+	}
+	next := 0
+	x := edges[0].x0
+	for {
+		active := s.active[:0]
+		for _, e := range s.active {
+			if e.x1 > x {
+				active = append(active, e)
+			}
+		}
+		for next < len(edges) && edges[next].x0 <= x {
+			active = append(active, edges[next])
+			next++
+		}
+		s.active = active
+		nextX := math.Inf(1)
+		if next < len(edges) {
+			nextX = edges[next].x0
+		}
+		for _, e := range s.active {
+			nextX = math.Min(nextX, e.x1)
+		}
+		if math.IsInf(nextX, 1) {
+			return
+		}
+		s.slab(x, nextX, 0)
+		x = nextX
+	}
+}
+
+// slab sorts active edges at the middle of x0 and x1 and splits the slab
+// at crossings.
+func (s *sweep) slab(x0, x1 float64, splits int) {
+	mid := x0 + (x1-x0)/2
+	s.keys = s.keys[:0]
+	for _, e := range s.active {
+		s.keys = append(s.keys, e.y(mid))
+	}
+	// insertion sort, active edges are almost sorted by last slab
+	for i := 1; i < len(s.active); i++ {
+		for j := i; j > 0 && s.keys[j] < s.keys[j-1]; j-- {
+			s.keys[j], s.keys[j-1] = s.keys[j-1], s.keys[j]
+			s.active[j], s.active[j-1] = s.active[j-1], s.active[j]
+		}
+	}
+	if splits < maxSplits {
+		cuts := make([]float64, 0)
+		for i := 1; i < len(s.active); i++ {
+			a, b := s.active[i-1], s.active[i]
+			if a.y(x0) <= b.y(x0) && a.y(x1) <= b.y(x1) {
+				continue
+			}
+			if x, ok := crossX(a, b); ok && x0 < x && x < x1 {
+				cuts = append(cuts, x)
+			}
+		}
+		if len(cuts) > 0 {
+			sort.Float64s(cuts)
+			for _, x := range append(cuts, x1) {
+				if x > x0 {
+					s.slab(x0, x, splits+1)
+					x0 = x
+				}
+			}
+			return
+		}
+	}
+	s.walk(x0, x1)
+}
+
+// walk keeps pieces of a slab whose edges don't cross, and links them to
+// pieces of last slab.
+func (s *sweep) walk(x0, x1 float64) {
+	s.cur = s.cur[:0]
+	b0, b1 := math.Inf(-1), math.Inf(-1)
+	bottom := int32(-1)
+	for i := 0; i <= len(s.active); i++ {
+		t0, t1 := math.Inf(1), math.Inf(1)
+		top := int32(-1)
+		if i < len(s.active) {
+			e := s.active[i]
+			// edges crossing at x0 or x1 could be out of order by
+			// rounding, which makes pieces upside down
+			t0, t1, top = math.Max(e.y(x0), b0), math.Max(e.y(x1), b1), e.location
+		}
+		if len(s.members) != 1 && (t0-b0)+(t1-b1) > 0 {
+			piece := interval{b0: b0, t0: t0, b1: b1, t1: t1, bottom: bottom, top: top}
+			if len(s.members) > 1 {
+				piece.class = s.set()
+			}
+			// -1 is a placeholder until linked
+			piece.id = -1
+			if i == 0 || i == len(s.active) {
+				piece.id = 0
+			}
+			s.cur = append(s.cur, piece)
+		}
+		if i < len(s.active) {
+			s.toggle(top)
+		}
+		b0, b1, bottom = t0, t1, top
+	}
+	s.link(x0, x1)
+	s.prev, s.cur = s.cur, s.prev
+}
+
+func (s *sweep) toggle(location int32) {
+	s.parity[location] = !s.parity[location]
+	if s.parity[location] {
+		s.members = append(s.members, location)
+		return
+	}
+	for i, member := range s.members {
+		if member == location {
+			s.members = append(s.members[:i], s.members[i+1:]...)
+			return
+		}
+	}
+}
+
+// set returns class of members.
+func (s *sweep) set() int32 {
+	members := append([]int32{}, s.members...)
+	sort.Slice(members, func(i, j int) bool { return members[i] < members[j] })
+	keys := make([]string, len(members))
+	for i, member := range members {
+		keys[i] = strconv.Itoa(int(member))
+	}
+	key := strings.Join(keys, ",")
+	class, ok := s.setIndex[key]
+	if !ok {
+		class = int32(len(s.sets))
+		s.sets = append(s.sets, members)
+		s.setIndex[key] = class
+	}
+	return class
+}
+
+// link gives new pieces ids and joins them to pieces of last slab they
+// touch with the same class. New uncovered pieces touching outside area are
+// outside area too and not kept.
+func (s *sweep) link(x0, x1 float64) {
+	s.pairs = s.pairs[:0]
+	i, j := 0, 0
+	for i < len(s.prev) && j < len(s.cur) {
+		p, c := &s.prev[i], &s.cur[j]
+		if math.Min(p.t1, c.t0) > math.Max(p.b1, c.b0) && p.class == c.class {
+			s.pairs = append(s.pairs, [2]int32{int32(j), p.id})
+		}
+		if p.t1 < c.t0 {
+			i++
+		} else {
+			j++
+		}
+	}
+	k := 0
+	for j := range s.cur {
+		c := &s.cur[j]
+		start := k
+		for k < len(s.pairs) && s.pairs[k][0] == int32(j) {
+			k++
+		}
+		links := s.pairs[start:k]
+		if c.id < 0 && c.class == 0 {
+			for _, link := range links {
+				if s.find(link[1]) == 0 {
+					c.id = 0
+					break
+				}
+			}
+		}
+		if c.id < 0 {
+			s.trapezoids = append(s.trapezoids, trapezoid{
+				x0: x0, x1: x1, b0: c.b0, b1: c.b1, t0: c.t0, t1: c.t1,
+				class: c.class, bottom: c.bottom, top: c.top,
+			})
+			c.id = int32(len(s.trapezoids))
+			s.parents = append(s.parents, c.id)
+		}
+		for _, link := range links {
+			s.union(c.id, link[1])
+		}
+	}
+}
+
+func (s *sweep) find(id int32) int32 {
+	root := id
+	for s.parents[root] != root {
+		root = s.parents[root]
+	}
+	for s.parents[id] != root {
+		s.parents[id], id = root, s.parents[id]
+	}
+	return root
+}
+
+// union joins a and b, smaller root wins so 0 is always outside area's.
+func (s *sweep) union(a, b int32) {
+	ra, rb := s.find(a), s.find(b)
+	if ra < rb {
+		ra, rb = rb, ra
+	}
+	s.parents[ra] = rb
+}
+
+// groups returns trapezoids of every kept region, in order of their first
+// trapezoid.
+func (s *sweep) groups() [][]*trapezoid {
+	index := map[int32]int{}
+	ret := make([][]*trapezoid, 0)
+	for i := range s.trapezoids {
+		root := s.find(int32(i + 1))
+		if root == 0 {
+			continue
+		}
+		j, ok := index[root]
+		if !ok {
+			j = len(ret)
+			index[root] = j
+			ret = append(ret, nil)
+		}
+		ret[j] = append(ret[j], &s.trapezoids[i])
+	}
+	return ret
+}
@@ -0,0 +1,280 @@
+// Package crs reprojects projected coordinates to WGS84 longitude and
+// latitude.
+//
+// Supported projections are geographic longitude/latitude, [WebMercator],
+// ellipsoidal Transverse Mercator, like UTM and many US state planes, and
+// Lambert Conformal Conic, like the other state planes. Datum shifts are not
+// applied, NAD83 and WGS84 differ about a meter in the US which is below
+// pinpoint's precision.
+//
+// [Parse] reads EPSG codes, OGC URNs like GeoJSON's crs member, and PROJ
+// strings like "+proj=lcc +lat_1=33 +lat_2=45 +lat_0=23 +lon_0=-96".
+package crs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Projection converts between projected coordinates and WGS84 degrees.
+type Projection interface {
+	// Inverse returns longitude and latitude of projected x and y.
+	Inverse(x, y float64) (lng, lat float64)
+	// Forward returns projected x and y of longitude and latitude.
+	Forward(lng, lat float64) (x, y float64)
+}
+
+// Ellipsoid is a reference ellipsoid, A is semi-major axis in meters and F is
+// flattening.
+type Ellipsoid struct {
+	A float64
+	F float64
+}
+
+var (
+	WGS84Ellipsoid = Ellipsoid{A: 6378137, F: 1 / 298.257223563}
+	GRS80          = Ellipsoid{A: 6378137, F: 1 / 298.257222101}
+	Clarke1866     = Ellipsoid{A: 6378206.4, F: 1 / 294.978698214}
+)
+
+// E returns first eccentricity.
+func (e Ellipsoid) E() float64 {
+	return math.Sqrt(e.F * (2 - e.F))
+}
+
+// Units to meters.
+const (
+	Meter      = 1
+	Foot       = 0.3048
+	USSurveyFt = 1200.0 / 3937
+)
+
+const (
+	deg = math.Pi / 180
+	rad = 180 / math.Pi
+)
+
+type lngLat struct{}
+
+// LngLat is geographic longitude and latitude in degrees, which needs no
+// reprojection.
+var LngLat Projection = lngLat{}
+
+func (lngLat) Inverse(x, y float64) (float64, float64) {
+	return x, y
+}
+
+func (lngLat) Forward(lng, lat float64) (float64, float64) {
+	return lng, lat
+}
+
+// utm returns UTM zone's projection in unit, south for southern hemisphere.
+func utm(ellipsoid Ellipsoid, zone int, south bool, unit float64) Projection {
+	p := &transverseMercator{
+		Ellipsoid: ellipsoid,
+		Lng0:      float64(zone*6 - 183),
+		K0:        0.9996,
+		X0:        500000,
+		Unit:      unit,
+	}
+	if south {
+		p.Y0 = 10000000
+	}
+	return p.init()
+}
+
+// epsg returns projection of an EPSG code.
+func epsg(code int) (Projection, bool) {
+	switch {
+	case code == 4326 || code == 4269 || code == 4258 || code == 4283:
+		// WGS84, NAD83, ETRS89 and GDA94
+		return LngLat, true
+	case code == 3857 || code == 900913 || code == 3785 || code == 102100 || code == 102113:
+		return WebMercator, true
+	case 32601 <= code && code <= 32660:
+		return utm(WGS84Ellipsoid, code-32600, false, Meter), true
+	case 32701 <= code && code <= 32760:
+		return utm(WGS84Ellipsoid, code-32700, true, Meter), true
+	case 26901 <= code && code <= 26923:
+		// NAD83 UTM zones
+		return utm(GRS80, code-26900, false, Meter), true
+	}
+	return nil, false
+}
+
+// Parse returns projection of name, which is one of:
+//
+//   - EPSG code like "EPSG:3857", geographic, Web Mercator and UTM zones are
+//     supported.
+//   - OGC URN like "urn:ogc:def:crs:EPSG::3857" or "urn:ogc:def:crs:OGC:1.3:CRS84".
+//   - PROJ string with +proj=longlat, merc (spherical Web Mercator), tmerc,
+//     utm or lcc.
+func Parse(name string) (Projection, error) {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "+") {
+		return parseProj(name)
+	}
+	upper := strings.ToUpper(name)
+	switch upper {
+	case "", "CRS84", "OGC:CRS84", "URN:OGC:DEF:CRS:OGC:1.3:CRS84", "URN:OGC:DEF:CRS:OGC::CRS84":
+		return LngLat, nil
+	}
+	code := ""
+	switch {
+	case strings.HasPrefix(upper, "EPSG:"):
+		code = upper[len("EPSG:"):]
+	case strings.HasPrefix(upper, "URN:OGC:DEF:CRS:EPSG:"):
+		// version could be between, like urn:ogc:def:crs:EPSG:6.6:4326
+		code = upper[strings.LastIndexByte(upper, ':')+1:]
+	default:
+		return nil, fmt.Errorf("pinpoint/crs: unknown crs %q", name)
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, fmt.Errorf("pinpoint/crs: invalid EPSG code %q", name)
+	}
+	p, ok := epsg(n)
+	if !ok {
+		return nil, fmt.Errorf("pinpoint/crs: EPSG:%d is not supported, use a PROJ string", n)
+	}
+	return p, nil
+}
+
+// Flag is a [flag.Value] of a projection parsed by [Parse], like converters'
+// -crs flag. Projection is nil if not set or set to "", which lets the source
+// name its CRS.
+type Flag struct {
+	Name       string
+	Projection Projection
+}
+
+func (f *Flag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.Name
+}
+
+func (f *Flag) Set(name string) error {
+	f.Name, f.Projection = name, nil
+	if name == "" {
+		return nil
+	}
+	p, err := Parse(name)
+	if err != nil {
+		return err
+	}
+	f.Projection = p
+	return nil
+}
+
+// parseProj parses a PROJ string like "+proj=tmerc +lon_0=-75 +k=0.9996".
+func parseProj(s string) (Projection, error) {
+	params := map[string]string{}
+	for _, field := range strings.Fields(s) {
+		key, value, _ := strings.Cut(strings.TrimPrefix(field, "+"), "=")
+		params[key] = value
+	}
+	float := func(key string, fallback float64) (float64, error) {
+		value, ok := params[key]
+		if !ok {
+			return fallback, nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("pinpoint/crs: invalid +%v=%v", key, value)
+		}
+		return v, nil
+	}
+	var err error
+	get := func(key string, fallback float64) float64 {
+		v, e := float(key, fallback)
+		if e != nil && err == nil {
+			err = e
+		}
+		return v
+	}
+
+	// +ellps takes precedence over +datum's ellipsoid
+	name := params["ellps"]
+	if name == "" {
+		name = params["datum"]
+	}
+	ellipsoid := WGS84Ellipsoid
+	switch strings.ToUpper(name) {
+	case "", "WGS84":
+	case "GRS80", "NAD83":
+		ellipsoid = GRS80
+	case "CLRK66", "NAD27":
+		ellipsoid = Clarke1866
+	default:
+		return nil, fmt.Errorf("pinpoint/crs: unknown ellipsoid %q", name)
+	}
+	if _, ok := params["a"]; ok {
+		ellipsoid.A = get("a", 0)
+		if _, ok := params["rf"]; ok {
+			ellipsoid.F = 1 / get("rf", 0)
+		} else if _, ok := params["b"]; ok {
+			ellipsoid.F = 1 - get("b", 0)/ellipsoid.A
+		}
+	}
+	unit := get("to_meter", Meter)
+	switch params["units"] {
+	case "", "m":
+	case "ft":
+		unit = Foot
+	case "us-ft":
+		unit = USSurveyFt
+	default:
+		return nil, fmt.Errorf("pinpoint/crs: unknown units %q", params["units"])
+	}
+
+	var p Projection
+	switch params["proj"] {
+	case "longlat", "latlong", "lonlat", "latlon":
+		p = LngLat
+	case "merc":
+		if get("lon_0", 0) != 0 || get("lat_ts", 0) != 0 || get("x_0", 0) != 0 || get("y_0", 0) != 0 {
+			return nil, fmt.Errorf("pinpoint/crs: only Web Mercator is supported for +proj=merc")
+		}
+		p = WebMercator
+	case "utm":
+		zone := int(get("zone", 0))
+		if zone < 1 || zone > 60 {
+			return nil, fmt.Errorf("pinpoint/crs: invalid UTM zone %v", params["zone"])
+		}
+		_, south := params["south"]
+		p = utm(ellipsoid, zone, south, unit)
+	case "tmerc":
+		p = (&transverseMercator{
+			Ellipsoid: ellipsoid,
+			Lat0:      get("lat_0", 0),
+			Lng0:      get("lon_0", 0),
+			K0:        get("k_0", get("k", 1)),
+			X0:        get("x_0", 0),
+			Y0:        get("y_0", 0),
+			Unit:      unit,
+		}).init()
+	case "lcc":
+		lat1 := get("lat_1", 0)
+		p = (&lambertConformalConic{
+			Ellipsoid: ellipsoid,
+			Lat0:      get("lat_0", 0),
+			Lng0:      get("lon_0", 0),
+			Lat1:      lat1,
+			Lat2:      get("lat_2", lat1),
+			K0:        get("k_0", get("k", 1)),
+			X0:        get("x_0", 0),
+			Y0:        get("y_0", 0),
+			Unit:      unit,
+		}).init()
+	default:
+		return nil, fmt.Errorf("pinpoint/crs: unsupported +proj=%v", params["proj"])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,83 @@
+package crs_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deslittle/pinpoint/crs"
+)
+
+func TestProjections(t *testing.T) {
+	cases := []struct {
+		crs      string
+		lng, lat float64
+		x, y     float64
+		// tolerance is in projected units
+		tolerance float64
+	}{
+		{"EPSG:3857", 180, 0, 20037508.342789244, 0, 1e-6},
+		{"urn:ogc:def:crs:EPSG::3857", -74.006, 40.7128, -8238310.24, 4970071.58, 0.01},
+		// Snyder, Map Projections: A Working Manual, p. 269 and p. 296
+		{"+proj=tmerc +lon_0=-75 +k=0.9996 +ellps=clrk66", -73.5, 40.5, 127106.5, 4484124.4, 0.1},
+		{"+proj=lcc +lat_1=33 +lat_2=45 +lat_0=23 +lon_0=-96 +ellps=clrk66", -75, 35, 1894410.9, 1564649.5, 0.1},
+		{"+proj=tmerc +lon_0=-75 +k=0.9996 +ellps=clrk66 +datum=NAD83", -73.5, 40.5, 127106.5, 4484124.4, 0.1},
+		{"EPSG:32618", -75, 0, 500000, 0, 1e-6},
+		{"+proj=utm +zone=18 +ellps=WGS84 +datum=WGS84", -75, 0, 500000, 0, 1e-6},
+		{"+proj=utm +zone=18 +ellps=GRS80 +datum=NAD83", -75, 0, 500000, 0, 1e-6},
+		{"+proj=utm +zone=18 +datum=NAD83 +units=us-ft", -75, 0, 1640416.6667, 0, 1e-4},
+		{"+proj=utm +zone=18 +datum=NAD83 +to_meter=0.3048", -75, 0, 1640419.9475, 0, 1e-4},
+		{"EPSG:4326", -75, 35, -75, 35, 0},
+	}
+	for _, c := range cases {
+		p, err := crs.Parse(c.crs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, y := p.Forward(c.lng, c.lat)
+		if math.Abs(x-c.x) > c.tolerance || math.Abs(y-c.y) > c.tolerance {
+			t.Errorf("%v: got %v %v, want %v %v", c.crs, x, y, c.x, c.y)
+		}
+		lng, lat := p.Inverse(x, y)
+		if math.Abs(lng-c.lng) > 1e-9 || math.Abs(lat-c.lat) > 1e-9 {
+			t.Errorf("%v: inverse got %v %v, want %v %v", c.crs, lng, lat, c.lng, c.lat)
+		}
+	}
+}
+
+func TestStatePlaneFeet(t *testing.T) {
+	// NAD83 New York Long Island in US survey feet, EPSG:2263
+	p, err := crs.Parse("+proj=lcc +lat_1=41.03333333333333 +lat_2=40.66666666666666 +lat_0=40.16666666666666 +lon_0=-74 +x_0=300000.0000000001 +y_0=0 +ellps=GRS80 +units=us-ft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// false easting at origin
+	if x, y := p.Forward(-74, 40.16666666666666); math.Abs(x-984250) > 0.01 || math.Abs(y) > 0.01 {
+		t.Errorf("got %v %v", x, y)
+	}
+	// Empire State Building
+	lng, lat := p.Inverse(988213, 211945)
+	if math.Abs(lng+73.9857) > 1e-3 || math.Abs(lat-40.7484) > 1e-3 {
+		t.Errorf("got %v %v", lng, lat)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, name := range []string{"EPSG:5070", "EPSG:x", "+proj=aea", "+proj=utm +zone=61", "+proj=tmerc +k=x", "WGS 84"} {
+		if _, err := crs.Parse(name); err == nil {
+			t.Errorf("%v: got no error", name)
+		}
+	}
+}
+
+func TestFlag(t *testing.T) {
+	f := &crs.Flag{}
+	if err := f.Set("EPSG:3857"); err != nil || f.Projection != crs.WebMercator || f.String() != "EPSG:3857" {
+		t.Errorf("got %v %v", f, err)
+	}
+	if err := f.Set(""); err != nil || f.Projection != nil {
+		t.Errorf("empty: got %v %v", f.Projection, err)
+	}
+	if err := f.Set("EPSG:5070"); err == nil {
+		t.Error("EPSG:5070: got no error")
+	}
+}
@@ -0,0 +1,185 @@
+package crs
+
+import "math"
+
+type webMercator struct{}
+
+// WebMercator is EPSG:3857, spherical Mercator used by web maps.
+var WebMercator Projection = webMercator{}
+
+func (webMercator) Inverse(x, y float64) (float64, float64) {
+	a := WGS84Ellipsoid.A
+	return x / a * rad, (2*math.Atan(math.Exp(y/a)) - math.Pi/2) * rad
+}
+
+func (webMercator) Forward(lng, lat float64) (float64, float64) {
+	a := WGS84Ellipsoid.A
+	return lng * deg * a, math.Log(math.Tan(math.Pi/4+lat*deg/2)) * a
+}
+
+// transverseMercator is ellipsoidal Transverse Mercator by Krüger's series,
+// accurate to millimeters within thousands of kilometers of the central
+// meridian.
+//
+// Lat0 and Lng0 are origin in degrees, K0 is scale on central meridian, X0
+// and Y0 are false easting and northing in meters. Unit is meters per
+// projected unit.
+type transverseMercator struct {
+	Ellipsoid Ellipsoid
+	Lat0      float64
+	Lng0      float64
+	K0        float64
+	X0        float64
+	Y0        float64
+	Unit      float64
+
+	// a is rectifying radius, alpha and beta are Krüger's forward and
+	// inverse coefficients, delta converts conformal latitude back.
+	a     float64
+	alpha [3]float64
+	beta  [3]float64
+	delta [3]float64
+	// xi0 is origin's ξ.
+	xi0 float64
+}
+
+func (p *transverseMercator) init() *transverseMercator {
+	n := p.Ellipsoid.F / (2 - p.Ellipsoid.F)
+	n2, n3 := n*n, n*n*n
+	p.a = p.Ellipsoid.A / (1 + n) * (1 + n2/4 + n2*n2/64)
+	p.alpha = [3]float64{n/2 - 2*n2/3 + 5*n3/16, 13*n2/48 - 3*n3/5, 61 * n3 / 240}
+	p.beta = [3]float64{n/2 - 2*n2/3 + 37*n3/96, n2/48 + n3/15, 17 * n3 / 480}
+	p.delta = [3]float64{2*n - 2*n2/3 - 2*n3, 7*n2/3 - 8*n3/5, 56 * n3 / 15}
+	p.xi0 = 0
+	p.xi0, _ = p.forward(p.Lat0*deg, 0)
+	return p
+}
+
+// conformal returns conformal latitude's tangent.
+func (p *transverseMercator) conformal(lat float64) float64 {
+	e := p.Ellipsoid.E()
+	return math.Sinh(math.Atanh(math.Sin(lat)) - e*math.Atanh(e*math.Sin(lat)))
+}
+
+// forward returns ξ and η of lat and longitude difference in radians.
+func (p *transverseMercator) forward(lat, dlng float64) (float64, float64) {
+	t := p.conformal(lat)
+	xi1 := math.Atan2(t, math.Cos(dlng))
+	eta1 := math.Atanh(math.Sin(dlng) / math.Sqrt(1+t*t))
+	xi, eta := xi1, eta1
+	for j, alpha := range p.alpha {
+		k := float64(2 * (j + 1))
+		xi += alpha * math.Sin(k*xi1) * math.Cosh(k*eta1)
+		eta += alpha * math.Cos(k*xi1) * math.Sinh(k*eta1)
+	}
+	return xi - p.xi0, eta
+}
+
+func (p *transverseMercator) Forward(lng, lat float64) (float64, float64) {
+	xi, eta := p.forward(lat*deg, (lng-p.Lng0)*deg)
+	x := p.X0 + p.K0*p.a*eta
+	y := p.Y0 + p.K0*p.a*xi
+	return x / p.Unit, y / p.Unit
+}
+
+func (p *transverseMercator) Inverse(x, y float64) (float64, float64) {
+	eta := (x*p.Unit - p.X0) / (p.K0 * p.a)
+	xi := (y*p.Unit-p.Y0)/(p.K0*p.a) + p.xi0
+	xi1, eta1 := xi, eta
+	for j, beta := range p.beta {
+		k := float64(2 * (j + 1))
+		xi1 -= beta * math.Sin(k*xi) * math.Cosh(k*eta)
+		eta1 -= beta * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+	chi := math.Asin(math.Sin(xi1) / math.Cosh(eta1))
+	lat := chi
+	for j, delta := range p.delta {
+		lat += delta * math.Sin(float64(2*(j+1))*chi)
+	}
+	dlng := math.Atan2(math.Sinh(eta1), math.Cos(xi1))
+	return p.Lng0 + dlng*rad, lat * rad
+}
+
+// lambertConformalConic is ellipsoidal Lambert Conformal Conic with one or
+// two standard parallels, Lat1 equals to Lat2 for one.
+//
+// Lat0 and Lng0 are origin in degrees, K0 is scale on standard parallel, 1
+// for two standard parallels, X0 and Y0 are false easting and northing in
+// meters. Unit is meters per projected unit.
+type lambertConformalConic struct {
+	Ellipsoid Ellipsoid
+	Lat0      float64
+	Lng0      float64
+	Lat1      float64
+	Lat2      float64
+	K0        float64
+	X0        float64
+	Y0        float64
+	Unit      float64
+
+	// n is cone constant, f is Snyder's F times semi-major axis and scale,
+	// rho0 is origin's radius.
+	n    float64
+	f    float64
+	rho0 float64
+}
+
+// m returns Snyder's m of latitude in radians.
+func (p *lambertConformalConic) m(lat float64) float64 {
+	e := p.Ellipsoid.E()
+	sin := math.Sin(lat)
+	return math.Cos(lat) / math.Sqrt(1-e*e*sin*sin)
+}
+
+// t returns Snyder's t of latitude in radians.
+func (p *lambertConformalConic) t(lat float64) float64 {
+	e := p.Ellipsoid.E()
+	sin := math.Sin(lat)
+	return math.Tan(math.Pi/4-lat/2) / math.Pow((1-e*sin)/(1+e*sin), e/2)
+}
+
+func (p *lambertConformalConic) init() *lambertConformalConic {
+	lat1, lat2 := p.Lat1*deg, p.Lat2*deg
+	m1, t1 := p.m(lat1), p.t(lat1)
+	if p.Lat1 == p.Lat2 {
+		p.n = math.Sin(lat1)
+	} else {
+		p.n = (math.Log(m1) - math.Log(p.m(lat2))) / (math.Log(t1) - math.Log(p.t(lat2)))
+	}
+	p.f = p.Ellipsoid.A * p.K0 * m1 / (p.n * math.Pow(t1, p.n))
+	p.rho0 = p.f * math.Pow(p.t(p.Lat0*deg), p.n)
+	return p
+}
+
+func (p *lambertConformalConic) Forward(lng, lat float64) (float64, float64) {
+	rho := p.f * math.Pow(p.t(lat*deg), p.n)
+	theta := p.n * (lng - p.Lng0) * deg
+	x := p.X0 + rho*math.Sin(theta)
+	y := p.Y0 + p.rho0 - rho*math.Cos(theta)
+	return x / p.Unit, y / p.Unit
+}
+
+func (p *lambertConformalConic) Inverse(x, y float64) (float64, float64) {
+	dx := x*p.Unit - p.X0
+	dy := p.rho0 - (y*p.Unit - p.Y0)
+	sign := 1.0
+	if p.n < 0 {
+		sign = -1
+	}
+	rho := sign * math.Hypot(dx, dy)
+	theta := math.Atan2(sign*dx, sign*dy)
+	t := math.Pow(rho/p.f, 1/p.n)
+
+	e := p.Ellipsoid.E()
+	lat := math.Pi/2 - 2*math.Atan(t)
+	for i := 0; i < 15; i++ {
+		sin := e * math.Sin(lat)
+		next := math.Pi/2 - 2*math.Atan(t*math.Pow((1-sin)/(1+sin), e/2))
+		if math.Abs(next-lat) < 1e-12 {
+			lat = next
+			break
+		}
+		lat = next
+	}
+	return p.Lng0 + theta/p.n*rad, lat * rad
+}
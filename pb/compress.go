@@ -0,0 +1,35 @@
+package pb
+
+import "fmt"
+
+// compressMethods are CLI names of compress methods, same as their
+// coordinate encodings.
+var compressMethods = map[string]CompressMethod{
+	EncodingPolyline:    CompressMethod_Polyline,
+	EncodingDeltaVarint: CompressMethod_DeltaVarint,
+}
+
+var ringCompressions = map[string]RingCompression{
+	"none":    RingCompression_NoRingCompression,
+	"deflate": RingCompression_Deflate,
+}
+
+// ParseCompressMethod returns compress method of name, one of polyline and
+// delta-varint.
+func ParseCompressMethod(name string) (CompressMethod, error) {
+	method, ok := compressMethods[name]
+	if !ok {
+		return 0, fmt.Errorf("pinpoint/pb: unknown compress method %q", name)
+	}
+	return method, nil
+}
+
+// ParseRingCompression returns ring compression of name, one of none and
+// deflate.
+func ParseRingCompression(name string) (RingCompression, error) {
+	compression, ok := ringCompressions[name]
+	if !ok {
+		return 0, fmt.Errorf("pinpoint/pb: unknown ring compression %q", name)
+	}
+	return compression, nil
+}
@@ -23,8 +23,9 @@ const (
 type CompressMethod int32
 
 const (
-	CompressMethod_Unknown  CompressMethod = 0
-	CompressMethod_Polyline CompressMethod = 1 // https://developers.google.com/maps/documentation/utilities/polylinealgorithm
+	CompressMethod_Unknown     CompressMethod = 0
+	CompressMethod_Polyline    CompressMethod = 1 // https://developers.google.com/maps/documentation/utilities/polylinealgorithm
+	CompressMethod_DeltaVarint CompressMethod = 2 // Scaled coordinates' deltas as zigzag varints
 )
 
 // Enum value maps for CompressMethod.
@@ -32,10 +33,12 @@ var (
 	CompressMethod_name = map[int32]string{
 		0: "Unknown",
 		1: "Polyline",
+		2: "DeltaVarint",
 	}
 	CompressMethod_value = map[string]int32{
-		"Unknown":  0,
-		"Polyline": 1,
+		"Unknown":     0,
+		"Polyline":    1,
+		"DeltaVarint": 2,
 	}
 )
 
@@ -66,6 +69,104 @@ func (CompressMethod) EnumDescriptor() ([]byte, []int) {
 	return file_pb_locinfo_proto_rawDescGZIP(), []int{0}
 }
 
+// RingCompression is general-purpose compression applied to every ring's
+// bytes after CompressMethod.
+type RingCompression int32
+
+const (
+	RingCompression_NoRingCompression RingCompression = 0
+	RingCompression_Deflate           RingCompression = 1 // RFC 1951 DEFLATE
+)
+
+// Enum value maps for RingCompression.
+var (
+	RingCompression_name = map[int32]string{
+		0: "NoRingCompression",
+		1: "Deflate",
+	}
+	RingCompression_value = map[string]int32{
+		"NoRingCompression": 0,
+		"Deflate":           1,
+	}
+)
+
+func (x RingCompression) Enum() *RingCompression {
+	p := new(RingCompression)
+	*p = x
+	return p
+}
+
+func (x RingCompression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RingCompression) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_locinfo_proto_enumTypes[1].Descriptor()
+}
+
+func (RingCompression) Type() protoreflect.EnumType {
+	return &file_pb_locinfo_proto_enumTypes[1]
+}
+
+func (x RingCompression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RingCompression.Descriptor instead.
+func (RingCompression) EnumDescriptor() ([]byte, []int) {
+	return file_pb_locinfo_proto_rawDescGZIP(), []int{1}
+}
+
+// CellSystem is how the earth split into cells for preindex.
+type CellSystem int32
+
+const (
+	CellSystem_MapTile   CellSystem = 0 // OSM style Web Mercator map tile, Z is zoom level
+	CellSystem_Geohash   CellSystem = 1 // Geohash cells, Z is bits count
+	CellSystem_EqualArea CellSystem = 2 // Quadtree on cylindrical equal-area projection
+)
+
+// Enum value maps for CellSystem.
+var (
+	CellSystem_name = map[int32]string{
+		0: "MapTile",
+		1: "Geohash",
+		2: "EqualArea",
+	}
+	CellSystem_value = map[string]int32{
+		"MapTile":   0,
+		"Geohash":   1,
+		"EqualArea": 2,
+	}
+)
+
+func (x CellSystem) Enum() *CellSystem {
+	p := new(CellSystem)
+	*p = x
+	return p
+}
+
+func (x CellSystem) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CellSystem) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_locinfo_proto_enumTypes[2].Descriptor()
+}
+
+func (CellSystem) Type() protoreflect.EnumType {
+	return &file_pb_locinfo_proto_enumTypes[2]
+}
+
+func (x CellSystem) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CellSystem.Descriptor instead.
+func (CellSystem) EnumDescriptor() ([]byte, []int) {
+	return file_pb_locinfo_proto_rawDescGZIP(), []int{2}
+}
+
 // Basic Point data define.
 type Point struct {
 	state         protoimpl.MessageState
@@ -217,7 +318,8 @@ type Location struct {
 	unknownFields protoimpl.UnknownFields
 
 	Polygons []*Polygon `protobuf:"bytes,1,rep,name=polygons,proto3" json:"polygons,omitempty"`
-	Name     string     `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Name     string     `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"` // Human-readable name, returned by finders
+	Id       string     `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`     // Stable ID like a FIPS code, empty if not set
 }
 
 func (x *Location) Reset() {
@@ -266,6 +368,13 @@ func (x *Location) GetName() string {
 	return ""
 }
 
+func (x *Location) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
 type Locations struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -273,6 +382,7 @@ type Locations struct {
 
 	Locations []*Location `protobuf:"bytes,1,rep,name=locations,proto3" json:"locations,omitempty"`
 	Reduced   bool        `protobuf:"varint,2,opt,name=reduced,proto3" json:"reduced,omitempty"` // Reduced data will toggle neighbor search as plan b
+	Metadata  *Metadata   `protobuf:"bytes,3,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
 func (x *Locations) Reset() {
@@ -321,6 +431,186 @@ func (x *Locations) GetReduced() bool {
 	return false
 }
 
+func (x *Locations) GetMetadata() *Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// Metadata tells where data came from and how it was made. It's set by
+// converters like geojson2locpb and carried by every later step, which
+// appends itself to steps.
+type Metadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SchemaVersion uint32          `protobuf:"varint,1,opt,name=schemaVersion,proto3" json:"schemaVersion,omitempty"` // Version of this proto, see pb.SchemaVersion
+	Name          string          `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                    // Dataset name like "us-states"
+	Version       string          `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`              // Dataset version or vintage like "2022"
+	SourceUrl     string          `protobuf:"bytes,4,opt,name=sourceUrl,proto3" json:"sourceUrl,omitempty"`          // Where the source data came from
+	License       string          `protobuf:"bytes,5,opt,name=license,proto3" json:"license,omitempty"`              // Source data's license
+	Created       int64           `protobuf:"varint,6,opt,name=created,proto3" json:"created,omitempty"`             // When the source was converted, Unix seconds
+	Steps         []*PipelineStep `protobuf:"bytes,7,rep,name=steps,proto3" json:"steps,omitempty"`                  // Processing steps in order
+	// How this message's coordinates are encoded, "float32" for Locations,
+	// CompressMethod like "polyline" for CompressedLocations and CellSystem
+	// like "maptile" for PreindexLocations.
+	CoordinateEncoding string `protobuf:"bytes,8,opt,name=coordinateEncoding,proto3" json:"coordinateEncoding,omitempty"`
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_locinfo_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_locinfo_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_pb_locinfo_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Metadata) GetSchemaVersion() uint32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *Metadata) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Metadata) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Metadata) GetSourceUrl() string {
+	if x != nil {
+		return x.SourceUrl
+	}
+	return ""
+}
+
+func (x *Metadata) GetLicense() string {
+	if x != nil {
+		return x.License
+	}
+	return ""
+}
+
+func (x *Metadata) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *Metadata) GetSteps() []*PipelineStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+func (x *Metadata) GetCoordinateEncoding() string {
+	if x != nil {
+		return x.CoordinateEncoding
+	}
+	return ""
+}
+
+// PipelineStep is a tool run on the data, like a CLI tool's flags.
+type PipelineStep struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tool       string            `protobuf:"bytes,1,opt,name=tool,proto3" json:"tool,omitempty"` // Tool name like "reducelocpb"
+	Parameters map[string]string `protobuf:"bytes,2,rep,name=parameters,proto3" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Time       int64             `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"` // When the tool ran, Unix seconds
+}
+
+func (x *PipelineStep) Reset() {
+	*x = PipelineStep{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_locinfo_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PipelineStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PipelineStep) ProtoMessage() {}
+
+func (x *PipelineStep) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_locinfo_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PipelineStep.ProtoReflect.Descriptor instead.
+func (*PipelineStep) Descriptor() ([]byte, []int) {
+	return file_pb_locinfo_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PipelineStep) GetTool() string {
+	if x != nil {
+		return x.Tool
+	}
+	return ""
+}
+
+func (x *PipelineStep) GetParameters() map[string]string {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *PipelineStep) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
 type CompressedPolygon struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -333,7 +623,7 @@ type CompressedPolygon struct {
 func (x *CompressedPolygon) Reset() {
 	*x = CompressedPolygon{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_locinfo_proto_msgTypes[4]
+		mi := &file_pb_locinfo_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -346,7 +636,7 @@ func (x *CompressedPolygon) String() string {
 func (*CompressedPolygon) ProtoMessage() {}
 
 func (x *CompressedPolygon) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_locinfo_proto_msgTypes[4]
+	mi := &file_pb_locinfo_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -359,7 +649,7 @@ func (x *CompressedPolygon) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CompressedPolygon.ProtoReflect.Descriptor instead.
 func (*CompressedPolygon) Descriptor() ([]byte, []int) {
-	return file_pb_locinfo_proto_rawDescGZIP(), []int{4}
+	return file_pb_locinfo_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *CompressedPolygon) GetPoints() []byte {
@@ -384,12 +674,13 @@ type CompressedLocation struct {
 
 	Data []*CompressedPolygon `protobuf:"bytes,1,rep,name=data,proto3" json:"data,omitempty"`
 	Name string               `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Id   string               `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
 }
 
 func (x *CompressedLocation) Reset() {
 	*x = CompressedLocation{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_locinfo_proto_msgTypes[5]
+		mi := &file_pb_locinfo_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -402,7 +693,7 @@ func (x *CompressedLocation) String() string {
 func (*CompressedLocation) ProtoMessage() {}
 
 func (x *CompressedLocation) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_locinfo_proto_msgTypes[5]
+	mi := &file_pb_locinfo_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -415,7 +706,7 @@ func (x *CompressedLocation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CompressedLocation.ProtoReflect.Descriptor instead.
 func (*CompressedLocation) Descriptor() ([]byte, []int) {
-	return file_pb_locinfo_proto_rawDescGZIP(), []int{5}
+	return file_pb_locinfo_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CompressedLocation) GetData() []*CompressedPolygon {
@@ -432,6 +723,13 @@ func (x *CompressedLocation) GetName() string {
 	return ""
 }
 
+func (x *CompressedLocation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
 type CompressedLocations struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -439,12 +737,17 @@ type CompressedLocations struct {
 
 	Method    CompressMethod        `protobuf:"varint,1,opt,name=method,proto3,enum=pinpoint.pb.v1.CompressMethod" json:"method,omitempty"`
 	Locations []*CompressedLocation `protobuf:"bytes,2,rep,name=locations,proto3" json:"locations,omitempty"`
+	// Scale is what DeltaVarint coordinates multiplied by before rounding to
+	// integers, 100000 means 5 decimals.
+	Scale           float64         `protobuf:"fixed64,3,opt,name=scale,proto3" json:"scale,omitempty"`
+	RingCompression RingCompression `protobuf:"varint,4,opt,name=ringCompression,proto3,enum=pinpoint.pb.v1.RingCompression" json:"ringCompression,omitempty"`
+	Metadata        *Metadata       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
 func (x *CompressedLocations) Reset() {
 	*x = CompressedLocations{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_locinfo_proto_msgTypes[6]
+		mi := &file_pb_locinfo_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -457,7 +760,7 @@ func (x *CompressedLocations) String() string {
 func (*CompressedLocations) ProtoMessage() {}
 
 func (x *CompressedLocations) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_locinfo_proto_msgTypes[6]
+	mi := &file_pb_locinfo_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -470,7 +773,7 @@ func (x *CompressedLocations) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CompressedLocations.ProtoReflect.Descriptor instead.
 func (*CompressedLocations) Descriptor() ([]byte, []int) {
-	return file_pb_locinfo_proto_rawDescGZIP(), []int{6}
+	return file_pb_locinfo_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *CompressedLocations) GetMethod() CompressMethod {
@@ -487,9 +790,31 @@ func (x *CompressedLocations) GetLocations() []*CompressedLocation {
 	return nil
 }
 
+func (x *CompressedLocations) GetScale() float64 {
+	if x != nil {
+		return x.Scale
+	}
+	return 0
+}
+
+func (x *CompressedLocations) GetRingCompression() RingCompression {
+	if x != nil {
+		return x.RingCompression
+	}
+	return RingCompression_NoRingCompression
+}
+
+func (x *CompressedLocations) GetMetadata() *Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 // PreindexLocation tile item.
 //
-// The X/Y/Z are OSM style like map tile index values.
+// The X/Y/Z are OSM style like map tile index values, or cell index values of
+// the CellSystem used.
 type PreindexLocation struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -504,7 +829,7 @@ type PreindexLocation struct {
 func (x *PreindexLocation) Reset() {
 	*x = PreindexLocation{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_locinfo_proto_msgTypes[7]
+		mi := &file_pb_locinfo_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -517,7 +842,7 @@ func (x *PreindexLocation) String() string {
 func (*PreindexLocation) ProtoMessage() {}
 
 func (x *PreindexLocation) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_locinfo_proto_msgTypes[7]
+	mi := &file_pb_locinfo_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -530,7 +855,7 @@ func (x *PreindexLocation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreindexLocation.ProtoReflect.Descriptor instead.
 func (*PreindexLocation) Descriptor() ([]byte, []int) {
-	return file_pb_locinfo_proto_rawDescGZIP(), []int{7}
+	return file_pb_locinfo_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *PreindexLocation) GetName() string {
@@ -567,15 +892,17 @@ type PreindexLocations struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	IdxZoom int32               `protobuf:"varint,1,opt,name=idxZoom,proto3" json:"idxZoom,omitempty"` // which zoom value the tiles generated
-	AggZoom int32               `protobuf:"varint,2,opt,name=aggZoom,proto3" json:"aggZoom,omitempty"` // which zoom value the tiles merge up with.
-	Keys    []*PreindexLocation `protobuf:"bytes,3,rep,name=keys,proto3" json:"keys,omitempty"`
+	IdxZoom    int32               `protobuf:"varint,1,opt,name=idxZoom,proto3" json:"idxZoom,omitempty"` // which zoom value the tiles generated
+	AggZoom    int32               `protobuf:"varint,2,opt,name=aggZoom,proto3" json:"aggZoom,omitempty"` // which zoom value the tiles merge up with.
+	Keys       []*PreindexLocation `protobuf:"bytes,3,rep,name=keys,proto3" json:"keys,omitempty"`
+	CellSystem CellSystem          `protobuf:"varint,4,opt,name=cellSystem,proto3,enum=pinpoint.pb.v1.CellSystem" json:"cellSystem,omitempty"` // which cell system X/Y/Z of keys belong to
+	Metadata   *Metadata           `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
 func (x *PreindexLocations) Reset() {
 	*x = PreindexLocations{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_locinfo_proto_msgTypes[8]
+		mi := &file_pb_locinfo_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -588,7 +915,7 @@ func (x *PreindexLocations) String() string {
 func (*PreindexLocations) ProtoMessage() {}
 
 func (x *PreindexLocations) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_locinfo_proto_msgTypes[8]
+	mi := &file_pb_locinfo_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -601,7 +928,7 @@ func (x *PreindexLocations) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreindexLocations.ProtoReflect.Descriptor instead.
 func (*PreindexLocations) Descriptor() ([]byte, []int) {
-	return file_pb_locinfo_proto_rawDescGZIP(), []int{8}
+	return file_pb_locinfo_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *PreindexLocations) GetIdxZoom() int32 {
@@ -625,6 +952,20 @@ func (x *PreindexLocations) GetKeys() []*PreindexLocation {
 	return nil
 }
 
+func (x *PreindexLocations) GetCellSystem() CellSystem {
+	if x != nil {
+		return x.CellSystem
+	}
+	return CellSystem_MapTile
+}
+
+func (x *PreindexLocations) GetMetadata() *Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 var File_pb_locinfo_proto protoreflect.FileDescriptor
 
 var file_pb_locinfo_proto_rawDesc = []byte{
@@ -639,59 +980,118 @@ var file_pb_locinfo_proto_rawDesc = []byte{
 	0x74, 0x52, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x2d, 0x0a, 0x05, 0x68, 0x6f, 0x6c,
 	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f,
 	0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f,
-	0x6e, 0x52, 0x05, 0x68, 0x6f, 0x6c, 0x65, 0x73, 0x22, 0x53, 0x0a, 0x08, 0x4c, 0x6f, 0x63, 0x61,
+	0x6e, 0x52, 0x05, 0x68, 0x6f, 0x6c, 0x65, 0x73, 0x22, 0x63, 0x0a, 0x08, 0x4c, 0x6f, 0x63, 0x61,
 	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x33, 0x0a, 0x08, 0x70, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x73,
 	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e,
 	0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x52,
 	0x08, 0x70, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x5d, 0x0a,
-	0x09, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x36, 0x0a, 0x09, 0x6c, 0x6f,
-	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e,
-	0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4c,
-	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x64, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x64, 0x22, 0x64, 0x0a, 0x11,
-	0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f,
-	0x6e, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x37, 0x0a, 0x05, 0x68, 0x6f, 0x6c,
-	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f,
-	0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65,
-	0x73, 0x73, 0x65, 0x64, 0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x52, 0x05, 0x68, 0x6f, 0x6c,
-	0x65, 0x73, 0x22, 0x5f, 0x0a, 0x12, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64,
-	0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61,
-	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e,
-	0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73,
-	0x65, 0x64, 0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12,
-	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
-	0x61, 0x6d, 0x65, 0x22, 0x8f, 0x01, 0x0a, 0x13, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73,
-	0x65, 0x64, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x36, 0x0a, 0x06, 0x6d,
-	0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x70, 0x69,
-	0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d,
-	0x70, 0x72, 0x65, 0x73, 0x73, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x52, 0x06, 0x6d, 0x65, 0x74,
-	0x68, 0x6f, 0x64, 0x12, 0x40, 0x0a, 0x09, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e,
-	0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73,
-	0x65, 0x64, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x6c, 0x6f, 0x63, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x50, 0x0a, 0x10, 0x50, 0x72, 0x65, 0x69, 0x6e, 0x64, 0x65,
-	0x78, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x0c, 0x0a,
-	0x01, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x79, 0x12, 0x0c, 0x0a, 0x01, 0x7a, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x7a, 0x22, 0x7d, 0x0a, 0x11, 0x50, 0x72, 0x65, 0x69, 0x6e,
-	0x64, 0x65, 0x78, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07,
-	0x69, 0x64, 0x78, 0x5a, 0x6f, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x69,
-	0x64, 0x78, 0x5a, 0x6f, 0x6f, 0x6d, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x67, 0x67, 0x5a, 0x6f, 0x6f,
-	0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x61, 0x67, 0x67, 0x5a, 0x6f, 0x6f, 0x6d,
-	0x12, 0x34, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x0e, 0x0a,
+	0x02, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x93, 0x01,
+	0x0a, 0x09, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x36, 0x0a, 0x09, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18,
 	0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e,
-	0x50, 0x72, 0x65, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x2a, 0x2b, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65,
-	0x73, 0x73, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x6e, 0x6b, 0x6e,
-	0x6f, 0x77, 0x6e, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x6f, 0x6c, 0x79, 0x6c, 0x69, 0x6e,
-	0x65, 0x10, 0x01, 0x42, 0x25, 0x5a, 0x23, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
-	0x6d, 0x2f, 0x64, 0x65, 0x73, 0x6c, 0x69, 0x74, 0x74, 0x6c, 0x65, 0x2f, 0x70, 0x69, 0x6e, 0x70,
-	0x6f, 0x69, 0x6e, 0x74, 0x2f, 0x70, 0x62, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+	0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x64, 0x12, 0x34, 0x0a,
+	0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x22, 0x94, 0x02, 0x0a, 0x08, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x12, 0x24, 0x0a, 0x0d, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x72,
+	0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55,
+	0x72, 0x6c, 0x12, 0x18, 0x0a, 0x07, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x63,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12, 0x32, 0x0a, 0x05, 0x73, 0x74, 0x65, 0x70, 0x73, 0x18,
+	0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74,
+	0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x53,
+	0x74, 0x65, 0x70, 0x52, 0x05, 0x73, 0x74, 0x65, 0x70, 0x73, 0x12, 0x2e, 0x0a, 0x12, 0x63, 0x6f,
+	0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x63, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61,
+	0x74, 0x65, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x22, 0xc3, 0x01, 0x0a, 0x0c, 0x50,
+	0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x53, 0x74, 0x65, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x74,
+	0x6f, 0x6f, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x6f, 0x6f, 0x6c, 0x12,
+	0x4c, 0x0a, 0x0a, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70,
+	0x62, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x53, 0x74, 0x65,
+	0x70, 0x2e, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x0a, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69, 0x6d,
+	0x65, 0x1a, 0x3d, 0x0a, 0x0f, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x22, 0x64, 0x0a, 0x11, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x50, 0x6f,
+	0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x37, 0x0a,
+	0x05, 0x68, 0x6f, 0x6c, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x70,
+	0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f,
+	0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x52,
+	0x05, 0x68, 0x6f, 0x6c, 0x65, 0x73, 0x22, 0x6f, 0x0a, 0x12, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x65, 0x64, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x04,
+	0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x70, 0x69, 0x6e,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x52, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0xa6, 0x02, 0x0a, 0x13, 0x43, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
+	0x36, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x1e, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x52,
+	0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x40, 0x0a, 0x09, 0x6c, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x70, 0x69, 0x6e,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09,
+	0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x61,
+	0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x61, 0x6c, 0x65, 0x12,
+	0x49, 0x0a, 0x0f, 0x72, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1f, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x69, 0x6e, 0x67, 0x43, 0x6f,
+	0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0f, 0x72, 0x69, 0x6e, 0x67, 0x43,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x34, 0x0a, 0x08, 0x6d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70,
+	0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x22, 0x50, 0x0a, 0x10, 0x50, 0x72, 0x65, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x4c, 0x6f, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x01, 0x79, 0x12, 0x0c, 0x0a, 0x01, 0x7a, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x01, 0x7a, 0x22, 0xef, 0x01, 0x0a, 0x11, 0x50, 0x72, 0x65, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x4c,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x64, 0x78, 0x5a,
+	0x6f, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x69, 0x64, 0x78, 0x5a, 0x6f,
+	0x6f, 0x6d, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x67, 0x67, 0x5a, 0x6f, 0x6f, 0x6d, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x07, 0x61, 0x67, 0x67, 0x5a, 0x6f, 0x6f, 0x6d, 0x12, 0x34, 0x0a, 0x04,
+	0x6b, 0x65, 0x79, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x70, 0x69, 0x6e,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x65, 0x69,
+	0x6e, 0x64, 0x65, 0x78, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x04, 0x6b, 0x65,
+	0x79, 0x73, 0x12, 0x3a, 0x0a, 0x0a, 0x63, 0x65, 0x6c, 0x6c, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1a, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e,
+	0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x65, 0x6c, 0x6c, 0x53, 0x79, 0x73, 0x74,
+	0x65, 0x6d, 0x52, 0x0a, 0x63, 0x65, 0x6c, 0x6c, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x34,
+	0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x18, 0x2e, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x62, 0x2e, 0x76,
+	0x31, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x2a, 0x3c, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73,
+	0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77,
+	0x6e, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x6f, 0x6c, 0x79, 0x6c, 0x69, 0x6e, 0x65, 0x10,
+	0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x56, 0x61, 0x72, 0x69, 0x6e, 0x74,
+	0x10, 0x02, 0x2a, 0x35, 0x0a, 0x0f, 0x52, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x15, 0x0a, 0x11, 0x4e, 0x6f, 0x52, 0x69, 0x6e, 0x67, 0x43,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07,
+	0x44, 0x65, 0x66, 0x6c, 0x61, 0x74, 0x65, 0x10, 0x01, 0x2a, 0x35, 0x0a, 0x0a, 0x43, 0x65, 0x6c,
+	0x6c, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x61, 0x70, 0x54, 0x69,
+	0x6c, 0x65, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x47, 0x65, 0x6f, 0x68, 0x61, 0x73, 0x68, 0x10,
+	0x01, 0x12, 0x0d, 0x0a, 0x09, 0x45, 0x71, 0x75, 0x61, 0x6c, 0x41, 0x72, 0x65, 0x61, 0x10, 0x02,
+	0x42, 0x25, 0x5a, 0x23, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64,
+	0x65, 0x73, 0x6c, 0x69, 0x74, 0x74, 0x6c, 0x65, 0x2f, 0x70, 0x69, 0x6e, 0x70, 0x6f, 0x69, 0x6e,
+	0x74, 0x2f, 0x70, 0x62, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -706,35 +1106,47 @@ func file_pb_locinfo_proto_rawDescGZIP() []byte {
 	return file_pb_locinfo_proto_rawDescData
 }
 
-var file_pb_locinfo_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_pb_locinfo_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_pb_locinfo_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_pb_locinfo_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_pb_locinfo_proto_goTypes = []interface{}{
 	(CompressMethod)(0),         // 0: pinpoint.pb.v1.CompressMethod
-	(*Point)(nil),               // 1: pinpoint.pb.v1.Point
-	(*Polygon)(nil),             // 2: pinpoint.pb.v1.Polygon
-	(*Location)(nil),            // 3: pinpoint.pb.v1.Location
-	(*Locations)(nil),           // 4: pinpoint.pb.v1.Locations
-	(*CompressedPolygon)(nil),   // 5: pinpoint.pb.v1.CompressedPolygon
-	(*CompressedLocation)(nil),  // 6: pinpoint.pb.v1.CompressedLocation
-	(*CompressedLocations)(nil), // 7: pinpoint.pb.v1.CompressedLocations
-	(*PreindexLocation)(nil),    // 8: pinpoint.pb.v1.PreindexLocation
-	(*PreindexLocations)(nil),   // 9: pinpoint.pb.v1.PreindexLocations
+	(RingCompression)(0),        // 1: pinpoint.pb.v1.RingCompression
+	(CellSystem)(0),             // 2: pinpoint.pb.v1.CellSystem
+	(*Point)(nil),               // 3: pinpoint.pb.v1.Point
+	(*Polygon)(nil),             // 4: pinpoint.pb.v1.Polygon
+	(*Location)(nil),            // 5: pinpoint.pb.v1.Location
+	(*Locations)(nil),           // 6: pinpoint.pb.v1.Locations
+	(*Metadata)(nil),            // 7: pinpoint.pb.v1.Metadata
+	(*PipelineStep)(nil),        // 8: pinpoint.pb.v1.PipelineStep
+	(*CompressedPolygon)(nil),   // 9: pinpoint.pb.v1.CompressedPolygon
+	(*CompressedLocation)(nil),  // 10: pinpoint.pb.v1.CompressedLocation
+	(*CompressedLocations)(nil), // 11: pinpoint.pb.v1.CompressedLocations
+	(*PreindexLocation)(nil),    // 12: pinpoint.pb.v1.PreindexLocation
+	(*PreindexLocations)(nil),   // 13: pinpoint.pb.v1.PreindexLocations
+	nil,                         // 14: pinpoint.pb.v1.PipelineStep.ParametersEntry
 }
 var file_pb_locinfo_proto_depIdxs = []int32{
-	1, // 0: pinpoint.pb.v1.Polygon.points:type_name -> pinpoint.pb.v1.Point
-	2, // 1: pinpoint.pb.v1.Polygon.holes:type_name -> pinpoint.pb.v1.Polygon
-	2, // 2: pinpoint.pb.v1.Location.polygons:type_name -> pinpoint.pb.v1.Polygon
-	3, // 3: pinpoint.pb.v1.Locations.locations:type_name -> pinpoint.pb.v1.Location
-	5, // 4: pinpoint.pb.v1.CompressedPolygon.holes:type_name -> pinpoint.pb.v1.CompressedPolygon
-	5, // 5: pinpoint.pb.v1.CompressedLocation.data:type_name -> pinpoint.pb.v1.CompressedPolygon
-	0, // 6: pinpoint.pb.v1.CompressedLocations.method:type_name -> pinpoint.pb.v1.CompressMethod
-	6, // 7: pinpoint.pb.v1.CompressedLocations.locations:type_name -> pinpoint.pb.v1.CompressedLocation
-	8, // 8: pinpoint.pb.v1.PreindexLocations.keys:type_name -> pinpoint.pb.v1.PreindexLocation
-	9, // [9:9] is the sub-list for method output_type
-	9, // [9:9] is the sub-list for method input_type
-	9, // [9:9] is the sub-list for extension type_name
-	9, // [9:9] is the sub-list for extension extendee
-	0, // [0:9] is the sub-list for field type_name
+	3,  // 0: pinpoint.pb.v1.Polygon.points:type_name -> pinpoint.pb.v1.Point
+	4,  // 1: pinpoint.pb.v1.Polygon.holes:type_name -> pinpoint.pb.v1.Polygon
+	4,  // 2: pinpoint.pb.v1.Location.polygons:type_name -> pinpoint.pb.v1.Polygon
+	5,  // 3: pinpoint.pb.v1.Locations.locations:type_name -> pinpoint.pb.v1.Location
+	7,  // 4: pinpoint.pb.v1.Locations.metadata:type_name -> pinpoint.pb.v1.Metadata
+	8,  // 5: pinpoint.pb.v1.Metadata.steps:type_name -> pinpoint.pb.v1.PipelineStep
+	14, // 6: pinpoint.pb.v1.PipelineStep.parameters:type_name -> pinpoint.pb.v1.PipelineStep.ParametersEntry
+	9,  // 7: pinpoint.pb.v1.CompressedPolygon.holes:type_name -> pinpoint.pb.v1.CompressedPolygon
+	9,  // 8: pinpoint.pb.v1.CompressedLocation.data:type_name -> pinpoint.pb.v1.CompressedPolygon
+	0,  // 9: pinpoint.pb.v1.CompressedLocations.method:type_name -> pinpoint.pb.v1.CompressMethod
+	10, // 10: pinpoint.pb.v1.CompressedLocations.locations:type_name -> pinpoint.pb.v1.CompressedLocation
+	1,  // 11: pinpoint.pb.v1.CompressedLocations.ringCompression:type_name -> pinpoint.pb.v1.RingCompression
+	7,  // 12: pinpoint.pb.v1.CompressedLocations.metadata:type_name -> pinpoint.pb.v1.Metadata
+	12, // 13: pinpoint.pb.v1.PreindexLocations.keys:type_name -> pinpoint.pb.v1.PreindexLocation
+	2,  // 14: pinpoint.pb.v1.PreindexLocations.cellSystem:type_name -> pinpoint.pb.v1.CellSystem
+	7,  // 15: pinpoint.pb.v1.PreindexLocations.metadata:type_name -> pinpoint.pb.v1.Metadata
+	16, // [16:16] is the sub-list for method output_type
+	16, // [16:16] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_pb_locinfo_proto_init() }
@@ -792,7 +1204,7 @@ func file_pb_locinfo_proto_init() {
 			}
 		}
 		file_pb_locinfo_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CompressedPolygon); i {
+			switch v := v.(*Metadata); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -804,7 +1216,7 @@ func file_pb_locinfo_proto_init() {
 			}
 		}
 		file_pb_locinfo_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CompressedLocation); i {
+			switch v := v.(*PipelineStep); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -816,7 +1228,7 @@ func file_pb_locinfo_proto_init() {
 			}
 		}
 		file_pb_locinfo_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CompressedLocations); i {
+			switch v := v.(*CompressedPolygon); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -828,7 +1240,7 @@ func file_pb_locinfo_proto_init() {
 			}
 		}
 		file_pb_locinfo_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PreindexLocation); i {
+			switch v := v.(*CompressedLocation); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -840,6 +1252,30 @@ func file_pb_locinfo_proto_init() {
 			}
 		}
 		file_pb_locinfo_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CompressedLocations); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_locinfo_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PreindexLocation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_locinfo_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PreindexLocations); i {
 			case 0:
 				return &v.state
@@ -857,8 +1293,8 @@ func file_pb_locinfo_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pb_locinfo_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   9,
+			NumEnums:      3,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
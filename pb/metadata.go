@@ -0,0 +1,64 @@
+package pb
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// SchemaVersion is [Metadata] SchemaVersion of data made by this package,
+// it's increased on incompatible changes of locinfo.proto.
+const SchemaVersion = 1
+
+// Coordinate encodings of [Metadata] CoordinateEncoding.
+const (
+	EncodingFloat32     = "float32"
+	EncodingPolyline    = "polyline"
+	EncodingDeltaVarint = "delta-varint"
+	EncodingMapTile     = "maptile"
+	EncodingGeohash     = "geohash"
+	EncodingEqualArea   = "equal-area"
+)
+
+// CellSystemEncoding returns coordinate encoding of preindex data by cell
+// system.
+func CellSystemEncoding(system CellSystem) string {
+	switch system {
+	case CellSystem_MapTile:
+		return EncodingMapTile
+	case CellSystem_Geohash:
+		return EncodingGeohash
+	case CellSystem_EqualArea:
+		return EncodingEqualArea
+	}
+	return system.String()
+}
+
+// CopyMetadata returns a copy of m for data derived from m's, with
+// coordinate encoding of the new data. If m is nil, like data made before
+// metadata, it returns [NewMetadata] with the encoding.
+func CopyMetadata(m *Metadata, encoding string) *Metadata {
+	if m == nil {
+		m = NewMetadata()
+	}
+	ret := proto.Clone(m).(*Metadata)
+	ret.CoordinateEncoding = encoding
+	return ret
+}
+
+// NewMetadata returns metadata of new [Locations] with SchemaVersion.
+func NewMetadata() *Metadata {
+	return &Metadata{
+		SchemaVersion:      SchemaVersion,
+		CoordinateEncoding: EncodingFloat32,
+	}
+}
+
+// AddStep appends a step of tool with parameters ran now to m's steps.
+func (m *Metadata) AddStep(tool string, parameters map[string]string) {
+	m.Steps = append(m.Steps, &PipelineStep{
+		Tool:       tool,
+		Parameters: parameters,
+		Time:       time.Now().Unix(),
+	})
+}
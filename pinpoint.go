@@ -86,11 +86,12 @@ func (i *locitem) GetMinMax() ([2]float64, [2]float64) {
 // Memeory will use about 100MB if lite data and 1G if full data.
 // Performance is very stable and very accuate.
 type Finder struct {
-	items   []*locitem
-	names   []string
-	reduced bool
-	tr      *rtree.RTreeG[*locitem]
-	opt     *Option
+	items    []*locitem
+	names    []string
+	reduced  bool
+	metadata *pb.Metadata
+	tr       *rtree.RTreeG[*locitem]
+	opt      *Option
 }
 
 func NewFinderFromRawJSON(input *convert.BoundaryFile, opts ...OptionFunc) (*Finder, error) {
@@ -101,26 +102,51 @@ func NewFinderFromRawJSON(input *convert.BoundaryFile, opts ...OptionFunc) (*Fin
 	return NewFinderFromPB(locations, opts...)
 }
 
-func NewFinderFromPB(input *pb.Locations, opts ...OptionFunc) (*Finder, error) {
-
-	items := make([]*locitem, 0)
-	names := make([]string, 0)
-
+func newOption(opts ...OptionFunc) *Option {
 	opt := &Option{}
 	for _, optFunc := range opts {
 		optFunc(opt)
 	}
+	return opt
+}
 
-	tr := &rtree.RTreeG[*locitem]{}
-	for _, location := range input.Locations {
-		names = append(names, location.Name)
+// finderBuilder adds locations to a new [Finder].
+type finderBuilder struct {
+	finder *Finder
+}
 
-		newItem := &locitem{
-			name: location.Name,
-		}
-		if !opt.DropPBLoc {
-			newItem.pbloc = location
-		}
+func newFinderBuilder(opt *Option) *finderBuilder {
+	return &finderBuilder{finder: &Finder{
+		items: make([]*locitem, 0),
+		names: make([]string, 0),
+		tr:    &rtree.RTreeG[*locitem]{},
+		opt:   opt,
+	}}
+}
+
+func (b *finderBuilder) add(name string, pbloc *pb.Location, polys []*geometry.Poly) {
+	f := b.finder
+	f.names = append(f.names, name)
+
+	newItem := &locitem{
+		name:  name,
+		polys: polys,
+	}
+	if !f.opt.DropPBLoc {
+		newItem.pbloc = pbloc
+	}
+	minp, maxp := newItem.GetMinMax()
+	newItem.min = minp
+	newItem.max = maxp
+
+	f.items = append(f.items, newItem)
+	f.tr.Insert(minp, maxp, newItem)
+}
+
+func NewFinderFromPB(input *pb.Locations, opts ...OptionFunc) (*Finder, error) {
+	b := newFinderBuilder(newOption(opts...))
+	for _, location := range input.Locations {
+		polys := []*geometry.Poly{}
 		for _, polygon := range location.Polygons {
 
 			newPoints := make([]geometry.Point, 0)
@@ -144,31 +170,67 @@ func NewFinderFromPB(input *pb.Locations, opts ...OptionFunc) (*Finder, error) {
 			}
 
 			newPoly := geometry.NewPoly(newPoints, holes, nil)
-			newItem.polys = append(newItem.polys, newPoly)
+			polys = append(polys, newPoly)
 		}
-		minp, maxp := newItem.GetMinMax()
-
-		newItem.min = minp
-		newItem.max = maxp
-
-		items = append(items, newItem)
-		tr.Insert(minp, maxp, newItem)
+		b.add(location.Name, location, polys)
 	}
-	finder := &Finder{}
-	finder.items = items
-	finder.names = names
-	finder.reduced = input.Reduced
-	finder.tr = tr
-	finder.opt = opt
-	return finder, nil
+	b.finder.reduced = input.Reduced
+	b.finder.metadata = input.Metadata
+	return b.finder, nil
 }
 
+// NewFinderFromCompressed decompress input to a [Finder], errors of corrupt
+// data name the location and ring.
+//
+// With [SetDropPBLoc], rings are decoded straight into Finder's polygons
+// without building [pb.Locations], which saves memory and time.
 func NewFinderFromCompressed(input *pb.CompressedLocations, opts ...OptionFunc) (*Finder, error) {
-	locs, err := reduce.Decompress(input)
+	opt := newOption(opts...)
+	if !opt.DropPBLoc {
+		locs, err := reduce.Decompress(input)
+		if err != nil {
+			return nil, err
+		}
+		f, err := NewFinderFromPB(locs, opts...)
+		if err != nil {
+			return nil, err
+		}
+		f.metadata = input.Metadata
+		return f, nil
+	}
+
+	polys := make([][]*geometry.Poly, len(input.Locations))
+	var rings [][]geometry.Point
+	current := -1
+	flush := func() {
+		if len(rings) != 0 {
+			polys[current] = append(polys[current], geometry.NewPoly(rings[0], rings[1:], nil))
+		}
+		rings = nil
+	}
+	err := reduce.DecompressRings(input, func(location, polygon, ring int, coords []float64) error {
+		if ring == 0 {
+			flush()
+			current = location
+		}
+		points := make([]geometry.Point, 0, len(coords)/2)
+		for i := 0; i < len(coords); i += 2 {
+			points = append(points, geometry.Point{X: coords[i], Y: coords[i+1]})
+		}
+		rings = append(rings, points)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return NewFinderFromPB(locs, opts...)
+	flush()
+
+	b := newFinderBuilder(opt)
+	for i, location := range input.Locations {
+		b.add(location.Name, nil, polys[i])
+	}
+	b.finder.metadata = input.Metadata
+	return b.finder, nil
 }
 
 func getRTreeRangeShifed(lng float64, lat float64) float64 {
@@ -265,3 +327,10 @@ func (f *Finder) GetLocationShapeByName(name string) (*pb.Location, error) {
 func (f *Finder) LocationNames() []string {
 	return f.names
 }
+
+// Metadata returns where finder's data came from and how it was made, nil
+// if the data has none. Data from [NewFinderFromCompressed] keeps its
+// compressed coordinate encoding.
+func (f *Finder) Metadata() *pb.Metadata {
+	return f.metadata
+}
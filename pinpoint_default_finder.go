@@ -87,3 +87,9 @@ func (f *ExampleCombinedFinder) GetLocationNames(lng float64, lat float64) ([]st
 func (f *ExampleCombinedFinder) LocationNames() []string {
 	return f.finder.LocationNames()
 }
+
+// Metadata returns metadata of the compressed data behind the polygon based
+// finder, nil if the data has none.
+func (f *ExampleCombinedFinder) Metadata() *pb.Metadata {
+	return f.finder.Metadata()
+}
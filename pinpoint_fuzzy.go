@@ -1,33 +1,56 @@
 package pinpoint
 
 import (
+	"sort"
+
+	"github.com/deslittle/pinpoint/cell"
 	"github.com/deslittle/pinpoint/pb"
 	"github.com/paulmach/orb"
-	"github.com/paulmach/orb/maptile"
 )
 
 // FuzzyFinder use a tile map to store location name. Data are made by
 // [github.com/deslittle/pinpoint/cmd/preindexlocpb] which powerd by
 // [github.com/deslittle/pinpoint/preindex.PreIndexLocations].
+//
+// Tiles could be any cell system in [github.com/deslittle/pinpoint/cell],
+// which recorded in [pb.PreindexLocations].
 type FuzzyFinder struct {
-	idxZoom int
-	aggZoom int
-	m       map[maptile.Tile][]string // locations may have common area
+	idxZoom  int
+	aggZoom  int
+	system   cell.System
+	zooms    []uint32               // zoom levels in m, small locations may use finer than idxZoom
+	m        map[cell.Cell][]string // locations may have common area
+	metadata *pb.Metadata
 }
 
 func NewFuzzyFinderFromPB(input *pb.PreindexLocations) (*FuzzyFinder, error) {
+	system, err := cell.FromKind(input.CellSystem)
+	if err != nil {
+		return nil, err
+	}
 	f := &FuzzyFinder{
-		m:       make(map[maptile.Tile][]string),
-		idxZoom: int(input.IdxZoom),
-		aggZoom: int(input.AggZoom),
+		m:        make(map[cell.Cell][]string),
+		idxZoom:  int(input.IdxZoom),
+		aggZoom:  int(input.AggZoom),
+		system:   system,
+		metadata: input.Metadata,
 	}
 	for _, item := range input.Keys {
-		tile := maptile.New(uint32(item.X), uint32(item.Y), maptile.Zoom(item.Z))
+		tile := cell.Cell{X: uint32(item.X), Y: uint32(item.Y), Z: uint32(item.Z)}
 		if _, ok := f.m[tile]; !ok {
 			f.m[tile] = make([]string, 0)
 		}
 		f.m[tile] = append(f.m[tile], item.Name)
 	}
+
+	zoomset := map[uint32]bool{}
+	for tile := range f.m {
+		zoomset[tile.Z] = true
+	}
+	for z := range zoomset {
+		f.zooms = append(f.zooms, z)
+	}
+	sort.Slice(f.zooms, func(i, j int) bool { return f.zooms[i] < f.zooms[j] })
 	return f, nil
 }
 
@@ -41,8 +64,8 @@ func (f *FuzzyFinder) GetLocationName(lng float64, lat float64) string {
 
 func (f *FuzzyFinder) GetLocationNames(lng float64, lat float64) ([]string, error) {
 	p := orb.Point{lng, lat}
-	for z := f.aggZoom; z <= f.idxZoom; z++ {
-		key := maptile.At(p, maptile.Zoom(z))
+	for _, z := range f.zooms {
+		key := f.system.At(p, z)
 		v, ok := f.m[key]
 		if ok {
 			return v, nil
@@ -50,3 +73,9 @@ func (f *FuzzyFinder) GetLocationNames(lng float64, lat float64) ([]string, erro
 	}
 	return nil, ErrNoLocationFound
 }
+
+// Metadata returns where finder's data came from and how it was made, nil
+// if the data has none.
+func (f *FuzzyFinder) Metadata() *pb.Metadata {
+	return f.metadata
+}
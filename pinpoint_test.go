@@ -2,11 +2,13 @@ package pinpoint_test
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	pinpoint "github.com/deslittle/pinpoint"
 	usstates "github.com/deslittle/pinpoint-us-states"
 	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/reduce"
 	"github.com/loov/hrtime/hrtesting"
 	"google.golang.org/protobuf/proto"
 )
@@ -125,3 +127,111 @@ func ExampleFinder_GetLocationShapeByName() {
 	fmt.Printf("%v %v\n", pbloc.GetName(), err)
 	// Output: 34 <nil>
 }
+
+func TestNewFinderFromCompressedCorrupt(t *testing.T) {
+	input := &pb.CompressedLocations{}
+	if err := proto.Unmarshal(usstates.LiteCompressData, input); err != nil {
+		t.Fatal(err)
+	}
+	data := input.Locations[0].Data[0].Points
+	input.Locations[0].Data[0].Points = data[:len(data)/2]
+	if _, err := pinpoint.NewFinderFromCompressed(input); err == nil {
+		t.Error("expect error from truncated ring")
+	}
+}
+
+func TestNewFinderFromCompressedDropPBLoc(t *testing.T) {
+	input := &pb.CompressedLocations{}
+	if err := proto.Unmarshal(usstates.LiteCompressData, input); err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := pinpoint.NewFinderFromCompressed(input, pinpoint.SetDropPBLoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := pinpoint.NewFinderFromCompressed(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		lng, lat := -125+r.Float64()*60, 24+r.Float64()*26
+		if got, want := streamed.GetLocationName(lng, lat), decompressed.GetLocationName(lng, lat); got != want {
+			t.Fatalf("%v,%v: got %q, want %q", lng, lat, got, want)
+		}
+	}
+}
+
+// TestNewFinderFromReducedLite reduces and compresses lite data by
+// reducelocpb and compresslocpb's defaults.
+func TestNewFinderFromReducedLite(t *testing.T) {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.LiteData, input); err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range [][]reduce.OptionFunc{nil, {reduce.SetTopology}} {
+		reduced := reduce.Do(input, 5, 10000, 10, opts...)
+		compressed, err := reduce.Compress(reduced, pb.CompressMethod_Polyline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pinpoint.NewFinderFromCompressed(compressed); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFinderMetadata(t *testing.T) {
+	input := &pb.CompressedLocations{}
+	if err := proto.Unmarshal(usstates.LiteCompressData, input); err != nil {
+		t.Fatal(err)
+	}
+	input.Metadata = pb.NewMetadata()
+	input.Metadata.Name = "us-states"
+	input.Metadata.CoordinateEncoding = pb.EncodingPolyline
+	for _, opts := range [][]pinpoint.OptionFunc{nil, {pinpoint.SetDropPBLoc}} {
+		finder, err := pinpoint.NewFinderFromCompressed(input, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := finder.Metadata(); got.GetName() != "us-states" || got.GetCoordinateEncoding() != pb.EncodingPolyline {
+			t.Errorf("got metadata %v", got)
+		}
+	}
+
+	preindexes := &pb.PreindexLocations{}
+	if err := proto.Unmarshal(usstates.PreindexData, preindexes); err != nil {
+		t.Fatal(err)
+	}
+	preindexes.Metadata = &pb.Metadata{Name: "us-states"}
+	finder, err := pinpoint.NewFuzzyFinderFromPB(preindexes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := finder.Metadata(); got.GetName() != "us-states" {
+		t.Errorf("got fuzzy finder metadata %v", got)
+	}
+}
+
+func BenchmarkNewFinderFromCompressed(b *testing.B) {
+	input := &pb.CompressedLocations{}
+	if err := proto.Unmarshal(usstates.LiteCompressData, input); err != nil {
+		b.Fatal(err)
+	}
+	for _, c := range []struct {
+		name string
+		opts []pinpoint.OptionFunc
+	}{
+		{"KeepPBLoc", nil},
+		{"DropPBLoc", []pinpoint.OptionFunc{pinpoint.SetDropPBLoc}},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := pinpoint.NewFinderFromCompressed(input, c.opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package preindex
+
+import (
+	"runtime"
+
+	"github.com/deslittle/pinpoint/cell"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+)
+
+// DefaultMaxIdxZoomShift is how many finer zoom levels to try by default
+// when a polygon keeps no tile at idxZoom.
+const DefaultMaxIdxZoomShift = 4
+
+type Option struct {
+	// Workers is how many goroutines used to preindex locations.
+	// Default is twice of CPU count.
+	Workers int
+	// Progress will be called each time a location is done, done counts
+	// locations both indexed and skipped.
+	//
+	// Calls are serialized so it's safe to print from it.
+	Progress func(done, total int)
+	// MaxIdxZoom is the finest zoom to try for small polygons.
+	// Default is idxZoom+[DefaultMaxIdxZoomShift], set to idxZoom to disable.
+	MaxIdxZoom maptile.Zoom
+	// Report will be called for every location in input order, after all
+	// locations done.
+	Report func(report *LocationReport)
+
+	// Queries are historical query points. If set, tiles finer than
+	// maxZoomLevelToKeep are kept where most queries hit, within MaxTiles
+	// and MaxBytes.
+	Queries []orb.Point
+	// MaxTiles limits total keys count of output, 0 means no limit.
+	MaxTiles int
+	// MaxBytes limits output size in bytes after marshal, 0 means no limit.
+	MaxBytes int
+
+	// CellSystem is how tiles are generated, default is [cell.MapTile].
+	// Zoom values passed to preindex functions are levels of it.
+	CellSystem cell.System
+}
+
+type OptionFunc = func(opt *Option)
+
+func newOption(idxZoom maptile.Zoom, opts ...OptionFunc) *Option {
+	opt := &Option{
+		Workers:    runtime.NumCPU() * 2,
+		MaxIdxZoom: idxZoom + DefaultMaxIdxZoomShift,
+		CellSystem: cell.MapTile{},
+	}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+	if opt.Workers < 1 {
+		opt.Workers = 1
+	}
+	return opt
+}
+
+// SetWorkers sets goroutines count used by [PreIndexLocations].
+func SetWorkers(n int) OptionFunc {
+	return func(opt *Option) {
+		opt.Workers = n
+	}
+}
+
+// SetProgress sets a callback to report [PreIndexLocations]'s progress.
+func SetProgress(fn func(done, total int)) OptionFunc {
+	return func(opt *Option) {
+		opt.Progress = fn
+	}
+}
+
+// SetMaxIdxZoom sets the finest zoom to try for small polygons.
+func SetMaxIdxZoom(zoom maptile.Zoom) OptionFunc {
+	return func(opt *Option) {
+		opt.MaxIdxZoom = zoom
+	}
+}
+
+// SetReport sets a callback to receive every location's [LocationReport].
+func SetReport(fn func(report *LocationReport)) OptionFunc {
+	return func(opt *Option) {
+		opt.Report = fn
+	}
+}
+
+// SetQueries sets historical query points to optimize tiles for.
+func SetQueries(points []orb.Point) OptionFunc {
+	return func(opt *Option) {
+		opt.Queries = points
+	}
+}
+
+// SetMaxTiles limits total keys count when optimizing with query points.
+func SetMaxTiles(n int) OptionFunc {
+	return func(opt *Option) {
+		opt.MaxTiles = n
+	}
+}
+
+// SetMaxBytes limits output size when optimizing with query points.
+func SetMaxBytes(n int) OptionFunc {
+	return func(opt *Option) {
+		opt.MaxBytes = n
+	}
+}
+
+// SetCellSystem sets cell system used to generate tiles.
+func SetCellSystem(system cell.System) OptionFunc {
+	return func(opt *Option) {
+		opt.CellSystem = system
+	}
+}
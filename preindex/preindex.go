@@ -15,21 +15,38 @@
 // and exclude 1/2 edge layer, then merge to upper tiles. Then dumps all the tiles's
 // X/Y/Z and location to Protocol Buffer based data.
 //
+// Every polygon is indexed on its own. Small or thin polygons, like islands and
+// small locations, keep no tile at the default zoom after dropping edge layers,
+// so they are retried at finer zoom levels. Polygons still without any tile are
+// listed in [LocationReport] instead of silently dropped.
+//
+// Tiles are selected purely by geometry by default. Given a sample of
+// historical query points via [SetQueries], finer tiles are kept where query
+// density is high, within [SetMaxTiles] or [SetMaxBytes] budget, so more real
+// traffic could be answered by tiles. Use [HitRate] to check the result.
+//
+// Tiles are OSM style map tiles by default, which can't represent area beyond
+// ~85° latitude. Other cell systems in [github.com/deslittle/pinpoint/cell]
+// could be used via [SetCellSystem], and recorded in output.
+//
 // A sample image of output tiles show on maps:
 // https://user-images.githubusercontent.com/13536789/200174943-7d40661e-bda5-4b79-a867-ec637e245a49.png
 package preindex
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"runtime"
+	"hash/fnv"
+	"math"
+	"sort"
 	"sync"
 
-	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/cell"
 	"github.com/deslittle/pinpoint/pb"
 	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
 	"github.com/paulmach/orb/maptile"
-	"github.com/paulmach/orb/maptile/tilecover"
 	"github.com/tidwall/geojson/geometry"
 	"github.com/tidwall/lotsa"
 	"golang.org/x/exp/maps"
@@ -110,119 +127,323 @@ func EnsureInside(geopolys []*geometry.Poly, tiles []maptile.Tile) []maptile.Til
 	return insideLocTiles
 }
 
-// PreIndexLocation will gen tiles at idxZoom level and merge up to aggZoom.
-//
-// The `idxZoom` level tiles will be removed before final return.
-func PreIndexLocation(input *pb.Location, idxZoom, aggZoom, maxZoomLevelToKeep maptile.Zoom, dropEdgeLayger int) ([]*pb.PreindexLocation, error) {
-	// Generate all tiles event not included in location shape
-	tiles := []maptile.Tile{}
-	for _, poly := range input.Polygons {
-		orbPoly := orb.Polygon{}
+var (
+	// ErrDegeneratePolygon means polygon's exterior ring has less than 3 points.
+	ErrDegeneratePolygon = errors.New("pinpoint/preindex: polygon has less than 3 points")
+	// ErrTooSmall means no tile could be kept even at the finest zoom tried.
+	ErrTooSmall = errors.New("pinpoint/preindex: no tile inside polygon at any zoom")
+)
 
-		ring := orb.Ring{}
-		for _, point := range poly.Points {
-			ring = append(ring, orb.Point{float64(point.Lng), float64(point.Lat)})
-		}
-		// bypass too little
-		if len(ring) < 10 {
-			continue
-		}
-		// add first point
-		ring = append(ring, ring[0])
-		orbPoly = append(orbPoly, ring)
-
-		// add polygon holes
-		for _, hole := range poly.Holes {
-			holering := orb.Ring{}
-			for _, point := range hole.Points {
-				holering = append(holering, orb.Point{float64(point.Lng), float64(point.Lat)})
-			}
-			if len(holering) < 3 {
-				continue
-			}
-			holering = append(holering, holering[0])
-			orbPoly = append(orbPoly, holering)
-		}
+// PolygonReport describes how a single polygon of a location was preindexed.
+type PolygonReport struct {
+	Index int          // polygon index in [pb.Location.Polygons]
+	Zoom  maptile.Zoom // zoom tiles generated at, 0 if skipped
+	Tiles int          // kept tiles count
+	Err   error        // why polygon skipped, nil if indexed
+}
 
-		// gen polygon tiles
-		polytiles, err := tilecover.Geometry(orbPoly, idxZoom)
-		if err != nil {
-			panic(err)
+// LocationReport describes how a location was preindexed, for review of
+// locations and islands which got no fast path.
+type LocationReport struct {
+	Name     string
+	Tiles    int
+	Polygons []*PolygonReport
+	Err      error // not nil if no tile kept for whole location
+}
+
+// Skipped returns polygons without any tile kept.
+func (r *LocationReport) Skipped() []*PolygonReport {
+	ret := []*PolygonReport{}
+	for _, poly := range r.Polygons {
+		if poly.Err != nil {
+			ret = append(ret, poly)
 		}
-		tiles = append(tiles, maps.Keys(polytiles)...)
 	}
-	// unable to agg
-	if len(tiles) < 9 {
-		return nil, fmt.Errorf("too little")
+	return ret
+}
+
+func toOrbPolygon(poly *pb.Polygon) (orb.Polygon, error) {
+	ring := orb.Ring{}
+	for _, point := range poly.Points {
+		ring = append(ring, orb.Point{float64(point.Lng), float64(point.Lat)})
 	}
+	if len(ring) < 3 {
+		return nil, ErrDegeneratePolygon
+	}
+	// add first point
+	ring = append(ring, ring[0])
+	orbPoly := orb.Polygon{ring}
 
-	// Iter all tile's polygon if inside original polygon
-	geopolys := convert.FromLocationPBToGeometryPoly(input)
-	insideLocTiles := EnsureInside(geopolys, tiles)
+	// add polygon holes
+	for _, hole := range poly.Holes {
+		holering := orb.Ring{}
+		for _, point := range hole.Points {
+			holering = append(holering, orb.Point{float64(point.Lng), float64(point.Lat)})
+		}
+		if len(holering) < 3 {
+			continue
+		}
+		holering = append(holering, holering[0])
+		orbPoly = append(orbPoly, holering)
+	}
+	return orbPoly, nil
+}
 
-	// Drop edge tiles
-	for i := 0; i < dropEdgeLayger; i++ {
-		insideLocTiles = DropEdgeTiles(insideLocTiles)
+// preIndexPolygon gen tiles at idxZoom level for a single polygon, drop edge
+// layers, then merge up to aggZoom and keep tiles not finer than keepZoom.
+//
+// Merged tiles finer than keepZoom are returned as finer, they are candidates
+// when optimizing with query points.
+func preIndexPolygon(system cell.System, orbPoly orb.Polygon, idxZoom, aggZoom, keepZoom maptile.Zoom, dropEdgeLayger int) (map[cell.Cell]bool, map[cell.Cell]bool, error) {
+	// Generate all tiles event not included in location shape
+	polytiles, err := system.Cover(orbPoly, uint32(idxZoom))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Gen tileset
-	newtileset := maptile.Set{}
-	for _, tile := range insideLocTiles {
+	// Drop edge tiles
+	newtileset := map[cell.Cell]bool{}
+	for _, tile := range polytiles {
 		newtileset[tile] = true
 	}
+	for i := 0; i < dropEdgeLayger; i++ {
+		newtileset = dropEdgeCells(newtileset)
+	}
 
 	// Merge all filterd tiles
-	mergedtiles := maptile.Set{}
-	for _, tile := range EnsureInside(geopolys, maps.Keys(tilecover.MergeUp(newtileset, aggZoom))) {
+	mergedtiles := map[cell.Cell]bool{}
+	finertiles := map[cell.Cell]bool{}
+	for tile := range cell.MergeUp(system, newtileset, uint32(aggZoom)) {
+		if tile.Z > uint32(keepZoom) {
+			finertiles[tile] = true
+			continue
+		}
 		mergedtiles[tile] = true
 	}
+	return mergedtiles, finertiles, nil
+}
 
-	// // Dumps JSON for debug
-	// b, _ := json.Marshal(mergedtiles.ToFeatureCollection())
-	// _ = os.WriteFile("preindexex.geojson", b, 0644)
+// dropEdgeCells works like [DropEdgeTiles] for any cell system.
+func dropEdgeCells(cells map[cell.Cell]bool) map[cell.Cell]bool {
+	ret := map[cell.Cell]bool{}
+	for c := range cells {
+		allNeighorIn := true
+		for _, neighbor := range cell.Neighbors(c) {
+			if !cells[neighbor] {
+				allNeighorIn = false
+				break
+			}
+		}
+		if allNeighorIn {
+			ret[c] = true
+		}
+	}
+	return ret
+}
 
-	// Dumps as pb
-	ret := []*pb.PreindexLocation{}
-	for _, v := range maps.Keys(mergedtiles) {
-		if int(v.Z) > int(maxZoomLevelToKeep) {
+// PreIndexLocation will gen tiles at idxZoom level and merge up to aggZoom.
+//
+// The `idxZoom` level tiles will be removed before final return.
+//
+// Each polygon is indexed on its own. Polygons too small or too thin to keep
+// any tile at idxZoom, like islands or small locations, are retried at finer
+// zoom up to [Option.MaxIdxZoom], with maxZoomLevelToKeep shifted as well.
+func PreIndexLocation(input *pb.Location, idxZoom, aggZoom, maxZoomLevelToKeep maptile.Zoom, dropEdgeLayger int, opts ...OptionFunc) ([]*pb.PreindexLocation, error) {
+	ret, report := PreIndexLocationWithReport(input, idxZoom, aggZoom, maxZoomLevelToKeep, dropEdgeLayger, opts...)
+	return ret, report.Err
+}
+
+// PreIndexLocationWithReport is like [PreIndexLocation] but returns a report
+// of each polygon instead of a single error.
+func PreIndexLocationWithReport(input *pb.Location, idxZoom, aggZoom, maxZoomLevelToKeep maptile.Zoom, dropEdgeLayger int, opts ...OptionFunc) ([]*pb.PreindexLocation, *LocationReport) {
+	ret, _, report := preIndexLocation(input, idxZoom, aggZoom, maxZoomLevelToKeep, dropEdgeLayger, newOption(idxZoom, opts...))
+	return ret, report
+}
+
+// preIndexLocation also returns tiles finer than kept zoom as candidates.
+func preIndexLocation(input *pb.Location, idxZoom, aggZoom, maxZoomLevelToKeep maptile.Zoom, dropEdgeLayger int, opt *Option) ([]*pb.PreindexLocation, map[cell.Cell]bool, *LocationReport) {
+	report := &LocationReport{
+		Name:     input.Name,
+		Polygons: make([]*PolygonReport, 0, len(input.Polygons)),
+	}
+
+	alltiles := map[cell.Cell]bool{}
+	candidates := map[cell.Cell]bool{}
+	for i, poly := range input.Polygons {
+		polyReport := &PolygonReport{Index: i}
+		report.Polygons = append(report.Polygons, polyReport)
+
+		orbPoly, err := toOrbPolygon(poly)
+		if err != nil {
+			polyReport.Err = err
 			continue
 		}
-		ret = append(ret, &pb.PreindexLocation{
-			Name: input.Name,
-			X:    int32(v.X),
-			Y:    int32(v.Y),
-			Z:    int32(v.Z),
-		})
+
+		polyReport.Err = ErrTooSmall
+		for zoom := idxZoom; zoom <= opt.MaxIdxZoom || zoom == idxZoom; zoom++ {
+			keepZoom := maxZoomLevelToKeep + (zoom - idxZoom)
+			tiles, finertiles, err := preIndexPolygon(opt.CellSystem, orbPoly, zoom, aggZoom, keepZoom, dropEdgeLayger)
+			if err != nil {
+				polyReport.Err = err
+				break
+			}
+			if len(tiles) == 0 {
+				continue
+			}
+			for tile := range finertiles {
+				candidates[tile] = true
+			}
+			polyReport.Zoom = zoom
+			polyReport.Tiles = len(tiles)
+			polyReport.Err = nil
+			for tile := range tiles {
+				alltiles[tile] = true
+			}
+			break
+		}
+	}
+
+	// Dumps as pb
+	ret := []*pb.PreindexLocation{}
+	for _, v := range maps.Keys(alltiles) {
+		ret = append(ret, newKey(input.Name, v))
+	}
+	SortKeys(ret)
+
+	report.Tiles = len(ret)
+	if len(ret) == 0 {
+		report.Err = fmt.Errorf("pinpoint/preindex: location=%v: %w", input.Name, ErrTooSmall)
+	}
+	return ret, candidates, report
+}
+
+func newKey(name string, tile cell.Cell) *pb.PreindexLocation {
+	return &pb.PreindexLocation{
+		Name: name,
+		X:    int32(tile.X),
+		Y:    int32(tile.Y),
+		Z:    int32(tile.Z),
 	}
-	return ret, nil
 }
 
-func PreIndexLocations(input *pb.Locations, idxZoom, aggZoom, maxZoomLevelToKeep maptile.Zoom, dropEdgeLayger int) *pb.PreindexLocations {
+// SortKeys sorts preindex keys by name, then by tile's Z/X/Y.
+//
+// Map iteration and goroutines make raw output order random, sorted keys
+// make the same input always dumps to same bytes.
+func SortKeys(keys []*pb.PreindexLocation) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return lessKey(keys[i], keys[j])
+	})
+}
+
+func lessKey(a, b *pb.PreindexLocation) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	if a.Z != b.Z {
+		return a.Z < b.Z
+	}
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	return a.Y < b.Y
+}
+
+func PreIndexLocations(input *pb.Locations, idxZoom, aggZoom, maxZoomLevelToKeep maptile.Zoom, dropEdgeLayger int, opts ...OptionFunc) *pb.PreindexLocations {
+	opt := newOption(idxZoom, opts...)
 	ret := &pb.PreindexLocations{
-		IdxZoom: int32(idxZoom),
-		AggZoom: int32(aggZoom),
-		Keys:    make([]*pb.PreindexLocation, 0),
+		IdxZoom:    int32(idxZoom),
+		AggZoom:    int32(aggZoom),
+		Keys:       make([]*pb.PreindexLocation, 0),
+		CellSystem: opt.CellSystem.Kind(),
+		Metadata:   pb.CopyMetadata(input.Metadata, pb.CellSystemEncoding(opt.CellSystem.Kind())),
 	}
 
+	total := len(input.Locations)
+	done := 0
+	results := make([][]*pb.PreindexLocation, total)
+	candidates := make([]map[cell.Cell]bool, total)
+	reports := make([]*LocationReport, total)
 	lock := &sync.Mutex{}
-	lotsa.Ops(len(input.Locations), runtime.NumCPU()*2, func(i, thread int) {
+	lotsa.Ops(total, opt.Workers, func(i, thread int) {
 		tz := input.Locations[i]
-		preindexes, err := PreIndexLocation(tz, idxZoom, aggZoom, maxZoomLevelToKeep, dropEdgeLayger)
-		if err != nil {
+		results[i], candidates[i], reports[i] = preIndexLocation(tz, idxZoom, aggZoom, maxZoomLevelToKeep, dropEdgeLayger, opt)
+		if opt.Progress == nil {
 			return
 		}
 		lock.Lock()
-		ret.Keys = append(ret.Keys, preindexes...)
-		lock.Unlock()
+		defer lock.Unlock()
+		done++
+		opt.Progress(done, total)
 	})
+
+	for i, preindexes := range results {
+		ret.Keys = append(ret.Keys, preindexes...)
+		if opt.Report != nil {
+			opt.Report(reports[i])
+		}
+	}
+	if len(opt.Queries) != 0 {
+		ret.Keys = append(ret.Keys, selectByQueries(input, ret, candidates, opt)...)
+	}
+	SortKeys(ret.Keys)
 	return ret
 }
 
+// PreIndexLocationsToGeoJSON dumps tiles as a GeoJSON FeatureCollection.
+//
+// Each tile carries its location's name and a fill color derived from that
+// name, so neighbor locations are easy to tell apart on maps like geojson.io.
+//
+// Returns nil if input's cell system is unknown.
 func PreIndexLocationsToGeoJSON(input *pb.PreindexLocations) []byte {
-	tileset := maptile.Set{}
+	system, err := cell.FromKind(input.CellSystem)
+	if err != nil {
+		return nil
+	}
+	fc := geojson.NewFeatureCollection()
 	for _, key := range input.Keys {
-		tileset[maptile.New(uint32(key.X), uint32(key.Y), maptile.Zoom(key.Z))] = true
+		color := LocationColor(key.Name)
+		feature := geojson.NewFeature(system.Bound(keyCell(key)).ToPolygon())
+		feature.Properties["name"] = key.Name
+		feature.Properties["x"] = key.X
+		feature.Properties["y"] = key.Y
+		feature.Properties["z"] = key.Z
+		feature.Properties["fill"] = color
+		feature.Properties["stroke"] = color
+		fc.Append(feature)
 	}
-	b, _ := json.Marshal(tileset.ToFeatureCollection())
+	b, _ := json.Marshal(fc)
 	return b
 }
+
+// LocationColor returns a stable "#rrggbb" color for a location name.
+func LocationColor(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	sum := h.Sum32()
+
+	// Pick hue from hash, keep saturation and lightness fixed for readability.
+	hue := float64(sum%360) / 60
+	const s, l = 0.65, 0.5
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(hue, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch int(hue) {
+	case 0:
+		r, g, b = c, x, 0
+	case 1:
+		r, g, b = x, c, 0
+	case 2:
+		r, g, b = 0, c, x
+	case 3:
+		r, g, b = 0, x, c
+	case 4:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return fmt.Sprintf("#%02x%02x%02x", int((r+m)*255), int((g+m)*255), int((b+m)*255))
+}
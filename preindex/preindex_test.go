@@ -0,0 +1,251 @@
+package preindex_test
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	usstates "github.com/deslittle/pinpoint-us-states"
+	"github.com/deslittle/pinpoint/cell"
+	"github.com/deslittle/pinpoint/convert"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/preindex"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+	"github.com/tidwall/geojson/geometry"
+	"google.golang.org/protobuf/proto"
+)
+
+// smallLocations returns a few small states from lite data to keep tests fast.
+func smallLocations(t testing.TB, names ...string) *pb.Locations {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.LiteData, input); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{}
+	for _, name := range names {
+		want[name] = true
+	}
+	ret := &pb.Locations{}
+	for _, location := range input.Locations {
+		if want[location.Name] {
+			ret.Locations = append(ret.Locations, location)
+		}
+	}
+	if len(ret.Locations) != len(names) {
+		t.Fatalf("got %v locations, want %v", len(ret.Locations), len(names))
+	}
+	return ret
+}
+
+func TestPreIndexLocationsDeterministic(t *testing.T) {
+	input := smallLocations(t, "44", "34", "10", "09")
+
+	var expect []byte
+	for _, workers := range []int{1, 2, 3, 8, 1, 8} {
+		output := preindex.PreIndexLocations(input, 11, 3, 10, 2, preindex.SetWorkers(workers))
+		if len(output.Keys) == 0 {
+			t.Fatalf("workers=%v got no keys", workers)
+		}
+		b, err := proto.Marshal(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect == nil {
+			expect = b
+			continue
+		}
+		if !bytes.Equal(expect, b) {
+			t.Errorf("workers=%v output not equal to first run", workers)
+		}
+	}
+}
+
+func TestPreIndexLocationsSorted(t *testing.T) {
+	input := smallLocations(t, "44", "34", "10", "09")
+	output := preindex.PreIndexLocations(input, 11, 3, 10, 2)
+	for i := 1; i < len(output.Keys); i++ {
+		a, b := output.Keys[i-1], output.Keys[i]
+		if a.Name > b.Name {
+			t.Fatalf("keys not sorted by name at %v: %v > %v", i, a.Name, b.Name)
+		}
+		if a.Name == b.Name && [3]int32{a.Z, a.X, a.Y} == [3]int32{b.Z, b.X, b.Y} {
+			t.Fatalf("duplicated key at %v: %v", i, b)
+		}
+	}
+}
+
+func TestPreIndexLocationSmall(t *testing.T) {
+	// District of Columbia is too small to keep any tile at idxZoom=13
+	input := smallLocations(t, "11").Locations[0]
+
+	_, err := preindex.PreIndexLocation(input, 13, 3, 10, 2, preindex.SetMaxIdxZoom(13))
+	if !errors.Is(err, preindex.ErrTooSmall) {
+		t.Fatalf("expect ErrTooSmall without finer zoom, got %v", err)
+	}
+
+	keys, report := preindex.PreIndexLocationWithReport(input, 13, 3, 10, 2)
+	if report.Err != nil {
+		t.Fatal(report.Err)
+	}
+	if len(keys) == 0 || report.Tiles != len(keys) {
+		t.Fatalf("got %v keys, report %v tiles", len(keys), report.Tiles)
+	}
+	if report.Polygons[0].Zoom <= 13 {
+		t.Errorf("expect finer zoom than 13, got %v", report.Polygons[0].Zoom)
+	}
+}
+
+func TestPreIndexLocationReport(t *testing.T) {
+	input := &pb.Location{
+		Name: "degenerate",
+		Polygons: []*pb.Polygon{
+			{Points: []*pb.Point{{Lng: 1, Lat: 1}, {Lng: 2, Lat: 2}}},
+		},
+	}
+	keys, report := preindex.PreIndexLocationWithReport(input, 13, 3, 10, 2)
+	if len(keys) != 0 {
+		t.Fatalf("expect no keys, got %v", len(keys))
+	}
+	if !errors.Is(report.Err, preindex.ErrTooSmall) {
+		t.Errorf("expect ErrTooSmall, got %v", report.Err)
+	}
+	skipped := report.Skipped()
+	if len(skipped) != 1 || !errors.Is(skipped[0].Err, preindex.ErrDegeneratePolygon) {
+		t.Errorf("expect degenerate polygon skipped, got %v", skipped)
+	}
+}
+
+// cityQueries returns points around a city center on a small grid.
+func cityQueries(lng, lat float64) []orb.Point {
+	ret := []orb.Point{}
+	for dx := -10; dx <= 10; dx++ {
+		for dy := -10; dy <= 10; dy++ {
+			ret = append(ret, orb.Point{lng + float64(dx)*0.005, lat + float64(dy)*0.005})
+		}
+	}
+	return ret
+}
+
+func TestPreIndexLocationsWithQueries(t *testing.T) {
+	input := smallLocations(t, "44", "34", "10", "09")
+	queries := append(cityQueries(-71.4128, 41.824), cityQueries(-74.1724, 40.7357)...)
+
+	base := preindex.PreIndexLocations(input, 11, 3, 8, 2)
+	baseRate := preindex.HitRate(base, queries)
+
+	optimized := preindex.PreIndexLocations(input, 11, 3, 8, 2, preindex.SetQueries(queries))
+	optimizedRate := preindex.HitRate(optimized, queries)
+	if optimizedRate <= baseRate {
+		t.Errorf("expect hit rate improved, base=%v optimized=%v", baseRate, optimizedRate)
+	}
+
+	maxTiles := len(base.Keys) + 10
+	limited := preindex.PreIndexLocations(input, 11, 3, 8, 2, preindex.SetQueries(queries), preindex.SetMaxTiles(maxTiles))
+	if len(limited.Keys) > maxTiles {
+		t.Errorf("expect at most %v tiles, got %v", maxTiles, len(limited.Keys))
+	}
+	if rate := preindex.HitRate(limited, queries); rate <= baseRate || rate > optimizedRate {
+		t.Errorf("expect hit rate in (%v, %v], got %v", baseRate, optimizedRate, rate)
+	}
+
+	maxBytes := proto.Size(base) + 100
+	limited = preindex.PreIndexLocations(input, 11, 3, 8, 2, preindex.SetQueries(queries), preindex.SetMaxBytes(maxBytes))
+	if size := proto.Size(limited); size > maxBytes {
+		t.Errorf("expect at most %v bytes, got %v", maxBytes, size)
+	}
+}
+
+func TestReadQueryPoints(t *testing.T) {
+	points, err := preindex.ReadQueryPoints(strings.NewReader("# lng,lat\n-74.1,40.7\n\n -71.4 , 41.8 \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || points[1] != (orb.Point{-71.4, 41.8}) {
+		t.Errorf("bad points %v", points)
+	}
+	if _, err := preindex.ReadQueryPoints(strings.NewReader("-74.1\n")); err == nil {
+		t.Error("expect error for bad line")
+	}
+}
+
+func TestPreIndexLocationsCellSystem(t *testing.T) {
+	input := smallLocations(t, "44", "34", "10", "09")
+	input.Metadata = pb.NewMetadata()
+	points := randomPointsInside(input, 1000)
+	for _, c := range []struct {
+		system                     cell.System
+		idxZoom, aggZoom, keepZoom maptile.Zoom
+	}{
+		{cell.Geohash{}, 22, 6, 18},
+		{cell.EqualArea{}, 10, 2, 8},
+	} {
+		system := c.system
+		output := preindex.PreIndexLocations(input, c.idxZoom, c.aggZoom, c.keepZoom, 2, preindex.SetCellSystem(system))
+		if output.CellSystem != system.Kind() {
+			t.Errorf("got cell system %v, want %v", output.CellSystem, system.Kind())
+		}
+		if got, want := output.Metadata.GetCoordinateEncoding(), pb.CellSystemEncoding(system.Kind()); got != want {
+			t.Errorf("got coordinate encoding %q, want %q", got, want)
+		}
+		if rate := preindex.HitRate(output, points); rate == 0 {
+			t.Errorf("%v: expect some points hit", system.Kind())
+		}
+	}
+}
+
+// randomPointsInside returns points uniformly distributed inside locations.
+func randomPointsInside(input *pb.Locations, n int) []orb.Point {
+	r := rand.New(rand.NewSource(1))
+	polys := []*geometry.Poly{}
+	bound := orb.Bound{Min: orb.Point{180, 90}, Max: orb.Point{-180, -90}}
+	for _, location := range input.Locations {
+		polys = append(polys, convert.FromLocationPBToGeometryPoly(location)...)
+		for _, poly := range location.Polygons {
+			for _, p := range poly.Points {
+				bound = bound.Extend(orb.Point{float64(p.Lng), float64(p.Lat)})
+			}
+		}
+	}
+	ret := []orb.Point{}
+	for len(ret) < n {
+		p := orb.Point{
+			bound.Min.Lon() + r.Float64()*(bound.Max.Lon()-bound.Min.Lon()),
+			bound.Min.Lat() + r.Float64()*(bound.Max.Lat()-bound.Min.Lat()),
+		}
+		for _, poly := range polys {
+			if poly.ContainsPoint(geometry.Point{X: p.Lon(), Y: p.Lat()}) {
+				ret = append(ret, p)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+func benchmarkCellSystem(b *testing.B, system cell.System, idxZoom, aggZoom, keepZoom maptile.Zoom) {
+	input := smallLocations(b, "44", "34", "10", "09", "25", "24")
+	points := randomPointsInside(input, 5000)
+	var output *pb.PreindexLocations
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		output = preindex.PreIndexLocations(input, idxZoom, aggZoom, keepZoom, 2, preindex.SetCellSystem(system))
+	}
+	b.ReportMetric(preindex.HitRate(output, points)*100, "hit%")
+	b.ReportMetric(float64(proto.Size(output)), "bytes")
+	b.ReportMetric(float64(len(output.Keys)), "tiles")
+}
+
+func BenchmarkPreIndexLocations_MapTile(b *testing.B) {
+	benchmarkCellSystem(b, cell.MapTile{}, 13, 3, 10)
+}
+
+func BenchmarkPreIndexLocations_Geohash(b *testing.B) {
+	benchmarkCellSystem(b, cell.Geohash{}, 26, 6, 20)
+}
+
+func BenchmarkPreIndexLocations_EqualArea(b *testing.B) {
+	benchmarkCellSystem(b, cell.EqualArea{}, 12, 2, 9)
+}
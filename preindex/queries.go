@@ -0,0 +1,177 @@
+package preindex
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deslittle/pinpoint/cell"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/paulmach/orb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// ReadQueryPoints reads historical query points, one "lng,lat" per line.
+//
+// Blank lines and lines start with "#" are ignored.
+func ReadQueryPoints(r io.Reader) ([]orb.Point, error) {
+	ret := []orb.Point{}
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("pinpoint/preindex: line %d: expect lng,lat got %q", lineno, line)
+		}
+		lng, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/preindex: line %d: bad lng: %w", lineno, err)
+		}
+		lat, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/preindex: line %d: bad lat: %w", lineno, err)
+		}
+		ret = append(ret, orb.Point{lng, lat})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// tileIndex looks up points like [github.com/deslittle/pinpoint.FuzzyFinder].
+type tileIndex struct {
+	system cell.System
+	zooms  []uint32
+	m      map[cell.Cell][]int
+}
+
+func newTileIndex(system cell.System) *tileIndex {
+	return &tileIndex{system: system, m: map[cell.Cell][]int{}}
+}
+
+func (idx *tileIndex) add(tile cell.Cell, i int) {
+	if _, ok := idx.m[tile]; !ok {
+		found := false
+		for _, z := range idx.zooms {
+			if z == tile.Z {
+				found = true
+				break
+			}
+		}
+		if !found {
+			idx.zooms = append(idx.zooms, tile.Z)
+			sort.Slice(idx.zooms, func(i, j int) bool { return idx.zooms[i] < idx.zooms[j] })
+		}
+	}
+	idx.m[tile] = append(idx.m[tile], i)
+}
+
+func (idx *tileIndex) get(p orb.Point) []int {
+	for _, z := range idx.zooms {
+		if v, ok := idx.m[idx.system.At(p, z)]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func keyCell(key *pb.PreindexLocation) cell.Cell {
+	return cell.Cell{X: uint32(key.X), Y: uint32(key.Y), Z: uint32(key.Z)}
+}
+
+// HitRate returns the fraction of points which could be answered by tiles.
+//
+// Returns 0 if input's cell system is unknown.
+func HitRate(input *pb.PreindexLocations, points []orb.Point) float64 {
+	system, err := cell.FromKind(input.CellSystem)
+	if err != nil || len(points) == 0 {
+		return 0
+	}
+	idx := newTileIndex(system)
+	for i, key := range input.Keys {
+		idx.add(keyCell(key), i)
+	}
+	hits := 0
+	for _, p := range points {
+		if idx.get(p) != nil {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(points))
+}
+
+type candidate struct {
+	key  *pb.PreindexLocation
+	hits int
+}
+
+// selectByQueries picks finer tiles which answer most of query points not
+// answered by output's base keys yet, until [Option.MaxTiles] or
+// [Option.MaxBytes].
+//
+// Candidates of a location never overlap, so picking by hits count greedily
+// maximize hit rate for a tile count budget.
+func selectByQueries(input *pb.Locations, output *pb.PreindexLocations, candidates []map[cell.Cell]bool, opt *Option) []*pb.PreindexLocation {
+	base := output.Keys
+	baseidx := newTileIndex(opt.CellSystem)
+	for i, key := range base {
+		baseidx.add(keyCell(key), i)
+	}
+
+	cands := []*candidate{}
+	candidx := newTileIndex(opt.CellSystem)
+	for i, tiles := range candidates {
+		for tile := range tiles {
+			candidx.add(tile, len(cands))
+			cands = append(cands, &candidate{key: newKey(input.Locations[i].Name, tile)})
+		}
+	}
+
+	for _, p := range opt.Queries {
+		if baseidx.get(p) != nil {
+			continue
+		}
+		for _, i := range candidx.get(p) {
+			cands[i].hits++
+		}
+	}
+
+	sort.Slice(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.hits != b.hits {
+			return a.hits > b.hits
+		}
+		return lessKey(a.key, b.key)
+	})
+
+	tiles := len(base)
+	// metadata and other fields count too
+	bytes := proto.Size(output)
+	ret := []*pb.PreindexLocation{}
+	for _, cand := range cands {
+		if cand.hits == 0 {
+			break
+		}
+		size := protowire.SizeTag(3) + protowire.SizeBytes(proto.Size(cand.key))
+		if opt.MaxTiles > 0 && tiles+1 > opt.MaxTiles {
+			break
+		}
+		if opt.MaxBytes > 0 && bytes+size > opt.MaxBytes {
+			break
+		}
+		tiles++
+		bytes += size
+		ret = append(ret, cand.key)
+	}
+	return ret
+}
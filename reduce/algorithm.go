@@ -0,0 +1,141 @@
+package reduce
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/orb/simplify"
+)
+
+// Algorithm is the line simplification algorithm used to reduce rings.
+type Algorithm int
+
+const (
+	// DouglasPeucker drops points closer than tolerance to the simplified line.
+	DouglasPeucker Algorithm = iota
+	// VisvalingamWhyatt drops points whose triangle with neighbors has area
+	// less than tolerance², keeps shape better for small features.
+	VisvalingamWhyatt
+	// RadialDistance drops points closer than tolerance to previous kept point.
+	RadialDistance
+)
+
+var algorithmNames = map[Algorithm]string{
+	DouglasPeucker:    "douglas-peucker",
+	VisvalingamWhyatt: "visvalingam-whyatt",
+	RadialDistance:    "radial",
+}
+
+func (a Algorithm) String() string {
+	if name, ok := algorithmNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(a))
+}
+
+// ParseAlgorithm parses algorithm's name returned by [Algorithm.String].
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for a, n := range algorithmNames {
+		if n == name {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("pinpoint/reduce: unknown algorithm %v", name)
+}
+
+// simplifier returns a's simplifier, [DouglasPeucker]'s if a is unknown.
+func (a Algorithm) simplifier(tolerance float64) orb.Simplifier {
+	switch a {
+	case VisvalingamWhyatt:
+		return simplify.VisvalingamThreshold(tolerance * tolerance)
+	case RadialDistance:
+		return simplify.Radial(planar.Distance, tolerance)
+	default:
+		return simplify.DouglasPeucker(tolerance)
+	}
+}
+
+// SimplifyPointsWith simplify points by algorithm, tolerance is in degree.
+// Unknown algorithm is [DouglasPeucker].
+//
+// Begin&end point are always kept.
+func SimplifyPointsWith(points []*pb.Point, algorithm Algorithm, tolerance float64) []*pb.Point {
+	if len(points) == 0 {
+		return points
+	}
+	original := toLineString(points)
+	reduced := algorithm.simplifier(tolerance).Simplify(original.Clone()).(orb.LineString)
+	return fromLineString(reduced)
+}
+
+// LocationArea returns location's planar area in square degree, holes excluded.
+func LocationArea(location *pb.Location) float64 {
+	area := 0.0
+	for _, polygon := range location.Polygons {
+		area += math.Abs(planar.Area(toRing(polygon.Points)))
+		for _, hole := range polygon.Holes {
+			area -= math.Abs(planar.Area(toRing(hole.Points)))
+		}
+	}
+	return area
+}
+
+func toRing(points []*pb.Point) orb.Ring {
+	return orb.Ring(toLineString(points))
+}
+
+// areaTolerance scales tolerance down by location's linear size compared with
+// a location of reference area, never scales up.
+func areaTolerance(location *pb.Location, tolerance float64, reference float64) float64 {
+	scale := math.Sqrt(LocationArea(location) / reference)
+	if scale >= 1 {
+		return tolerance
+	}
+	return tolerance * scale
+}
+
+// budgetTolerance finds the smallest tolerance which reduces location to at
+// most budget points.
+func budgetTolerance(budget int, reduceAt func(tolerance float64) int) float64 {
+	if reduceAt(0) <= budget {
+		return 0
+	}
+	lo, hi := 1e-7, 1.0
+	if reduceAt(hi) > budget {
+		return hi
+	}
+	// bisect in log scale, tolerances span many orders of magnitude
+	for i := 0; i < 40; i++ {
+		mid := math.Sqrt(lo * hi)
+		if reduceAt(mid) <= budget {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
+
+// errorTolerance returns the largest tolerance not larger than tolerance whose
+// error is at most maxError, or 0 if none.
+func errorTolerance(tolerance float64, maxError float64, errorAt func(tolerance float64) float64) float64 {
+	if tolerance <= 0 || errorAt(tolerance) <= maxError {
+		return tolerance
+	}
+	lo, hi := tolerance*1e-4, tolerance
+	if errorAt(lo) > maxError {
+		return 0
+	}
+	for i := 0; i < 20; i++ {
+		mid := math.Sqrt(lo * hi)
+		if errorAt(mid) <= maxError {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
@@ -15,24 +15,61 @@ func CompressedPointsToPolylineBytes(points []*pb.Point) []byte {
 	return polyline.EncodeCoords(expect)
 }
 
-func DecompressedPolylineBytesToPoints(input []byte) []*pb.Point {
+func DecompressedPolylineBytesToPoints(input []byte) ([]*pb.Point, error) {
 	expect := []*pb.Point{}
-	coords, _, _ := polyline.DecodeCoords(input)
+	coords, _, err := polyline.DecodeCoords(input)
+	if err != nil {
+		return nil, err
+	}
 	for _, coord := range coords {
+		if len(coord) != 2 {
+			return nil, fmt.Errorf("pinpoint/reduce: got %d dimensions coordinate", len(coord))
+		}
 		expect = append(expect, &pb.Point{
 			Lng: float32(coord[0]), Lat: float32(coord[1]),
 		})
 	}
-	return expect
+	return expect, nil
+}
+
+// decompressLocations decodes every ring by [DecompressRings].
+func decompressLocations(input *pb.CompressedLocations, method pb.CompressMethod) (*pb.Locations, error) {
+	output := &pb.Locations{Metadata: pb.CopyMetadata(input.Metadata, pb.EncodingFloat32)}
+	for _, location := range input.Locations {
+		output.Locations = append(output.Locations, &pb.Location{Name: location.Name, Id: location.Id})
+	}
+	err := decompressRings(input, method, func(location, polygon, ring int, coords []float64) error {
+		points := make([]*pb.Point, 0, len(coords)/2)
+		for i := 0; i < len(coords); i += 2 {
+			points = append(points, &pb.Point{Lng: float32(coords[i]), Lat: float32(coords[i+1])})
+		}
+		loc := output.Locations[location]
+		if ring == 0 {
+			loc.Polygons = append(loc.Polygons, &pb.Polygon{
+				Points: points,
+				Holes:  make([]*pb.Polygon, 0),
+			})
+			return nil
+		}
+		poly := loc.Polygons[len(loc.Polygons)-1]
+		poly.Holes = append(poly.Holes, &pb.Polygon{Points: points})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func CompressWithPolyline(input *pb.Locations) *pb.CompressedLocations {
 	output := &pb.CompressedLocations{
-		Method: pb.CompressMethod_Polyline,
+		Method:   pb.CompressMethod_Polyline,
+		Metadata: pb.CopyMetadata(input.Metadata, pb.EncodingPolyline),
 	}
 	for _, location := range input.Locations {
 		reducedLocation := &pb.CompressedLocation{
 			Name: location.Name,
+			Id:   location.Id,
 		}
 		for _, polygon := range location.Polygons {
 			newPoly := &pb.CompressedPolygon{
@@ -51,42 +88,33 @@ func CompressWithPolyline(input *pb.Locations) *pb.CompressedLocations {
 	return output
 }
 
-func Compress(input *pb.Locations, method pb.CompressMethod) (*pb.CompressedLocations, error) {
+// Compress compress locations by method, [SetScale] and [SetRingCompression]
+// work for [pb.CompressMethod_DeltaVarint] only. Input's metadata is copied
+// with method's coordinate encoding.
+func Compress(input *pb.Locations, method pb.CompressMethod, opts ...OptionFunc) (*pb.CompressedLocations, error) {
+	opt := newOption(opts...)
 	switch method {
 	case pb.CompressMethod_Polyline:
 		return CompressWithPolyline(input), nil
+	case pb.CompressMethod_DeltaVarint:
+		return CompressWithDeltaVarint(input, opt.Scale, opt.RingCompression)
 	default:
 		return nil, fmt.Errorf("pinpoint/reduce: unknown method %v", method)
 	}
 }
 
-func DecompressWithPolyline(input *pb.CompressedLocations) *pb.Locations {
-	output := &pb.Locations{}
-	for _, location := range input.Locations {
-		reducedLocation := &pb.Location{
-			Name: location.Name,
-		}
-		for _, polygon := range location.Data {
-			newPoly := &pb.Polygon{
-				Points: DecompressedPolylineBytesToPoints(polygon.Points),
-				Holes:  make([]*pb.Polygon, 0),
-			}
-			for _, hole := range polygon.Holes {
-				newPoly.Holes = append(newPoly.Holes, &pb.Polygon{
-					Points: DecompressedPolylineBytesToPoints(hole.Points),
-				})
-			}
-			reducedLocation.Polygons = append(reducedLocation.Polygons, newPoly)
-		}
-		output.Locations = append(output.Locations, reducedLocation)
-	}
-	return output
+func DecompressWithPolyline(input *pb.CompressedLocations) (*pb.Locations, error) {
+	return decompressLocations(input, pb.CompressMethod_Polyline)
 }
 
+// Decompress decompress locations, every ring must have at least 3
+// points with valid longitude and latitude. Input's metadata is copied.
 func Decompress(input *pb.CompressedLocations) (*pb.Locations, error) {
 	switch input.Method {
 	case pb.CompressMethod_Polyline:
-		return DecompressWithPolyline(input), nil
+		return DecompressWithPolyline(input)
+	case pb.CompressMethod_DeltaVarint:
+		return DecompressWithDeltaVarint(input)
 	default:
 		return nil, fmt.Errorf("pinpoint/reduce: unknown method %v", input.Method)
 	}
@@ -0,0 +1,336 @@
+package reduce_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	usstates "github.com/deslittle/pinpoint-us-states"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/reduce"
+	"google.golang.org/protobuf/proto"
+)
+
+func loadLite(t testing.TB) *pb.Locations {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.LiteData, input); err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+func maxDrift(t testing.TB, a, b *pb.Locations) float64 {
+	drift := 0.0
+	compare := func(x, y []*pb.Point) {
+		if len(x) != len(y) {
+			t.Fatalf("got %v points, want %v", len(y), len(x))
+		}
+		for i := range x {
+			drift = math.Max(drift, math.Abs(float64(x[i].Lng-y[i].Lng)))
+			drift = math.Max(drift, math.Abs(float64(x[i].Lat-y[i].Lat)))
+		}
+	}
+	for i, location := range a.Locations {
+		other := b.Locations[i]
+		if location.Name != other.Name || len(location.Polygons) != len(other.Polygons) {
+			t.Fatalf("location %v mismatch", location.Name)
+		}
+		for j, polygon := range location.Polygons {
+			compare(polygon.Points, other.Polygons[j].Points)
+			for k, hole := range polygon.Holes {
+				compare(hole.Points, other.Polygons[j].Holes[k].Points)
+			}
+		}
+	}
+	return drift
+}
+
+func TestDeltaVarintRoundTrip(t *testing.T) {
+	input := loadLite(t)
+	for _, c := range []struct {
+		scale           float64
+		ringCompression pb.RingCompression
+	}{
+		{reduce.DefaultScale, pb.RingCompression_NoRingCompression},
+		{reduce.DefaultScale, pb.RingCompression_Deflate},
+		{1e7, pb.RingCompression_NoRingCompression},
+	} {
+		compressed, err := reduce.Compress(input, pb.CompressMethod_DeltaVarint, reduce.SetScale(c.scale), reduce.SetRingCompression(c.ringCompression))
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := reduce.Decompress(compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// float32 has about 7 significant digits, 1e-5 at 100 degrees
+		if drift := maxDrift(t, input, output); drift > math.Max(0.5/c.scale, 1e-5) {
+			t.Errorf("scale=%v %v: got drift %v", c.scale, c.ringCompression, drift)
+		}
+	}
+}
+
+func TestCompressMetadata(t *testing.T) {
+	input := loadLite(t)
+	input.Metadata = pb.NewMetadata()
+	input.Metadata.Name = "us-states"
+	input.Metadata.AddStep("geojson2locpb", nil)
+
+	reduced := reduce.Do(input, 1, 0, 0)
+	for _, method := range []pb.CompressMethod{pb.CompressMethod_Polyline, pb.CompressMethod_DeltaVarint} {
+		compressed, err := reduce.Compress(reduced, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compressed.Metadata.AddStep("compresslocpb", nil)
+		if got := compressed.Metadata; got.Name != "us-states" || len(got.Steps) != 2 || got.CoordinateEncoding == pb.EncodingFloat32 {
+			t.Errorf("%v: got metadata %v", method, got)
+		}
+		output, err := reduce.Decompress(compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := output.Metadata; len(got.Steps) != 2 || got.CoordinateEncoding != pb.EncodingFloat32 {
+			t.Errorf("%v: got decompressed metadata %v", method, got)
+		}
+	}
+	if len(input.Metadata.Steps) != 1 || len(reduced.Metadata.Steps) != 1 {
+		t.Error("expect metadata copied instead of shared")
+	}
+
+	// data made before metadata gets new one
+	reduced.Metadata = nil
+	compressed, err := reduce.Compress(reduced, pb.CompressMethod_Polyline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := compressed.Metadata; got.GetSchemaVersion() != pb.SchemaVersion || got.GetCoordinateEncoding() != pb.EncodingPolyline {
+		t.Errorf("got metadata %v of input without metadata", got)
+	}
+}
+
+func TestDeltaVarintBytesToPointsTruncated(t *testing.T) {
+	data := reduce.PointsToDeltaVarintBytes([]*pb.Point{{Lng: -71.5, Lat: 41.5}}, reduce.DefaultScale)
+	if _, err := reduce.DeltaVarintBytesToPoints(data[:len(data)-1], reduce.DefaultScale); err == nil {
+		t.Error("expect error")
+	}
+}
+
+func TestDecompressDeflateBomb(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := flate.NewWriter(buf, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(make([]byte, 65<<20)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	input := &pb.CompressedLocations{
+		Method:          pb.CompressMethod_DeltaVarint,
+		Scale:           reduce.DefaultScale,
+		RingCompression: pb.RingCompression_Deflate,
+		Locations: []*pb.CompressedLocation{{
+			Name: "bomb",
+			Data: []*pb.CompressedPolygon{{Points: buf.Bytes()}},
+		}},
+	}
+	if _, err := reduce.Decompress(input); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("got error %v", err)
+	}
+}
+
+// BenchmarkDecompress compares size and decoding speed of compress methods on
+// lite data.
+func BenchmarkDecompress(b *testing.B) {
+	input := loadLite(b)
+	for _, c := range []struct {
+		name   string
+		method pb.CompressMethod
+		opts   []reduce.OptionFunc
+	}{
+		{"Polyline", pb.CompressMethod_Polyline, nil},
+		{"DeltaVarint", pb.CompressMethod_DeltaVarint, nil},
+		{"DeltaVarint_Deflate", pb.CompressMethod_DeltaVarint, []reduce.OptionFunc{reduce.SetRingCompression(pb.RingCompression_Deflate)}},
+	} {
+		compressed, err := reduce.Compress(input, c.method, c.opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		data, _ := proto.Marshal(compressed)
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := reduce.Decompress(compressed); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(data)), "bytes")
+		})
+	}
+}
+
+func ExampleCompress() {
+	input := &pb.Locations{Locations: []*pb.Location{{
+		Name: "square",
+		Polygons: []*pb.Polygon{{Points: []*pb.Point{
+			{Lng: -71.5, Lat: 41.5}, {Lng: -71.4, Lat: 41.5}, {Lng: -71.4, Lat: 41.6}, {Lng: -71.5, Lat: 41.5},
+		}}},
+	}}}
+	compressed, _ := reduce.Compress(input, pb.CompressMethod_DeltaVarint)
+	fmt.Println(len(compressed.Locations[0].Data[0].Points))
+	// Output: 22
+}
+
+func loadLiteCompressed(t testing.TB) *pb.CompressedLocations {
+	input := &pb.CompressedLocations{}
+	if err := proto.Unmarshal(usstates.LiteCompressData, input); err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+func TestDecompressCorrupt(t *testing.T) {
+	cases := []struct {
+		name   string
+		method pb.CompressMethod
+		modify func(c *pb.CompressedLocations)
+		expect string
+	}{
+		{"truncated polyline", pb.CompressMethod_Polyline, func(c *pb.CompressedLocations) {
+			data := c.Locations[1].Data[0].Points
+			c.Locations[1].Data[0].Points = data[:len(data)-1]
+		}, "location 1 "},
+		{"too few points", pb.CompressMethod_Polyline, func(c *pb.CompressedLocations) {
+			c.Locations[2].Data[0].Points = nil
+		}, "location 2 "},
+		{"truncated varint", pb.CompressMethod_DeltaVarint, func(c *pb.CompressedLocations) {
+			data := c.Locations[3].Data[0].Points
+			c.Locations[3].Data[0].Points = data[:len(data)-1]
+		}, "location 3 "},
+		{"out of range", pb.CompressMethod_DeltaVarint, func(c *pb.CompressedLocations) {
+			c.Scale = 1
+		}, "out of range"},
+	}
+	input := loadLite(t)
+	for _, c := range cases {
+		compressed, err := reduce.Compress(input, c.method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.modify(compressed)
+		_, err = reduce.Decompress(compressed)
+		if err == nil || !strings.Contains(err.Error(), c.expect) {
+			t.Errorf("%v: got error %v, want containing %q", c.name, err, c.expect)
+		}
+	}
+}
+
+func checkDecompressed(t *testing.T, output *pb.Locations) {
+	for _, location := range output.Locations {
+		for _, polygon := range location.Polygons {
+			if len(polygon.Points) < 3 {
+				t.Fatalf("location %v: got ring with %v points", location.Name, len(polygon.Points))
+			}
+			for _, hole := range polygon.Holes {
+				if len(hole.Points) < 3 {
+					t.Fatalf("location %v: got hole with %v points", location.Name, len(hole.Points))
+				}
+			}
+		}
+	}
+}
+
+func FuzzDecompress(f *testing.F) {
+	small := &pb.Locations{Locations: []*pb.Location{{
+		Name: "square",
+		Polygons: []*pb.Polygon{{
+			Points: []*pb.Point{{Lng: -71.5, Lat: 41.5}, {Lng: -71.4, Lat: 41.5}, {Lng: -71.4, Lat: 41.6}, {Lng: -71.5, Lat: 41.5}},
+			Holes: []*pb.Polygon{{
+				Points: []*pb.Point{{Lng: -71.45, Lat: 41.52}, {Lng: -71.42, Lat: 41.52}, {Lng: -71.42, Lat: 41.55}, {Lng: -71.45, Lat: 41.52}},
+			}},
+		}},
+	}}}
+	for _, method := range []pb.CompressMethod{pb.CompressMethod_Polyline, pb.CompressMethod_DeltaVarint} {
+		compressed, err := reduce.Compress(small, method)
+		if err != nil {
+			f.Fatal(err)
+		}
+		data, _ := proto.Marshal(compressed)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		compressed := &pb.CompressedLocations{}
+		if err := proto.Unmarshal(data, compressed); err != nil {
+			return
+		}
+		output, err := reduce.Decompress(compressed)
+		if err != nil {
+			return
+		}
+		checkDecompressed(t, output)
+	})
+}
+
+func FuzzDecompressedPolylineBytesToPoints(f *testing.F) {
+	f.Add(reduce.CompressedPointsToPolylineBytes([]*pb.Point{{Lng: -71.5, Lat: 41.5}, {Lng: -71.4, Lat: 41.6}}))
+	f.Add([]byte("_p~iF~ps|U_ulLnnqC_mqNvxq`@"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		points, err := reduce.DecompressedPolylineBytesToPoints(data)
+		if err != nil && points != nil {
+			t.Errorf("got points %v with error %v", points, err)
+		}
+	})
+}
+
+func FuzzDeltaVarintBytesToPoints(f *testing.F) {
+	f.Add(reduce.PointsToDeltaVarintBytes([]*pb.Point{{Lng: -71.5, Lat: 41.5}, {Lng: -71.4, Lat: 41.6}}, reduce.DefaultScale))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		points, err := reduce.DeltaVarintBytesToPoints(data, reduce.DefaultScale)
+		if err != nil {
+			return
+		}
+		// every point takes at least 2 bytes
+		if len(points) > len(data)/2 {
+			t.Errorf("got %v points from %v bytes", len(points), len(data))
+		}
+	})
+}
+
+func TestVerify(t *testing.T) {
+	input := loadLite(t)
+	for _, method := range []pb.CompressMethod{pb.CompressMethod_Polyline, pb.CompressMethod_DeltaVarint} {
+		v, err := reduce.Verify(input, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.OK() || v.MaxDrift == 0 {
+			t.Errorf("%v: got %+v", method, v)
+		}
+	}
+}
+
+func TestCompareLocations(t *testing.T) {
+	input := loadLite(t)
+	output := proto.Clone(input).(*pb.Locations)
+	output.Locations[0], output.Locations[1] = output.Locations[1], output.Locations[0]
+	output.Locations[2].Polygons[0].Points[0].Lng += 0.1
+	for _, location := range output.Locations {
+		if len(location.Polygons[0].Holes) > 0 {
+			location.Polygons[0].Holes = nil
+			break
+		}
+	}
+
+	v := reduce.CompareLocations(input, output, reduce.Precision(pb.CompressMethod_Polyline, 0))
+	if v.OK() || len(v.Differences) != 3 {
+		t.Fatalf("got %q", v.Differences)
+	}
+	if v.MaxDrift < 0.09 || !strings.HasPrefix(v.MaxDriftAt, "location 2 ") {
+		t.Errorf("got drift %v at %v", v.MaxDrift, v.MaxDriftAt)
+	}
+}
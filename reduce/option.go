@@ -0,0 +1,113 @@
+package reduce
+
+import "github.com/deslittle/pinpoint/pb"
+
+type Option struct {
+	// Tolerance is simplification tolerance in degree, default is [DefaultTolerance].
+	Tolerance float64
+	// Algorithm is how rings are simplified, default is [DouglasPeucker].
+	Algorithm Algorithm
+	// AreaReference is area in square degree of a location reduced with full
+	// Tolerance, smaller locations use a smaller tolerance. 0 means disabled.
+	AreaReference float64
+	// VertexBudget is max points of a location, each location uses the
+	// smallest tolerance fits it. Overrides AreaReference, 0 means disabled.
+	VertexBudget int
+	// MaxError is max Hausdorff distance in meters between a location and
+	// its reduced one, tolerance is lowered per location to keep under it.
+	// 0 means no limit.
+	MaxError float64
+	// Report is called for every location with its [Quality] after [Do].
+	Report func(quality *Quality)
+	// Topology makes borders shared by neighbor locations reduced once,
+	// see [github.com/deslittle/pinpoint/topology].
+	Topology bool
+	// Scale is [pb.CompressMethod_DeltaVarint]'s multiplier, default is [DefaultScale].
+	Scale float64
+	// RingCompression is applied to every compressed ring.
+	RingCompression pb.RingCompression
+}
+
+type OptionFunc = func(opt *Option)
+
+func newOption(opts ...OptionFunc) *Option {
+	opt := &Option{
+		Tolerance: DefaultTolerance,
+		Scale:     DefaultScale,
+	}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+	return opt
+}
+
+// SetTolerance sets simplification tolerance in degree.
+func SetTolerance(tolerance float64) OptionFunc {
+	return func(opt *Option) {
+		opt.Tolerance = tolerance
+	}
+}
+
+// SetTopology makes [Do] keep borders shared by neighbor locations seamless.
+func SetTopology(opt *Option) {
+	opt.Topology = true
+}
+
+// SetAlgorithm sets line simplification algorithm, an unknown one is ignored.
+// Use [ParseAlgorithm] to check algorithm's name from users.
+func SetAlgorithm(algorithm Algorithm) OptionFunc {
+	return func(opt *Option) {
+		if _, ok := algorithmNames[algorithm]; ok {
+			opt.Algorithm = algorithm
+		}
+	}
+}
+
+// SetAreaAdaptive scales tolerance by location's size, a location smaller
+// than reference square degree uses tolerance*sqrt(area/reference), so small
+// locations like islands keep their shape.
+func SetAreaAdaptive(reference float64) OptionFunc {
+	return func(opt *Option) {
+		opt.AreaReference = reference
+	}
+}
+
+// SetVertexBudget picks tolerance per location, the smallest one reducing
+// location to at most budget points.
+func SetVertexBudget(budget int) OptionFunc {
+	return func(opt *Option) {
+		opt.VertexBudget = budget
+	}
+}
+
+// SetMaxError sets max Hausdorff distance in meters a location could change.
+//
+// Skip, precise and minist are not changed, so a location could still exceed
+// it, [Quality.Exceeded] reports it.
+func SetMaxError(meters float64) OptionFunc {
+	return func(opt *Option) {
+		opt.MaxError = meters
+	}
+}
+
+// SetReport sets a func called with each location's [Quality], in input order.
+func SetReport(fn func(quality *Quality)) OptionFunc {
+	return func(opt *Option) {
+		opt.Report = fn
+	}
+}
+
+// SetScale sets what coordinates multiplied by before rounding to integers by
+// [pb.CompressMethod_DeltaVarint], e.g. 1e7 keeps float32 precise.
+func SetScale(scale float64) OptionFunc {
+	return func(opt *Option) {
+		opt.Scale = scale
+	}
+}
+
+// SetRingCompression sets general-purpose compression of every compressed ring.
+func SetRingCompression(method pb.RingCompression) OptionFunc {
+	return func(opt *Option) {
+		opt.RingCompression = method
+	}
+}
@@ -0,0 +1,242 @@
+package reduce
+
+import (
+	"math"
+
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+// Quality is how much accuracy a location lost by reducing.
+type Quality struct {
+	Name         string `json:"name"`
+	InputPoints  int    `json:"inputPoints"`
+	OutputPoints int    `json:"outputPoints"`
+	// VertexReduction is the fraction of points removed, 0.9 means 90% removed.
+	VertexReduction float64 `json:"vertexReduction"`
+	// Hausdorff is the max distance in meters from a vertex of one side to the
+	// other side's rings, both directions checked. It's [math.MaxFloat64] if
+	// only one side has no points.
+	Hausdorff float64 `json:"hausdorff"`
+	// InputArea and OutputArea are geodesic areas in square meters, holes excluded.
+	InputArea  float64 `json:"inputArea"`
+	OutputArea float64 `json:"outputArea"`
+	// AreaChange is (OutputArea-InputArea)/InputArea.
+	AreaChange float64 `json:"areaChange"`
+	// Tolerance is the simplification tolerance in degree used by [Do].
+	Tolerance float64 `json:"tolerance"`
+	// Exceeded is true if Hausdorff is larger than [Option.MaxError] even
+	// without simplification, e.g. because of skip or precise.
+	Exceeded bool `json:"exceeded,omitempty"`
+}
+
+// Compare measures quality of each location between input and output, which
+// must have the same locations in the same order like [Do]'s result.
+func Compare(input, output *pb.Locations) []*Quality {
+	ret := make([]*Quality, 0, len(input.Locations))
+	for i, location := range input.Locations {
+		ret = append(ret, compareLocation(location, output.Locations[i]))
+	}
+	return ret
+}
+
+func compareLocation(input, output *pb.Location) *Quality {
+	q := &Quality{
+		Name:         input.Name,
+		InputPoints:  locationPoints(input),
+		OutputPoints: locationPoints(output),
+		Hausdorff:    Hausdorff(input, output),
+		InputArea:    geodesicArea(input),
+		OutputArea:   geodesicArea(output),
+	}
+	if q.InputPoints > 0 {
+		q.VertexReduction = 1 - float64(q.OutputPoints)/float64(q.InputPoints)
+	}
+	if q.InputArea > 0 {
+		q.AreaChange = (q.OutputArea - q.InputArea) / q.InputArea
+	}
+	return q
+}
+
+func geodesicArea(location *pb.Location) float64 {
+	area := 0.0
+	for _, polygon := range location.Polygons {
+		area += geo.Area(toRing(polygon.Points))
+		for _, hole := range polygon.Holes {
+			area -= geo.Area(toRing(hole.Points))
+		}
+	}
+	return area
+}
+
+func eachRing(location *pb.Location, fn func(points []*pb.Point)) {
+	for _, polygon := range location.Polygons {
+		fn(polygon.Points)
+		for _, hole := range polygon.Holes {
+			fn(hole.Points)
+		}
+	}
+}
+
+// Hausdorff returns the discrete Hausdorff distance in meters between two
+// locations' rings, see [Quality.Hausdorff].
+func Hausdorff(a, b *pb.Location) float64 {
+	proj := newProjection(a)
+	d := directedHausdorff(proj, a, b)
+	if d == math.MaxFloat64 {
+		return d
+	}
+	return math.Max(d, directedHausdorff(proj, b, a))
+}
+
+// directedHausdorff returns max distance from a's vertices to b's rings.
+func directedHausdorff(proj projection, a, b *pb.Location) float64 {
+	grid := newSegmentGrid(proj, b)
+	max := 0.0
+	eachRing(a, func(points []*pb.Point) {
+		for _, p := range points {
+			max = math.Max(max, grid.distance(proj.project(p)))
+		}
+	})
+	return max
+}
+
+// projection is an equirectangular projection to meters at a location's
+// mean latitude, good enough to measure distances between close points.
+type projection struct {
+	scaleX float64
+}
+
+const metersPerDegree = orb.EarthRadius * math.Pi / 180
+
+func newProjection(location *pb.Location) projection {
+	sum, n := 0.0, 0
+	eachRing(location, func(points []*pb.Point) {
+		for _, p := range points {
+			sum += float64(p.Lat)
+			n++
+		}
+	})
+	if n == 0 {
+		return projection{scaleX: metersPerDegree}
+	}
+	return projection{scaleX: metersPerDegree * math.Cos(sum/float64(n)*math.Pi/180)}
+}
+
+func (p projection) project(point *pb.Point) orb.Point {
+	return orb.Point{float64(point.Lng) * p.scaleX, float64(point.Lat) * metersPerDegree}
+}
+
+type segment [2]orb.Point
+
+func (s segment) distance(p orb.Point) float64 {
+	a, b := s[0], s[1]
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = ((p[0]-a[0])*dx + (p[1]-a[1])*dy) / l
+		t = math.Max(0, math.Min(1, t))
+	}
+	return math.Hypot(p[0]-a[0]-t*dx, p[1]-a[1]-t*dy)
+}
+
+// segmentGrid buckets segments into square cells for nearest segment queries.
+type segmentGrid struct {
+	size        float64
+	min         orb.Point
+	maxX, maxY  int
+	cells       map[[2]int][]segment
+	hasSegments bool
+}
+
+func newSegmentGrid(proj projection, location *pb.Location) *segmentGrid {
+	segments := []segment{}
+	bound := orb.Bound{Min: orb.Point{math.Inf(1), math.Inf(1)}, Max: orb.Point{math.Inf(-1), math.Inf(-1)}}
+	eachRing(location, func(points []*pb.Point) {
+		for i, p := range points {
+			a := proj.project(p)
+			b := a
+			if i+1 < len(points) {
+				b = proj.project(points[i+1])
+			} else if len(points) > 2 {
+				continue
+			}
+			segments = append(segments, segment{a, b})
+			bound = bound.Extend(a).Extend(b)
+		}
+	})
+	g := &segmentGrid{cells: map[[2]int][]segment{}, hasSegments: len(segments) > 0}
+	if !g.hasSegments {
+		return g
+	}
+	// about one segment per cell along rings
+	g.size = math.Max(bound.Right()-bound.Left(), bound.Top()-bound.Bottom()) / math.Sqrt(float64(len(segments)))
+	if g.size == 0 {
+		g.size = 1
+	}
+	g.min = bound.Min
+	for _, s := range segments {
+		x0, y0 := g.cell(orb.Point{math.Min(s[0][0], s[1][0]), math.Min(s[0][1], s[1][1])})
+		x1, y1 := g.cell(orb.Point{math.Max(s[0][0], s[1][0]), math.Max(s[0][1], s[1][1])})
+		for x := x0; x <= x1; x++ {
+			for y := y0; y <= y1; y++ {
+				g.cells[[2]int{x, y}] = append(g.cells[[2]int{x, y}], s)
+			}
+		}
+		if x1 > g.maxX {
+			g.maxX = x1
+		}
+		if y1 > g.maxY {
+			g.maxY = y1
+		}
+	}
+	return g
+}
+
+func (g *segmentGrid) cell(p orb.Point) (int, int) {
+	return int(math.Floor((p[0] - g.min[0]) / g.size)), int(math.Floor((p[1] - g.min[1]) / g.size))
+}
+
+// distance returns distance from p to the nearest segment.
+func (g *segmentGrid) distance(p orb.Point) float64 {
+	if !g.hasSegments {
+		return math.MaxFloat64
+	}
+	cx, cy := g.cell(p)
+	best := math.Inf(1)
+	visit := func(x, y int) {
+		for _, s := range g.cells[[2]int{x, y}] {
+			best = math.Min(best, s.distance(p))
+		}
+	}
+	// cells closer than r0 are out of grid
+	r0 := 0
+	for _, d := range []int{-cx, -cy, cx - g.maxX, cy - g.maxY} {
+		if d > r0 {
+			r0 = d
+		}
+	}
+	for r := r0; ; r++ {
+		if r == 0 {
+			visit(cx, cy)
+		} else {
+			for i := -r; i <= r; i++ {
+				visit(cx+i, cy-r)
+				visit(cx+i, cy+r)
+			}
+			for i := -r + 1; i < r; i++ {
+				visit(cx-r, cy+i)
+				visit(cx+r, cy+i)
+			}
+		}
+		// unvisited cells are at least r cells away
+		if best <= float64(r)*g.size {
+			return best
+		}
+		// every cell visited
+		if cx-r <= 0 && cy-r <= 0 && cx+r >= g.maxX && cy+r >= g.maxY {
+			return best
+		}
+	}
+}
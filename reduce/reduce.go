@@ -2,22 +2,29 @@
 package reduce
 
 import (
+	"math"
+
 	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/topology"
 	"github.com/paulmach/orb"
-	"github.com/paulmach/orb/simplify"
+	"github.com/paulmach/orb/geo"
 )
 
-func ReducePoints(points []*pb.Point) []*pb.Point {
-	if len(points) == 0 {
-		return points
-	}
-	original := orb.LineString{}
+// DefaultTolerance is the simplification tolerance in degree used by
+// [ReducePoints] and [Do] if not set.
+const DefaultTolerance = 0.001
+
+func toLineString(points []*pb.Point) orb.LineString {
+	ret := make(orb.LineString, 0, len(points))
 	for _, point := range points {
-		original = append(original, orb.Point{float64(point.Lng), float64(point.Lat)})
+		ret = append(ret, orb.Point{float64(point.Lng), float64(point.Lat)})
 	}
-	reduced := simplify.DouglasPeucker(0.001).Simplify(original.Clone()).(orb.LineString)
-	res := make([]*pb.Point, 0)
-	for _, orbPoint := range reduced {
+	return ret
+}
+
+func fromLineString(ls orb.LineString) []*pb.Point {
+	res := make([]*pb.Point, 0, len(ls))
+	for _, orbPoint := range ls {
 		res = append(res, &pb.Point{
 			Lng: float32(orbPoint.Lon()),
 			Lat: float32(orbPoint.Lat()),
@@ -26,25 +33,260 @@ func ReducePoints(points []*pb.Point) []*pb.Point {
 	return res
 }
 
-func Do(input *pb.Locations, skip int, precise float64, minist float64) *pb.Locations {
-	output := &pb.Locations{}
-	for _, location := range input.Locations {
-		reducedLocation := &pb.Location{
-			Name: location.Name,
+func ReducePoints(points []*pb.Point) []*pb.Point {
+	return SimplifyPoints(points, DefaultTolerance)
+}
+
+// SimplifyPoints simplify points by Douglas-Peucker algorithm, tolerance is in degree.
+func SimplifyPoints(points []*pb.Point, tolerance float64) []*pb.Point {
+	return SimplifyPointsWith(points, DouglasPeucker, tolerance)
+}
+
+// SkipPoints keeps one point of every skip points, begin&end point are
+// always kept. No point skipped if skip <= 1.
+func SkipPoints(points []*pb.Point, skip int) []*pb.Point {
+	if skip <= 1 || len(points) <= 2 {
+		return points
+	}
+	res := make([]*pb.Point, 0, len(points)/skip+2)
+	for i := 0; i < len(points)-1; i += skip {
+		res = append(res, points[i])
+	}
+	return append(res, points[len(points)-1])
+}
+
+// RoundPoints rounds coordinates to 1/precise, e.g. 10000 means 4 decimals,
+// then drops repeated points. No rounding if precise <= 0.
+func RoundPoints(points []*pb.Point, precise float64) []*pb.Point {
+	if precise <= 0 || len(points) == 0 {
+		return points
+	}
+	round := func(v float32) float32 {
+		return float32(math.Round(float64(v)*precise) / precise)
+	}
+	res := make([]*pb.Point, 0, len(points))
+	for _, point := range points {
+		newPoint := &pb.Point{Lng: round(point.Lng), Lat: round(point.Lat)}
+		if len(res) > 0 {
+			last := res[len(res)-1]
+			if last.Lng == newPoint.Lng && last.Lat == newPoint.Lat {
+				continue
+			}
+		}
+		res = append(res, newPoint)
+	}
+	return res
+}
+
+// FilterMinDistance drops points closer than minist meters to previous kept
+// point, except begin&end point. No point dropped if minist <= 0.
+func FilterMinDistance(points []*pb.Point, minist float64) []*pb.Point {
+	if minist <= 0 || len(points) <= 2 {
+		return points
+	}
+	ls := toLineString(points)
+	res := []*pb.Point{points[0]}
+	last := ls[0]
+	for i := 1; i < len(points)-1; i++ {
+		if geo.Distance(last, ls[i]) < minist {
+			continue
 		}
-		for _, polygon := range location.Polygons {
-			newPoly := &pb.Polygon{
-				Points: ReducePoints(polygon.Points),
-				Holes:  make([]*pb.Polygon, 0),
+		res = append(res, points[i])
+		last = ls[i]
+	}
+	return append(res, points[len(points)-1])
+}
+
+// ReduceRing reduce a ring's points by steps:
+//
+//  1. [SkipPoints] by skip
+//  2. [FilterMinDistance] by minist
+//  3. [SimplifyPoints] by Douglas-Peucker with tolerance
+//  4. [RoundPoints] by precise
+func ReduceRing(points []*pb.Point, skip int, precise float64, minist float64, tolerance float64) []*pb.Point {
+	return reduceRing(points, skip, precise, minist, DouglasPeucker, tolerance)
+}
+
+func reduceRing(points []*pb.Point, skip int, precise float64, minist float64, algorithm Algorithm, tolerance float64) []*pb.Point {
+	points = SkipPoints(points, skip)
+	points = FilterMinDistance(points, minist)
+	points = SimplifyPointsWith(points, algorithm, tolerance)
+	return RoundPoints(points, precise)
+}
+
+// minRingDistinctPoints is the least distinct points of a reduced ring,
+// fewer is a line or a point which finders reject.
+const minRingDistinctPoints = 3
+
+func distinctPoints(points []*pb.Point) int {
+	seen := make(map[[2]float32]bool, len(points))
+	for _, point := range points {
+		seen[[2]float32{point.Lng, point.Lat}] = true
+	}
+	return len(seen)
+}
+
+// dropCollapsed drops location's rings collapsed to a line or a point, like a
+// small hole skipped and rounded away, a collapsed polygon is dropped with its
+// holes.
+func dropCollapsed(location *pb.Location) {
+	polygons := make([]*pb.Polygon, 0, len(location.Polygons))
+	for _, polygon := range location.Polygons {
+		if distinctPoints(polygon.Points) < minRingDistinctPoints {
+			continue
+		}
+		holes := make([]*pb.Polygon, 0, len(polygon.Holes))
+		for _, hole := range polygon.Holes {
+			if distinctPoints(hole.Points) >= minRingDistinctPoints {
+				holes = append(holes, hole)
 			}
-			for _, hole := range polygon.Holes {
-				newPoly.Holes = append(newPoly.Holes, &pb.Polygon{
-					Points: ReducePoints(hole.Points),
-				})
+		}
+		polygon.Holes = holes
+		polygons = append(polygons, polygon)
+	}
+	location.Polygons = polygons
+}
+
+// keepLargest keeps original's largest polygon unreduced if all reduced's
+// polygons have collapsed, so no location is lost.
+func keepLargest(original, reduced *pb.Location) {
+	if len(reduced.Polygons) > 0 || len(original.Polygons) == 0 {
+		return
+	}
+	largest := original.Polygons[0]
+	for _, polygon := range original.Polygons[1:] {
+		if len(polygon.Points) > len(largest.Points) {
+			largest = polygon
+		}
+	}
+	reduced.Polygons = []*pb.Polygon{largest}
+}
+
+func reduceLocation(location *pb.Location, fn func(points []*pb.Point) []*pb.Point) *pb.Location {
+	reducedLocation := &pb.Location{
+		Name: location.Name,
+		Id:   location.Id,
+	}
+	for _, polygon := range location.Polygons {
+		newPoly := &pb.Polygon{
+			Points: fn(polygon.Points),
+			Holes:  make([]*pb.Polygon, 0),
+		}
+		for _, hole := range polygon.Holes {
+			newPoly.Holes = append(newPoly.Holes, &pb.Polygon{
+				Points: fn(hole.Points),
+			})
+		}
+		reducedLocation.Polygons = append(reducedLocation.Polygons, newPoly)
+	}
+	dropCollapsed(reducedLocation)
+	return reducedLocation
+}
+
+func locationPoints(location *pb.Location) int {
+	count := 0
+	for _, polygon := range location.Polygons {
+		count += len(polygon.Points)
+		for _, hole := range polygon.Holes {
+			count += len(hole.Points)
+		}
+	}
+	return count
+}
+
+// locationTolerance returns tolerance for location by opt's adaptive mode,
+// lowered to keep under opt.MaxError.
+func locationTolerance(location *pb.Location, skip int, precise float64, minist float64, opt *Option) float64 {
+	reduceAt := func(tolerance float64) *pb.Location {
+		return reduceLocation(location, func(points []*pb.Point) []*pb.Point {
+			return reduceRing(points, skip, precise, minist, opt.Algorithm, tolerance)
+		})
+	}
+
+	var tolerance float64
+	switch {
+	case opt.VertexBudget > 0:
+		tolerance = budgetTolerance(opt.VertexBudget, func(tolerance float64) int {
+			return locationPoints(reduceAt(tolerance))
+		})
+	case opt.AreaReference > 0:
+		tolerance = areaTolerance(location, opt.Tolerance, opt.AreaReference)
+	default:
+		tolerance = opt.Tolerance
+	}
+
+	if opt.MaxError > 0 {
+		tolerance = errorTolerance(tolerance, opt.MaxError, func(tolerance float64) float64 {
+			return Hausdorff(location, reduceAt(tolerance))
+		})
+	}
+	return tolerance
+}
+
+// Do reduce all locations' rings, see [ReduceRing] for how skip, precise and
+// minist work. Rings are simplified by [Option.Algorithm] with a tolerance
+// picked per location if [SetAreaAdaptive] or [SetVertexBudget] is used.
+//
+// A ring collapsed to a line or a point is dropped, with its holes if it's a
+// polygon's, a location whose polygons all collapse keeps its largest polygon
+// unreduced.
+//
+// Rings are reduced independently by default, so borders shared by neighbor
+// locations may no longer match. Use [SetTopology] to reduce each shared
+// border once and keep neighbors seamless, a shared border uses the smallest
+// tolerance of its locations. Joined rings are not checked against MaxError
+// again, the smaller tolerance only moves a shared border less.
+//
+// Use [SetMaxError] to bound how far a location could move, and [SetReport]
+// to get each location's [Quality]. Input's metadata is copied to output,
+// steps are left to callers.
+func Do(input *pb.Locations, skip int, precise float64, minist float64, opts ...OptionFunc) *pb.Locations {
+	opt := newOption(opts...)
+	tolerances := make([]float64, len(input.Locations))
+	for i, location := range input.Locations {
+		tolerances[i] = locationTolerance(location, skip, precise, minist, opt)
+	}
+
+	if opt.Topology {
+		t := topology.Build(input)
+		owners := t.ArcLocations()
+		t.SimplifyEach(func(arc int, points []*pb.Point) []*pb.Point {
+			tolerance := math.Inf(1)
+			for _, i := range owners[arc] {
+				tolerance = math.Min(tolerance, tolerances[i])
 			}
-			reducedLocation.Polygons = append(reducedLocation.Polygons, newPoly)
+			return reduceRing(points, skip, precise, minist, opt.Algorithm, tolerance)
+		})
+		output := t.ToLocations()
+		for i, location := range output.Locations {
+			dropCollapsed(location)
+			keepLargest(input.Locations[i], location)
 		}
-		output.Locations = append(output.Locations, reducedLocation)
+		output.Metadata = pb.CopyMetadata(input.Metadata, pb.EncodingFloat32)
+		report(input, output, tolerances, opt)
+		return output
+	}
+
+	output := &pb.Locations{Metadata: pb.CopyMetadata(input.Metadata, pb.EncodingFloat32)}
+	for i, location := range input.Locations {
+		tolerance := tolerances[i]
+		reduced := reduceLocation(location, func(points []*pb.Point) []*pb.Point {
+			return reduceRing(points, skip, precise, minist, opt.Algorithm, tolerance)
+		})
+		keepLargest(location, reduced)
+		output.Locations = append(output.Locations, reduced)
 	}
+	report(input, output, tolerances, opt)
 	return output
 }
+
+func report(input, output *pb.Locations, tolerances []float64, opt *Option) {
+	if opt.Report == nil {
+		return
+	}
+	for i, quality := range Compare(input, output) {
+		quality.Tolerance = tolerances[i]
+		quality.Exceeded = opt.MaxError > 0 && quality.Hausdorff > opt.MaxError
+		opt.Report(quality)
+	}
+}
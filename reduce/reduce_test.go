@@ -0,0 +1,299 @@
+package reduce_test
+
+import (
+	"testing"
+
+	usstates "github.com/deslittle/pinpoint-us-states"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/reduce"
+	"google.golang.org/protobuf/proto"
+)
+
+func loadFull(t testing.TB) *pb.Locations {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.FullData, input); err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+func findLocation(t testing.TB, input *pb.Locations, name string) *pb.Locations {
+	for _, location := range input.Locations {
+		if location.Name == name {
+			return &pb.Locations{Locations: []*pb.Location{location}}
+		}
+	}
+	t.Fatalf("location=%v not found", name)
+	return nil
+}
+
+func countPoints(input *pb.Locations) int {
+	count := 0
+	for _, location := range input.Locations {
+		for _, polygon := range location.Polygons {
+			count += len(polygon.Points)
+			for _, hole := range polygon.Holes {
+				count += len(hole.Points)
+			}
+		}
+	}
+	return count
+}
+
+func TestDoParams(t *testing.T) {
+	input := findLocation(t, loadFull(t), "44")
+	noTolerance := reduce.SetTolerance(0)
+
+	cases := []struct {
+		name    string
+		skip    int
+		precise float64
+		minist  float64
+		opts    []reduce.OptionFunc
+		expect  int
+	}{
+		{"nothing", 1, 0, 0, []reduce.OptionFunc{noTolerance}, 2447},
+		{"default tolerance", 1, 0, 0, nil, 119},
+		{"tolerance", 1, 0, 0, []reduce.OptionFunc{reduce.SetTolerance(0.01)}, 34},
+		{"skip", 5, 0, 0, []reduce.OptionFunc{noTolerance}, 521},
+		{"precise", 1, 100, 0, []reduce.OptionFunc{noTolerance}, 306},
+		{"minist", 1, 0, 500, []reduce.OptionFunc{noTolerance}, 402},
+		{"all", 5, 10000, 10, nil, 91},
+	}
+	for _, c := range cases {
+		output := reduce.Do(input, c.skip, c.precise, c.minist, c.opts...)
+		if got := countPoints(output); got != c.expect {
+			t.Errorf("%v: got %v points, want %v", c.name, got, c.expect)
+		}
+	}
+}
+
+func line(n int) []*pb.Point {
+	ret := []*pb.Point{}
+	for i := 0; i < n; i++ {
+		ret = append(ret, &pb.Point{Lng: float32(i) * 0.00005, Lat: 0})
+	}
+	return ret
+}
+
+func TestSkipPoints(t *testing.T) {
+	points := line(11)
+	got := reduce.SkipPoints(points, 3)
+	// 0, 3, 6, 9 and end point 10
+	if len(got) != 5 || got[0] != points[0] || got[4] != points[10] {
+		t.Errorf("got %v", got)
+	}
+	if len(reduce.SkipPoints(points, 1)) != 11 {
+		t.Error("skip=1 should keep all points")
+	}
+}
+
+func TestRoundPoints(t *testing.T) {
+	points := []*pb.Point{{Lng: 0}, {Lng: 0.0004}, {Lng: 0.0006}, {Lng: 0.0014}, {Lng: 0.0021}}
+	got := reduce.RoundPoints(points, 1000)
+	// 0, 0, 0.001, 0.001, 0.002 with repeated points dropped
+	expect := []float32{0, 0.001, 0.002}
+	if len(got) != len(expect) {
+		t.Fatalf("got %v", got)
+	}
+	for i := range expect {
+		if got[i].Lng != expect[i] {
+			t.Errorf("got %v at %v, want %v", got[i].Lng, i, expect[i])
+		}
+	}
+}
+
+func TestFilterMinDistance(t *testing.T) {
+	points := line(11)
+	// ~5.5 meters between points
+	got := reduce.FilterMinDistance(points, 10)
+	if len(got) != 6 || got[0] != points[0] || got[5] != points[10] {
+		t.Errorf("got %v", got)
+	}
+}
+
+type point [2]float32
+
+func pointSet(location *pb.Location) map[point]bool {
+	ret := map[point]bool{}
+	for _, polygon := range location.Polygons {
+		for _, p := range polygon.Points {
+			ret[point{p.Lng, p.Lat}] = true
+		}
+		for _, hole := range polygon.Holes {
+			for _, p := range hole.Points {
+				ret[point{p.Lng, p.Lat}] = true
+			}
+		}
+	}
+	return ret
+}
+
+// borderMismatch counts points on the border shared by a and b in input,
+// which kept in only one side of output.
+func borderMismatch(input, output *pb.Locations, a, b int) int {
+	shared := map[point]bool{}
+	inB := pointSet(input.Locations[b])
+	for p := range pointSet(input.Locations[a]) {
+		if inB[p] {
+			shared[p] = true
+		}
+	}
+	outA, outB := pointSet(output.Locations[a]), pointSet(output.Locations[b])
+	mismatch := 0
+	for p := range shared {
+		if outA[p] != outB[p] {
+			mismatch++
+		}
+	}
+	return mismatch
+}
+
+func TestDoTopology(t *testing.T) {
+	full := loadFull(t)
+	input := &pb.Locations{}
+	for _, name := range []string{"44", "25", "09"} {
+		input.Locations = append(input.Locations, findLocation(t, full, name).Locations...)
+	}
+
+	independent := reduce.Do(input, 5, 0, 10)
+	if borderMismatch(input, independent, 0, 1) == 0 {
+		t.Error("expect independent reduce breaks shared border")
+	}
+
+	output := reduce.Do(input, 5, 0, 10, reduce.SetTopology)
+	for _, pair := range [][2]int{{0, 1}, {0, 2}, {1, 2}} {
+		if n := borderMismatch(input, output, pair[0], pair[1]); n != 0 {
+			t.Errorf("%v and %v: %v shared border points kept only at one side", input.Locations[pair[0]].Name, input.Locations[pair[1]].Name, n)
+		}
+	}
+	if got, all := countPoints(output), countPoints(input); got >= all/5 {
+		t.Errorf("expect reduced, got %v points from %v", got, all)
+	}
+}
+
+func TestDoAlgorithms(t *testing.T) {
+	input := findLocation(t, loadFull(t), "44")
+
+	cases := []struct {
+		algorithm reduce.Algorithm
+		expect    int
+	}{
+		{reduce.DouglasPeucker, 119},
+		{reduce.VisvalingamWhyatt, 229},
+		{reduce.RadialDistance, 994},
+	}
+	for _, c := range cases {
+		output := reduce.Do(input, 1, 0, 0, reduce.SetAlgorithm(c.algorithm))
+		if got := countPoints(output); got != c.expect {
+			t.Errorf("%v: got %v points, want %v", c.algorithm, got, c.expect)
+		}
+	}
+	if got := countPoints(reduce.Do(input, 1, 0, 0, reduce.SetAlgorithm(reduce.Algorithm(100)))); got != cases[0].expect {
+		t.Errorf("unknown algorithm: got %v points, want %v of default", got, cases[0].expect)
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for _, a := range []reduce.Algorithm{reduce.DouglasPeucker, reduce.VisvalingamWhyatt, reduce.RadialDistance} {
+		got, err := reduce.ParseAlgorithm(a.String())
+		if err != nil || got != a {
+			t.Errorf("%v: got %v, %v", a, got, err)
+		}
+	}
+	if _, err := reduce.ParseAlgorithm("unknown"); err == nil {
+		t.Error("expect error")
+	}
+}
+
+func TestDoAreaAdaptive(t *testing.T) {
+	full := loadFull(t)
+	small := findLocation(t, full, "44")
+	large := findLocation(t, full, "36")
+	reference := reduce.LocationArea(large.Locations[0])
+
+	// large location keeps full tolerance
+	if got, want := countPoints(reduce.Do(large, 1, 0, 0, reduce.SetAreaAdaptive(reference))), countPoints(reduce.Do(large, 1, 0, 0)); got != want {
+		t.Errorf("large: got %v points, want %v", got, want)
+	}
+	// small location keeps more points
+	if got, fixed := countPoints(reduce.Do(small, 1, 0, 0, reduce.SetAreaAdaptive(reference))), countPoints(reduce.Do(small, 1, 0, 0)); got <= fixed {
+		t.Errorf("small: got %v points, want more than %v", got, fixed)
+	}
+}
+
+func TestDoVertexBudget(t *testing.T) {
+	full := loadFull(t)
+	input := &pb.Locations{}
+	for _, name := range []string{"44", "25", "09"} {
+		input.Locations = append(input.Locations, findLocation(t, full, name).Locations...)
+	}
+	for _, algorithm := range []reduce.Algorithm{reduce.DouglasPeucker, reduce.VisvalingamWhyatt, reduce.RadialDistance} {
+		output := reduce.Do(input, 1, 0, 0, reduce.SetAlgorithm(algorithm), reduce.SetVertexBudget(200))
+		for _, location := range output.Locations {
+			got := countPoints(&pb.Locations{Locations: []*pb.Location{location}})
+			if got > 200 || got < 100 {
+				t.Errorf("%v %v: got %v points, want close to budget 200", algorithm, location.Name, got)
+			}
+		}
+	}
+
+	output := reduce.Do(input, 1, 0, 0, reduce.SetVertexBudget(200), reduce.SetTopology)
+	for _, pair := range [][2]int{{0, 1}, {0, 2}, {1, 2}} {
+		if n := borderMismatch(input, output, pair[0], pair[1]); n != 0 {
+			t.Errorf("%v and %v: %v shared border points kept only at one side", input.Locations[pair[0]].Name, input.Locations[pair[1]].Name, n)
+		}
+	}
+}
+
+func square(x, y, size float32) *pb.Location {
+	return &pb.Location{Polygons: []*pb.Polygon{{Points: []*pb.Point{
+		{Lng: x, Lat: y}, {Lng: x + size, Lat: y}, {Lng: x + size, Lat: y + size}, {Lng: x, Lat: y + size}, {Lng: x, Lat: y},
+	}}}}
+}
+
+func TestHausdorff(t *testing.T) {
+	a := square(0, 0, 1)
+	if got := reduce.Hausdorff(a, a); got != 0 {
+		t.Errorf("same: got %v", got)
+	}
+	// 0.01 degree at equator is about 1113 meters
+	got := reduce.Hausdorff(a, square(0, 0.01, 1))
+	if got < 1100 || got > 1120 {
+		t.Errorf("shifted: got %v", got)
+	}
+}
+
+func TestDoReport(t *testing.T) {
+	input := findLocation(t, loadFull(t), "44")
+
+	var quality *reduce.Quality
+	output := reduce.Do(input, 1, 0, 0, reduce.SetReport(func(q *reduce.Quality) { quality = q }))
+	if quality == nil || quality.Name != "44" {
+		t.Fatalf("got %+v", quality)
+	}
+	if quality.InputPoints != countPoints(input) || quality.OutputPoints != countPoints(output) {
+		t.Errorf("got %v -> %v points", quality.InputPoints, quality.OutputPoints)
+	}
+	// tolerance 0.001 degree is about 110 meters
+	if quality.Hausdorff <= 0 || quality.Hausdorff > 120 {
+		t.Errorf("got hausdorff %v", quality.Hausdorff)
+	}
+	if quality.AreaChange == 0 || quality.AreaChange > 0.01 || quality.AreaChange < -0.01 {
+		t.Errorf("got area change %v", quality.AreaChange)
+	}
+
+	output = reduce.Do(input, 1, 0, 0, reduce.SetMaxError(20), reduce.SetReport(func(q *reduce.Quality) { quality = q }))
+	if quality.Exceeded || quality.Hausdorff > 20 {
+		t.Errorf("got hausdorff %v, want at most 20", quality.Hausdorff)
+	}
+	if quality.Tolerance >= reduce.DefaultTolerance || countPoints(output) <= 119 {
+		t.Errorf("expect smaller tolerance, got %v with %v points", quality.Tolerance, countPoints(output))
+	}
+
+	// rounding to 0.1 degree moves points more than any tolerance could fix
+	reduce.Do(input, 1, 10, 0, reduce.SetMaxError(20), reduce.SetReport(func(q *reduce.Quality) { quality = q }))
+	if !quality.Exceeded {
+		t.Errorf("expect exceeded, got %+v", quality)
+	}
+}
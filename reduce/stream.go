@@ -0,0 +1,106 @@
+package reduce
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/twpayne/go-polyline"
+)
+
+// polylineCodec is Polyline's default codec, same as [polyline.DecodeCoords].
+var polylineCodec = polyline.Codec{Dim: 2, Scale: 1e5}
+
+// minRingPoints is the least points of a decompressed ring, published data
+// has closed rings with 3 points.
+const minRingPoints = 3
+
+// validateRing checks a decompressed ring has enough points and every point
+// is a valid longitude/latitude.
+func validateRing(coords []float64) error {
+	if len(coords)/2 < minRingPoints {
+		return fmt.Errorf("ring has %d points, need at least %d", len(coords)/2, minRingPoints)
+	}
+	for i := 0; i < len(coords); i += 2 {
+		lng, lat := coords[i], coords[i+1]
+		if math.IsNaN(lng) || math.IsNaN(lat) || lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+			return fmt.Errorf("point %d (%v, %v) out of range", i/2, lng, lat)
+		}
+	}
+	return nil
+}
+
+// appendRingCoords decodes a ring compressed by method and appends its
+// coordinates to dst as lng, lat pairs, rounded to float32 like [pb.Point].
+func appendRingCoords(dst []float64, input *pb.CompressedLocations, method pb.CompressMethod, data []byte) ([]float64, error) {
+	start := len(dst)
+	var err error
+	switch method {
+	case pb.CompressMethod_Polyline:
+		dst, _, err = polylineCodec.DecodeFlatCoords(dst, data)
+	case pb.CompressMethod_DeltaVarint:
+		data, err = decompressRing(data, input.RingCompression)
+		if err == nil {
+			dst, err = appendDeltaVarintCoords(dst, data, input.Scale)
+		}
+	default:
+		err = fmt.Errorf("pinpoint/reduce: unknown method %v", method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := start; i < len(dst); i++ {
+		dst[i] = float64(float32(dst[i]))
+	}
+	return dst, nil
+}
+
+// DecompressRings decodes every ring of input in order without building
+// [pb.Locations], and calls fn with ring's coordinates as lng, lat pairs.
+// Ring 0 is the polygon's exterior ring, others are holes. coords is reused
+// after fn returns.
+//
+// Every ring must have at least 3 points with valid longitude and latitude,
+// errors name the location, polygon and ring. Error returned by fn stops
+// decoding and is returned as is.
+func DecompressRings(input *pb.CompressedLocations, fn func(location, polygon, ring int, coords []float64) error) error {
+	return decompressRings(input, input.Method, fn)
+}
+
+func decompressRings(input *pb.CompressedLocations, method pb.CompressMethod, fn func(location, polygon, ring int, coords []float64) error) error {
+	switch method {
+	case pb.CompressMethod_Polyline:
+	case pb.CompressMethod_DeltaVarint:
+		if input.Scale <= 0 {
+			return fmt.Errorf("pinpoint/reduce: invalid scale %v", input.Scale)
+		}
+	default:
+		return fmt.Errorf("pinpoint/reduce: unknown method %v", method)
+	}
+
+	var coords []float64
+	for i, location := range input.Locations {
+		decodeRing := func(data []byte, polygon int, ring int) error {
+			var err error
+			coords, err = appendRingCoords(coords[:0], input, method, data)
+			if err == nil {
+				err = validateRing(coords)
+			}
+			if err != nil {
+				return fmt.Errorf("pinpoint/reduce: location %d %q polygon %d ring %d: %w", i, location.Name, polygon, ring, err)
+			}
+			return fn(i, polygon, ring, coords)
+		}
+		for j, polygon := range location.Data {
+			if err := decodeRing(polygon.Points, j, 0); err != nil {
+				return err
+			}
+			for k, hole := range polygon.Holes {
+				if err := decodeRing(hole.Points, j, k+1); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package reduce
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// DefaultScale is [pb.CompressMethod_DeltaVarint]'s default scale, 5
+// decimals like Polyline.
+const DefaultScale = 1e5
+
+var errTruncated = errors.New("pinpoint/reduce: truncated varint")
+
+// maxRingBytes limits a deflated ring's inflated size, about 3M points of the
+// largest deltas, corrupt or crafted data could inflate to GBs.
+const maxRingBytes = 64 << 20
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// PointsToDeltaVarintBytes encodes points as deltas of coordinates multiplied
+// by scale, each delta is a zigzag varint, lng before lat.
+func PointsToDeltaVarintBytes(points []*pb.Point, scale float64) []byte {
+	ret := make([]byte, 0, len(points)*4)
+	var lastLng, lastLat int64
+	for _, point := range points {
+		lng := int64(math.Round(float64(point.Lng) * scale))
+		lat := int64(math.Round(float64(point.Lat) * scale))
+		ret = binary.AppendUvarint(ret, zigzag(lng-lastLng))
+		ret = binary.AppendUvarint(ret, zigzag(lat-lastLat))
+		lastLng, lastLat = lng, lat
+	}
+	return ret
+}
+
+// DeltaVarintBytesToPoints decodes bytes from [PointsToDeltaVarintBytes].
+func DeltaVarintBytesToPoints(input []byte, scale float64) ([]*pb.Point, error) {
+	coords, err := appendDeltaVarintCoords(nil, input, scale)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*pb.Point, 0, len(coords)/2)
+	for i := 0; i < len(coords); i += 2 {
+		ret = append(ret, &pb.Point{Lng: float32(coords[i]), Lat: float32(coords[i+1])})
+	}
+	return ret, nil
+}
+
+// appendDeltaVarintCoords decodes input and appends coordinates to dst as
+// lng, lat pairs.
+func appendDeltaVarintCoords(dst []float64, input []byte, scale float64) ([]float64, error) {
+	var last [2]int64
+	for len(input) > 0 {
+		for i := range last {
+			v, n := binary.Uvarint(input)
+			if n <= 0 {
+				return nil, errTruncated
+			}
+			last[i] += unzigzag(v)
+			input = input[n:]
+			dst = append(dst, float64(last[i])/scale)
+		}
+	}
+	return dst, nil
+}
+
+func compressRing(input []byte, method pb.RingCompression) ([]byte, error) {
+	switch method {
+	case pb.RingCompression_NoRingCompression:
+		return input, nil
+	case pb.RingCompression_Deflate:
+		buf := &bytes.Buffer{}
+		w, err := flate.NewWriter(buf, flate.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(input); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("pinpoint/reduce: unknown ring compression %v", method)
+	}
+}
+
+func decompressRing(input []byte, method pb.RingCompression) ([]byte, error) {
+	switch method {
+	case pb.RingCompression_NoRingCompression:
+		return input, nil
+	case pb.RingCompression_Deflate:
+		r := flate.NewReader(bytes.NewReader(input))
+		defer r.Close()
+		ret, err := io.ReadAll(io.LimitReader(r, maxRingBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("pinpoint/reduce: inflate ring: %w", err)
+		}
+		if len(ret) > maxRingBytes {
+			return nil, fmt.Errorf("pinpoint/reduce: inflated ring exceeds %d bytes", maxRingBytes)
+		}
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("pinpoint/reduce: unknown ring compression %v", method)
+	}
+}
+
+func CompressWithDeltaVarint(input *pb.Locations, scale float64, ringCompression pb.RingCompression) (*pb.CompressedLocations, error) {
+	if scale <= 0 {
+		return nil, fmt.Errorf("pinpoint/reduce: invalid scale %v", scale)
+	}
+	encode := func(points []*pb.Point) ([]byte, error) {
+		return compressRing(PointsToDeltaVarintBytes(points, scale), ringCompression)
+	}
+	output := &pb.CompressedLocations{
+		Method:          pb.CompressMethod_DeltaVarint,
+		Scale:           scale,
+		RingCompression: ringCompression,
+		Metadata:        pb.CopyMetadata(input.Metadata, pb.EncodingDeltaVarint),
+	}
+	for _, location := range input.Locations {
+		reducedLocation := &pb.CompressedLocation{
+			Name: location.Name,
+			Id:   location.Id,
+		}
+		for _, polygon := range location.Polygons {
+			points, err := encode(polygon.Points)
+			if err != nil {
+				return nil, err
+			}
+			newPoly := &pb.CompressedPolygon{
+				Points: points,
+				Holes:  make([]*pb.CompressedPolygon, 0),
+			}
+			for _, hole := range polygon.Holes {
+				points, err := encode(hole.Points)
+				if err != nil {
+					return nil, err
+				}
+				newPoly.Holes = append(newPoly.Holes, &pb.CompressedPolygon{
+					Points: points,
+				})
+			}
+			reducedLocation.Data = append(reducedLocation.Data, newPoly)
+		}
+		output.Locations = append(output.Locations, reducedLocation)
+	}
+	return output, nil
+}
+
+func DecompressWithDeltaVarint(input *pb.CompressedLocations) (*pb.Locations, error) {
+	return decompressLocations(input, pb.CompressMethod_DeltaVarint)
+}
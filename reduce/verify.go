@@ -0,0 +1,115 @@
+package reduce
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// float32HalfUlp is half of float32's spacing for coordinates in [128, 256),
+// the largest rounding error of a longitude stored in [pb.Point].
+const float32HalfUlp = 1.0 / (1 << 17)
+
+// Precision returns max coordinate drift in degree expected from compress
+// method with scale, scale is ignored by [pb.CompressMethod_Polyline].
+func Precision(method pb.CompressMethod, scale float64) float64 {
+	if method == pb.CompressMethod_Polyline {
+		scale = 1e5
+	}
+	return 0.5/scale + float32HalfUlp
+}
+
+// Verification is the difference between locations and their compression
+// round trip.
+type Verification struct {
+	// Precision is max drift allowed in degree.
+	Precision float64
+	// MaxDrift is max coordinate difference in degree of matched points.
+	MaxDrift float64
+	// MaxDriftAt names where MaxDrift is, empty if no drift.
+	MaxDriftAt string
+	// Differences are structural differences, like missing holes or
+	// reordered locations.
+	Differences []string
+}
+
+// OK is true if there is no structural difference and drift is in precision.
+func (v *Verification) OK() bool {
+	return len(v.Differences) == 0 && v.MaxDrift <= v.Precision
+}
+
+func (v *Verification) differ(format string, args ...interface{}) {
+	v.Differences = append(v.Differences, fmt.Sprintf(format, args...))
+}
+
+// CompareLocations compares every ring of got with expect, drift is
+// compared with precision in degree.
+func CompareLocations(expect, got *pb.Locations, precision float64) *Verification {
+	v := &Verification{Precision: precision}
+	if len(expect.Locations) != len(got.Locations) {
+		v.differ("got %d locations, want %d", len(got.Locations), len(expect.Locations))
+	}
+	for i := 0; i < len(expect.Locations) && i < len(got.Locations); i++ {
+		v.compareLocation(i, expect.Locations[i], got.Locations[i])
+	}
+	return v
+}
+
+func (v *Verification) compareLocation(i int, expect, got *pb.Location) {
+	where := fmt.Sprintf("location %d %q", i, expect.Name)
+	if expect.Name != got.Name {
+		v.differ("%v: got name %q", where, got.Name)
+		return
+	}
+	if expect.Id != got.Id {
+		v.differ("%v: got id %q, want %q", where, got.Id, expect.Id)
+	}
+	if len(expect.Polygons) != len(got.Polygons) {
+		v.differ("%v: got %d polygons, want %d", where, len(got.Polygons), len(expect.Polygons))
+		return
+	}
+	for j, polygon := range expect.Polygons {
+		other := got.Polygons[j]
+		v.compareRing(fmt.Sprintf("%v polygon %d ring 0", where, j), polygon.Points, other.Points)
+		if len(polygon.Holes) != len(other.Holes) {
+			v.differ("%v polygon %d: got %d holes, want %d", where, j, len(other.Holes), len(polygon.Holes))
+			continue
+		}
+		for k, hole := range polygon.Holes {
+			v.compareRing(fmt.Sprintf("%v polygon %d ring %d", where, j, k+1), hole.Points, other.Holes[k].Points)
+		}
+	}
+}
+
+func (v *Verification) compareRing(where string, expect, got []*pb.Point) {
+	if len(expect) != len(got) {
+		v.differ("%v: got %d points, want %d", where, len(got), len(expect))
+		return
+	}
+	for i, point := range expect {
+		drift := math.Max(
+			math.Abs(float64(point.Lng)-float64(got[i].Lng)),
+			math.Abs(float64(point.Lat)-float64(got[i].Lat)),
+		)
+		if drift > v.MaxDrift {
+			v.MaxDrift = drift
+			v.MaxDriftAt = fmt.Sprintf("%v point %d", where, i)
+		}
+	}
+}
+
+// Verify compresses input by method, decompresses it back and compares every
+// ring with input within the method's [Precision].
+func Verify(input *pb.Locations, method pb.CompressMethod, opts ...OptionFunc) (*Verification, error) {
+	opt := newOption(opts...)
+	compressed, err := Compress(input, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := Decompress(compressed)
+	if err != nil {
+		return nil, err
+	}
+	return CompareLocations(input, output, Precision(method, opt.Scale)), nil
+}
@@ -0,0 +1,370 @@
+// Package topology splits locations' rings into arcs shared between neighbors.
+//
+// Neighbor locations, like states, share borders. When each ring is processed
+// on its own, e.g. simplified, shared borders are changed differently on each
+// side, which leaves gaps and overlaps. [Build] detects junctions, points where
+// rings stop sharing a border, cuts rings into arcs at them and deduplicates
+// arcs, so every shared border is stored once, like TopoJSON.
+package topology
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// Topology is locations whose rings reference shared arcs.
+type Topology struct {
+	// Arcs are deduplicated arcs. Arcs from a ring without junction are
+	// closed, first point equals to last one.
+	Arcs      [][]*pb.Point
+	Locations []*Location
+}
+
+type Location struct {
+	Name     string
+	ID       string
+	Polygons []*Polygon
+}
+
+// Polygon's first ring is the exterior ring, others are holes.
+type Polygon struct {
+	Rings []*Ring
+}
+
+// Ring is a sequence of arc references.
+//
+// Like TopoJSON, reference i means Arcs[i] and ^i means Arcs[i] reversed.
+type Ring struct {
+	Arcs []int
+	// Closed is true if original ring's last point equals to first point.
+	Closed bool
+}
+
+type point [2]float32
+
+func key(p *pb.Point) point {
+	return point{p.Lng, p.Lat}
+}
+
+func less(a, b point) bool {
+	if a[0] != b[0] {
+		return a[0] < b[0]
+	}
+	return a[1] < b[1]
+}
+
+// openRing returns ring's points without the repeated last point.
+func openRing(points []*pb.Point) ([]*pb.Point, bool) {
+	if len(points) > 1 && key(points[0]) == key(points[len(points)-1]) {
+		return points[:len(points)-1], true
+	}
+	return points, false
+}
+
+func allRings(input *pb.Locations, fn func(points []*pb.Point)) {
+	for _, location := range input.Locations {
+		for _, polygon := range location.Polygons {
+			fn(polygon.Points)
+			for _, hole := range polygon.Holes {
+				fn(hole.Points)
+			}
+		}
+	}
+}
+
+// findJunctions returns points which have different neighbors in rings.
+func findJunctions(input *pb.Locations) map[point]bool {
+	junctions := map[point]bool{}
+	neighbors := map[point][2]point{}
+	allRings(input, func(points []*pb.Point) {
+		ring, _ := openRing(points)
+		n := len(ring)
+		for i, p := range ring {
+			a, b := key(ring[(i+n-1)%n]), key(ring[(i+1)%n])
+			if less(b, a) {
+				a, b = b, a
+			}
+			k := key(p)
+			pair, ok := neighbors[k]
+			if !ok {
+				neighbors[k] = [2]point{a, b}
+				continue
+			}
+			if pair != [2]point{a, b} {
+				junctions[k] = true
+			}
+		}
+	})
+	return junctions
+}
+
+// splitRing cuts ring into arcs at junctions, arcs include both end points.
+func splitRing(ring []*pb.Point, junctions map[point]bool) ([][]*pb.Point, bool) {
+	first := -1
+	for i, p := range ring {
+		if junctions[key(p)] {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		closed := make([]*pb.Point, 0, len(ring)+1)
+		closed = append(closed, ring...)
+		return [][]*pb.Point{append(closed, ring[0])}, true
+	}
+
+	n := len(ring)
+	arcs := [][]*pb.Point{}
+	current := []*pb.Point{ring[first]}
+	for i := 1; i <= n; i++ {
+		p := ring[(first+i)%n]
+		current = append(current, p)
+		if junctions[key(p)] {
+			arcs = append(arcs, current)
+			current = []*pb.Point{p}
+		}
+	}
+	return arcs, false
+}
+
+func reversed(points []*pb.Point) []*pb.Point {
+	ret := make([]*pb.Point, len(points))
+	for i, p := range points {
+		ret[len(points)-1-i] = p
+	}
+	return ret
+}
+
+// rotateClosed rotates a closed arc to start at its smallest point.
+func rotateClosed(arc []*pb.Point) []*pb.Point {
+	ring := arc[:len(arc)-1]
+	min := 0
+	for i, p := range ring {
+		if less(key(p), key(ring[min])) {
+			min = i
+		}
+	}
+	ret := make([]*pb.Point, 0, len(arc))
+	ret = append(ret, ring[min:]...)
+	ret = append(ret, ring[:min]...)
+	return append(ret, ring[min])
+}
+
+func arcKey(points []*pb.Point) string {
+	b := make([]byte, 0, len(points)*8)
+	for _, p := range points {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(p.Lng))
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(p.Lat))
+	}
+	return string(b)
+}
+
+// Build splits locations' rings into deduplicated arcs.
+func Build(input *pb.Locations) *Topology {
+	junctions := findJunctions(input)
+	t := &Topology{}
+	arcIndex := map[string]int{}
+
+	addArc := func(arc []*pb.Point, closed bool) int {
+		forward := arc
+		if closed {
+			forward = rotateClosed(arc)
+		}
+		backward := reversed(forward)
+		if closed {
+			backward = rotateClosed(backward)
+		}
+		fkey, bkey := arcKey(forward), arcKey(backward)
+		if i, ok := arcIndex[fkey]; ok {
+			return i
+		}
+		if i, ok := arcIndex[bkey]; ok {
+			return ^i
+		}
+		arcIndex[fkey] = len(t.Arcs)
+		t.Arcs = append(t.Arcs, forward)
+		return len(t.Arcs) - 1
+	}
+
+	toRing := func(points []*pb.Point) *Ring {
+		ring, closed := openRing(points)
+		ret := &Ring{Closed: closed}
+		if len(ring) == 0 {
+			return ret
+		}
+		arcs, closedArc := splitRing(ring, junctions)
+		for _, arc := range arcs {
+			ret.Arcs = append(ret.Arcs, addArc(arc, closedArc))
+		}
+		return ret
+	}
+
+	for _, location := range input.Locations {
+		newLocation := &Location{Name: location.Name, ID: location.Id}
+		for _, polygon := range location.Polygons {
+			newPolygon := &Polygon{Rings: []*Ring{toRing(polygon.Points)}}
+			for _, hole := range polygon.Holes {
+				newPolygon.Rings = append(newPolygon.Rings, toRing(hole.Points))
+			}
+			newLocation.Polygons = append(newLocation.Polygons, newPolygon)
+		}
+		t.Locations = append(t.Locations, newLocation)
+	}
+	return t
+}
+
+// ArcPoints returns points of an arc reference, reversed if ref is negative.
+func (t *Topology) ArcPoints(ref int) []*pb.Point {
+	if ref < 0 {
+		return reversed(t.Arcs[^ref])
+	}
+	return t.Arcs[ref]
+}
+
+// RingPoints joins arcs of a ring back to points.
+func (t *Topology) RingPoints(ring *Ring) []*pb.Point {
+	return t.ringPoints(ring, t.Arcs)
+}
+
+func (t *Topology) ringPoints(ring *Ring, arcs [][]*pb.Point) []*pb.Point {
+	ret := []*pb.Point{}
+	for i, ref := range ring.Arcs {
+		var points []*pb.Point
+		if ref < 0 {
+			points = reversed(arcs[^ref])
+		} else {
+			points = arcs[ref]
+		}
+		if i > 0 && len(points) > 0 {
+			points = points[1:]
+		}
+		ret = append(ret, points...)
+	}
+	// joined arcs always end at start point
+	if !ring.Closed && len(ret) > 1 {
+		ret = ret[:len(ret)-1]
+	}
+	return ret
+}
+
+// minRingPoints is how many points a valid ring needs, 4 for a closed one.
+func minRingPoints(ring *Ring) int {
+	if ring.Closed {
+		return 4
+	}
+	return 3
+}
+
+// Simplify replaces every arc with fn's result. fn must keep arc's first and
+// last point so neighbors still meet at junctions.
+//
+// If a ring would collapse to less than a valid ring's points, its arcs are
+// kept unchanged, for both this ring and its neighbors.
+func (t *Topology) Simplify(fn func(points []*pb.Point) []*pb.Point) {
+	t.SimplifyEach(func(_ int, points []*pb.Point) []*pb.Point {
+		return fn(points)
+	})
+}
+
+// SimplifyEach is like [Topology.Simplify] but fn also gets arc's index, e.g.
+// to pick a tolerance by [Topology.ArcLocations].
+func (t *Topology) SimplifyEach(fn func(arc int, points []*pb.Point) []*pb.Point) {
+	simplified := make([][]*pb.Point, len(t.Arcs))
+	for i, arc := range t.Arcs {
+		simplified[i] = fn(i, arc)
+	}
+
+	for changed := true; changed; {
+		changed = false
+		t.eachRing(func(ring *Ring) {
+			original := t.ringPoints(ring, t.Arcs)
+			if len(t.ringPoints(ring, simplified)) >= minRingPoints(ring) || len(original) < minRingPoints(ring) {
+				return
+			}
+			for _, ref := range ring.Arcs {
+				if ref < 0 {
+					ref = ^ref
+				}
+				if len(simplified[ref]) != len(t.Arcs[ref]) {
+					simplified[ref] = t.Arcs[ref]
+					changed = true
+				}
+			}
+		})
+	}
+	t.Arcs = simplified
+}
+
+func (t *Topology) eachRing(fn func(ring *Ring)) {
+	for _, location := range t.Locations {
+		for _, polygon := range location.Polygons {
+			for _, ring := range polygon.Rings {
+				fn(ring)
+			}
+		}
+	}
+}
+
+// ArcLocations returns indexes of locations referencing each arc.
+func (t *Topology) ArcLocations() [][]int {
+	owners := make([][]int, len(t.Arcs))
+	for i, location := range t.Locations {
+		for _, polygon := range location.Polygons {
+			for _, ring := range polygon.Rings {
+				for _, ref := range ring.Arcs {
+					if ref < 0 {
+						ref = ^ref
+					}
+					if n := len(owners[ref]); n == 0 || owners[ref][n-1] != i {
+						owners[ref] = append(owners[ref], i)
+					}
+				}
+			}
+		}
+	}
+	return owners
+}
+
+// SharedArcs returns how many arcs are referenced by more than one ring.
+func (t *Topology) SharedArcs() int {
+	refs := make([]int, len(t.Arcs))
+	t.eachRing(func(ring *Ring) {
+		for _, ref := range ring.Arcs {
+			if ref < 0 {
+				ref = ^ref
+			}
+			refs[ref]++
+		}
+	})
+	count := 0
+	for _, n := range refs {
+		if n > 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// ToLocations joins arcs back to locations.
+func (t *Topology) ToLocations() *pb.Locations {
+	output := &pb.Locations{}
+	for _, location := range t.Locations {
+		newLocation := &pb.Location{Name: location.Name, Id: location.ID}
+		for _, polygon := range location.Polygons {
+			newPoly := &pb.Polygon{
+				Points: t.RingPoints(polygon.Rings[0]),
+				Holes:  make([]*pb.Polygon, 0),
+			}
+			for _, hole := range polygon.Rings[1:] {
+				newPoly.Holes = append(newPoly.Holes, &pb.Polygon{
+					Points: t.RingPoints(hole),
+				})
+			}
+			newLocation.Polygons = append(newLocation.Polygons, newPoly)
+		}
+		output.Locations = append(output.Locations, newLocation)
+	}
+	return output
+}
@@ -0,0 +1,145 @@
+package topology_test
+
+import (
+	"testing"
+
+	usstates "github.com/deslittle/pinpoint-us-states"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/topology"
+	"google.golang.org/protobuf/proto"
+)
+
+func ring(coords ...[2]float32) []*pb.Point {
+	ret := []*pb.Point{}
+	for _, c := range coords {
+		ret = append(ret, &pb.Point{Lng: c[0], Lat: c[1]})
+	}
+	return ret
+}
+
+// twoSquares returns two unit squares share the border x=1.
+func twoSquares() *pb.Locations {
+	return &pb.Locations{
+		Locations: []*pb.Location{
+			{
+				Name: "west",
+				Polygons: []*pb.Polygon{{
+					Points: ring([2]float32{0, 0}, [2]float32{1, 0}, [2]float32{1, 0.5}, [2]float32{1, 1}, [2]float32{0, 1}, [2]float32{0, 0}),
+				}},
+			},
+			{
+				Name: "east",
+				Polygons: []*pb.Polygon{{
+					Points: ring([2]float32{1, 0}, [2]float32{2, 0}, [2]float32{2, 1}, [2]float32{1, 1}, [2]float32{1, 0.5}, [2]float32{1, 0}),
+				}},
+			},
+		},
+	}
+}
+
+// sameRing checks if two closed rings have same points, maybe start at different point.
+func sameRing(a, b []*pb.Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if len(a) == 0 {
+		return true
+	}
+	n := len(a) - 1
+	for shift := 0; shift < n; shift++ {
+		ok := true
+		for i := 0; i < n; i++ {
+			p, q := a[i], b[(i+shift)%n]
+			if p.Lng != q.Lng || p.Lat != q.Lat {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sameLocations(t *testing.T, a, b *pb.Locations) {
+	t.Helper()
+	if len(a.Locations) != len(b.Locations) {
+		t.Fatalf("got %v locations, want %v", len(b.Locations), len(a.Locations))
+	}
+	for i, location := range a.Locations {
+		other := b.Locations[i]
+		if location.Name != other.Name || len(location.Polygons) != len(other.Polygons) {
+			t.Fatalf("location %v not match %v", location.Name, other.Name)
+		}
+		for j, polygon := range location.Polygons {
+			if !sameRing(polygon.Points, other.Polygons[j].Points) {
+				t.Errorf("location %v polygon %v not match", location.Name, j)
+			}
+			for k, hole := range polygon.Holes {
+				if !sameRing(hole.Points, other.Polygons[j].Holes[k].Points) {
+					t.Errorf("location %v polygon %v hole %v not match", location.Name, j, k)
+				}
+			}
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	input := twoSquares()
+	top := topology.Build(input)
+	if len(top.Arcs) != 3 {
+		t.Errorf("got %v arcs, want 3", len(top.Arcs))
+	}
+	if shared := top.SharedArcs(); shared != 1 {
+		t.Errorf("got %v shared arcs, want 1", shared)
+	}
+	sameLocations(t, input, top.ToLocations())
+}
+
+func TestSimplifyKeepSharedBorder(t *testing.T) {
+	input := twoSquares()
+	top := topology.Build(input)
+	// drop the middle point of shared border
+	top.Simplify(func(points []*pb.Point) []*pb.Point {
+		ret := []*pb.Point{}
+		for _, p := range points {
+			if p.Lat != 0.5 {
+				ret = append(ret, p)
+			}
+		}
+		return ret
+	})
+	output := top.ToLocations()
+	for _, location := range output.Locations {
+		for _, p := range location.Polygons[0].Points {
+			if p.Lng == 1 && p.Lat == 0.5 {
+				t.Errorf("%v: shared border's inner point should be dropped on both sides", location.Name)
+			}
+		}
+		if n := len(location.Polygons[0].Points); n != 5 {
+			t.Errorf("%v: got %v points, want 5", location.Name, n)
+		}
+	}
+}
+
+func TestSimplifyKeepValidRing(t *testing.T) {
+	input := twoSquares()
+	top := topology.Build(input)
+	top.Simplify(func(points []*pb.Point) []*pb.Point {
+		return points[:1]
+	})
+	sameLocations(t, input, top.ToLocations())
+}
+
+func TestBuildRoundTrip(t *testing.T) {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.LiteData, input); err != nil {
+		t.Fatal(err)
+	}
+	top := topology.Build(input)
+	if top.SharedArcs() == 0 {
+		t.Error("expect neighbor states share arcs")
+	}
+	sameLocations(t, input, top.ToLocations())
+}
@@ -0,0 +1,34 @@
+package validate
+
+type Option struct {
+	// Kinds are kinds of issues checked and repaired, default is [Kinds].
+	Kinds []Kind
+}
+
+type OptionFunc = func(opt *Option)
+
+func newOption(opts ...OptionFunc) *Option {
+	opt := &Option{
+		Kinds: Kinds,
+	}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+	return opt
+}
+
+// SetKinds sets kinds of issues checked and repaired.
+func SetKinds(kinds ...Kind) OptionFunc {
+	return func(opt *Option) {
+		opt.Kinds = kinds
+	}
+}
+
+func (opt *Option) has(kind Kind) bool {
+	for _, k := range opt.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
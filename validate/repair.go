@@ -0,0 +1,268 @@
+package validate
+
+import (
+	"math"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// Repair repairs every location's rings in place and returns issues found,
+// see [RepairLocation].
+func Repair(input *pb.Locations, opts ...OptionFunc) []*Issue {
+	opt := newOption(opts...)
+	issues := make([]*Issue, 0)
+	for i, location := range input.Locations {
+		issues = append(issues, repairLocation(i, location, opt)...)
+	}
+	return issues
+}
+
+// RepairLocation repairs location's rings in place and returns issues found
+// like [CheckLocation], all of them are repaired:
+//
+//   - Unclosed rings are closed.
+//   - TooFewPoints and ZeroArea rings are dropped, an exterior ring is
+//     dropped with its holes.
+//   - Winding rings are reversed.
+//   - SelfIntersection rings are split into simple rings at crossings and
+//     spikes are removed. A piece of an exterior ring inside another piece,
+//     like a keyhole, becomes a hole.
+//
+// Only kinds of [SetKinds] are checked and repaired, consecutive duplicate
+// points of repaired rings are removed.
+func RepairLocation(index int, location *pb.Location, opts ...OptionFunc) []*Issue {
+	return repairLocation(index, location, newOption(opts...))
+}
+
+func repairLocation(index int, location *pb.Location, opt *Option) []*Issue {
+	issues := make([]*Issue, 0)
+	report := func(found []*Issue, polygon, ring int) {
+		for _, issue := range found {
+			issue.Location = index
+			issue.Name = location.Name
+			issue.Polygon = polygon
+			issue.Ring = ring
+			issue.Repaired = true
+			issues = append(issues, issue)
+		}
+	}
+	polygons := make([]*pb.Polygon, 0, len(location.Polygons))
+	for i, polygon := range location.Polygons {
+		found := checkRing(polygon.Points, false, opt)
+		changed := len(found) > 0
+		report(found, i, 0)
+		for j, hole := range polygon.Holes {
+			found := checkRing(hole.Points, true, opt)
+			changed = changed || len(found) > 0
+			report(found, i, j+1)
+		}
+		if !changed {
+			polygons = append(polygons, polygon)
+			continue
+		}
+
+		exteriors, closed := repairRing(polygon.Points, false, opt)
+		newPolygons := nest(exteriors, closed, opt)
+		for _, hole := range polygon.Holes {
+			pieces, closed := repairRing(hole.Points, true, opt)
+			for _, piece := range pieces {
+				if owner := smallestContaining(newPolygons, piece); owner != nil {
+					owner.Holes = append(owner.Holes, &pb.Polygon{Points: toPB(piece, closed)})
+				}
+			}
+		}
+		polygons = append(polygons, newPolygons...)
+	}
+	location.Polygons = polygons
+	return issues
+}
+
+// repairRing returns pieces of repaired ring in open form, and if pieces
+// should be closed.
+func repairRing(points []*pb.Point, hole bool, opt *Option) ([][]point, bool) {
+	ring, closed := openRing(points)
+	closed = closed || opt.has(Unclosed)
+	if len(ring) < 3 {
+		if opt.has(TooFewPoints) {
+			return nil, closed
+		}
+		return [][]point{ring}, closed
+	}
+	if signedArea(ring) == 0 {
+		if opt.has(ZeroArea) {
+			return nil, closed
+		}
+		return [][]point{ring}, closed
+	}
+	pieces := [][]point{ring}
+	if opt.has(SelfIntersection) {
+		pieces = split(ring)
+	}
+	if opt.has(Winding) {
+		for _, piece := range pieces {
+			if hole != (signedArea(piece) < 0) {
+				reverse(piece)
+			}
+		}
+	}
+	return pieces, closed
+}
+
+// split splits an open ring into rings without self-intersection, pieces
+// with zero area are dropped.
+func split(ring []point) [][]point {
+	pieces := make([][]point, 0, 1)
+	stack := [][]point{ring}
+	for len(stack) > 0 {
+		ring := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for len(ring) >= 3 {
+			i, ok := findSpike(ring)
+			if !ok {
+				break
+			}
+			ring = dedupe(append(ring[:i:i], ring[i+1:]...))
+		}
+		if len(ring) < 3 || signedArea(ring) == 0 {
+			continue
+		}
+		x, ok := findIntersection(ring)
+		if !ok {
+			pieces = append(pieces, ring)
+			continue
+		}
+		// both pieces are shorter than ring, so it ends
+		at := toPoint(x.at.toPB())
+		loop := append([]point{at}, ring[x.i+1:x.j+1]...)
+		rest := append(append(append([]point{}, ring[:x.i+1]...), at), ring[x.j+1:]...)
+		stack = append(stack, dedupe(loop), dedupe(rest))
+	}
+	return pieces
+}
+
+// dedupe removes consecutive duplicates of an open ring.
+func dedupe(ring []point) []point {
+	ret := ring[:0:0]
+	for _, p := range ring {
+		if len(ret) == 0 || ret[len(ret)-1] != p {
+			ret = append(ret, p)
+		}
+	}
+	for len(ret) > 1 && ret[0] == ret[len(ret)-1] {
+		ret = ret[:len(ret)-1]
+	}
+	return ret
+}
+
+func reverse(ring []point) {
+	for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+		ring[i], ring[j] = ring[j], ring[i]
+	}
+}
+
+func toPB(ring []point, closed bool) []*pb.Point {
+	points := make([]*pb.Point, 0, len(ring)+1)
+	for _, p := range ring {
+		points = append(points, p.toPB())
+	}
+	if closed && len(ring) > 0 {
+		points = append(points, ring[0].toPB())
+	}
+	return points
+}
+
+// locate returns 1 if p is inside ring, 0 if on its boundary and -1 if
+// outside.
+func locate(p point, ring []point) int {
+	inside := false
+	for i, a := range ring {
+		b := ring[(i+1)%len(ring)]
+		if orient(a, b, p) == 0 && onSegment(a, b, p) {
+			return 0
+		}
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	if inside {
+		return 1
+	}
+	return -1
+}
+
+// contains reports if ring inner is inside ring outer, rings don't cross.
+func contains(outer, inner []point) bool {
+	for _, p := range inner {
+		if r := locate(p, outer); r != 0 {
+			return r > 0
+		}
+	}
+	return false
+}
+
+// nest makes pieces polygons, a piece inside an odd number of others is a
+// hole of the smallest one.
+func nest(pieces [][]point, closed bool, opt *Option) []*pb.Polygon {
+	if len(pieces) == 1 {
+		return []*pb.Polygon{{Points: toPB(pieces[0], closed), Holes: make([]*pb.Polygon, 0)}}
+	}
+	areas := make([]float64, len(pieces))
+	for i, piece := range pieces {
+		areas[i] = math.Abs(signedArea(piece))
+	}
+	depths := make([]int, len(pieces))
+	parents := make([]int, len(pieces))
+	for i, piece := range pieces {
+		parents[i] = -1
+		for j, other := range pieces {
+			if areas[j] <= areas[i] || !contains(other, piece) {
+				continue
+			}
+			depths[i]++
+			if parents[i] < 0 || areas[j] < areas[parents[i]] {
+				parents[i] = j
+			}
+		}
+	}
+	polygons := make([]*pb.Polygon, len(pieces))
+	ret := make([]*pb.Polygon, 0, len(pieces))
+	for i, piece := range pieces {
+		if depths[i]%2 == 0 {
+			polygons[i] = &pb.Polygon{Points: toPB(piece, closed), Holes: make([]*pb.Polygon, 0)}
+			ret = append(ret, polygons[i])
+		}
+	}
+	for i, piece := range pieces {
+		if depths[i]%2 == 0 {
+			continue
+		}
+		if opt.has(Winding) {
+			reverse(piece)
+		}
+		if parent := polygons[parents[i]]; parent != nil {
+			parent.Holes = append(parent.Holes, &pb.Polygon{Points: toPB(piece, closed)})
+		}
+	}
+	return ret
+}
+
+// smallestContaining returns the polygon with smallest area whose exterior
+// ring contains ring, the first one if none, nil if polygons is empty.
+func smallestContaining(polygons []*pb.Polygon, ring []point) *pb.Polygon {
+	if len(polygons) == 1 {
+		return polygons[0]
+	}
+	var ret *pb.Polygon
+	retArea := 0.0
+	for _, polygon := range polygons {
+		exterior, _ := openRing(polygon.Points)
+		area := math.Abs(signedArea(exterior))
+		if (ret == nil || area < retArea) && contains(exterior, ring) {
+			ret, retArea = polygon, area
+		}
+	}
+	if ret == nil && len(polygons) > 0 {
+		return polygons[0]
+	}
+	return ret
+}
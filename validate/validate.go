@@ -0,0 +1,307 @@
+// Package validate checks locations' rings against pb.Polygon's definition
+// and repairs them.
+//
+// A ring must be closed, have 4 or more positions, follow the right-hand
+// rule, exterior rings counterclockwise and holes clockwise, and must not
+// cross or touch itself. Finders don't reject bad rings, but a ring crossing
+// itself makes ContainsPoint wrong around the crossing.
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deslittle/pinpoint/pb"
+)
+
+// Kind is a kind of ring issue.
+type Kind int
+
+const (
+	// Unclosed is a ring whose first and last points differ.
+	Unclosed Kind = iota + 1
+	// TooFewPoints is a ring with less than 4 positions, closing one
+	// included.
+	TooFewPoints
+	// ZeroArea is a ring whose points are all on a line.
+	ZeroArea
+	// Winding is a clockwise exterior ring or a counterclockwise hole.
+	Winding
+	// SelfIntersection is a ring crossing or touching itself, like a bow-tie
+	// or a spike going back on itself.
+	SelfIntersection
+)
+
+// Kinds are all kinds of issues.
+var Kinds = []Kind{Unclosed, TooFewPoints, ZeroArea, Winding, SelfIntersection}
+
+var kindNames = map[Kind]string{
+	Unclosed:         "unclosed",
+	TooFewPoints:     "too-few-points",
+	ZeroArea:         "zero-area",
+	Winding:          "winding",
+	SelfIntersection: "self-intersection",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// ParseKind returns kind of name like "self-intersection", see [Kind.String].
+func ParseKind(name string) (Kind, error) {
+	for kind, kindName := range kindNames {
+		if kindName == name {
+			return kind, nil
+		}
+	}
+	return 0, fmt.Errorf("pinpoint/validate: unknown kind %q", name)
+}
+
+// Issue is a problem of a ring.
+type Issue struct {
+	// Location is location's index.
+	Location int    `json:"location"`
+	Name     string `json:"name"`
+	Polygon  int    `json:"polygon"`
+	// Ring is 0 for polygon's exterior ring and i for its (i-1)th hole.
+	Ring int  `json:"ring"`
+	Kind Kind `json:"kind"`
+	// At is where a self-intersection is, nil for other kinds.
+	At *pb.Point `json:"at,omitempty"`
+	// Repaired is true if [Repair] fixed the issue.
+	Repaired bool `json:"repaired,omitempty"`
+}
+
+func (i *Issue) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "location %d %q polygon %d ring %d: %v", i.Location, i.Name, i.Polygon, i.Ring, i.Kind)
+	if i.At != nil {
+		fmt.Fprintf(b, " at %v,%v", i.At.Lng, i.At.Lat)
+	}
+	if i.Repaired {
+		b.WriteString(", repaired")
+	}
+	return b.String()
+}
+
+// Check returns issues of every location's rings.
+func Check(input *pb.Locations, opts ...OptionFunc) []*Issue {
+	opt := newOption(opts...)
+	issues := make([]*Issue, 0)
+	for i, location := range input.Locations {
+		issues = append(issues, checkLocation(i, location, opt)...)
+	}
+	return issues
+}
+
+// CheckLocation returns issues of location's rings, index is reported as
+// [Issue.Location].
+func CheckLocation(index int, location *pb.Location, opts ...OptionFunc) []*Issue {
+	return checkLocation(index, location, newOption(opts...))
+}
+
+func checkLocation(index int, location *pb.Location, opt *Option) []*Issue {
+	issues := make([]*Issue, 0)
+	for i, polygon := range location.Polygons {
+		rings := append([]*pb.Polygon{{Points: polygon.Points}}, polygon.Holes...)
+		for j, ring := range rings {
+			for _, found := range checkRing(ring.Points, j > 0, opt) {
+				found.Location = index
+				found.Name = location.Name
+				found.Polygon = i
+				found.Ring = j
+				issues = append(issues, found)
+			}
+		}
+	}
+	return issues
+}
+
+type point [2]float64
+
+func toPoint(p *pb.Point) point {
+	return point{float64(p.Lng), float64(p.Lat)}
+}
+
+func (p point) toPB() *pb.Point {
+	return &pb.Point{Lng: float32(p[0]), Lat: float32(p[1])}
+}
+
+// openRing returns ring's points without closing point and consecutive
+// duplicates, and if ring is closed.
+func openRing(points []*pb.Point) ([]point, bool) {
+	closed := len(points) > 1 && toPoint(points[0]) == toPoint(points[len(points)-1])
+	ring := make([]point, 0, len(points))
+	for _, p := range points {
+		if q := toPoint(p); len(ring) == 0 || ring[len(ring)-1] != q {
+			ring = append(ring, q)
+		}
+	}
+	for len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+	return ring, closed
+}
+
+// checkRing returns issues of a ring without its position.
+func checkRing(points []*pb.Point, hole bool, opt *Option) []*Issue {
+	issues := make([]*Issue, 0)
+	report := func(kind Kind, at *pb.Point) {
+		if opt.has(kind) {
+			issues = append(issues, &Issue{Kind: kind, At: at})
+		}
+	}
+	ring, closed := openRing(points)
+	if !closed && len(points) > 0 {
+		report(Unclosed, nil)
+	}
+	// an unclosed ring is checked as if it's closed
+	positions := len(points)
+	if !closed {
+		positions++
+	}
+	if positions < 4 || len(ring) < 3 {
+		report(TooFewPoints, nil)
+		return issues
+	}
+	area := signedArea(ring)
+	if area == 0 {
+		report(ZeroArea, nil)
+		return issues
+	}
+	if hole != (area < 0) {
+		report(Winding, nil)
+	}
+	if opt.has(SelfIntersection) {
+		if i, ok := findSpike(ring); ok {
+			report(SelfIntersection, ring[i].toPB())
+		} else if x, ok := findIntersection(ring); ok {
+			report(SelfIntersection, x.at.toPB())
+		}
+	}
+	return issues
+}
+
+// signedArea returns twice ring's area, positive if counterclockwise.
+func signedArea(ring []point) float64 {
+	area := 0.0
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		area += p[0]*q[1] - q[0]*p[1]
+	}
+	return area
+}
+
+// orient is positive if c is left of a→b, negative if right and 0 if on the
+// line. float64 has twice float32's precision, so the sign is exact for
+// nearby float32 coordinates.
+func orient(a, b, c point) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// onSegment reports if p on line a→b is between a and b.
+func onSegment(a, b, p point) bool {
+	return min(a[0], b[0]) <= p[0] && p[0] <= max(a[0], b[0]) &&
+		min(a[1], b[1]) <= p[1] && p[1] <= max(a[1], b[1])
+}
+
+func min(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// intersect returns a point where segments a→b and c→d cross or touch.
+func intersect(a, b, c, d point) (point, bool) {
+	o1, o2 := orient(a, b, c), orient(a, b, d)
+	o3, o4 := orient(c, d, a), orient(c, d, b)
+	if (o1 > 0 && o2 < 0 || o1 < 0 && o2 > 0) && (o3 > 0 && o4 < 0 || o3 < 0 && o4 > 0) {
+		t := o3 / (o3 - o4)
+		return point{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}, true
+	}
+	switch {
+	case o1 == 0 && onSegment(a, b, c):
+		return c, true
+	case o2 == 0 && onSegment(a, b, d):
+		return d, true
+	case o3 == 0 && onSegment(c, d, a):
+		return a, true
+	case o4 == 0 && onSegment(c, d, b):
+		return b, true
+	}
+	return point{}, false
+}
+
+// intersection is where segment i and j of a ring cross or touch, segment i
+// is ring[i]→ring[i+1] and i < j.
+type intersection struct {
+	i, j int
+	at   point
+}
+
+// findSpike returns index of a vertex where its adjacent segments go back
+// on each other, of an open ring without consecutive duplicates.
+func findSpike(ring []point) (int, bool) {
+	n := len(ring)
+	for i := range ring {
+		a, b, c := ring[(i+n-1)%n], ring[i], ring[(i+1)%n]
+		if orient(a, b, c) == 0 && (a[0]-b[0])*(c[0]-b[0])+(a[1]-b[1])*(c[1]-b[1]) > 0 {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// findIntersection returns an intersection of non-adjacent segments of an
+// open ring without consecutive duplicates.
+func findIntersection(ring []point) (intersection, bool) {
+	n := len(ring)
+	// sort and sweep segments by their min x
+	segments := make([]int, n)
+	for i := range segments {
+		segments[i] = i
+	}
+	minX := func(i int) float64 { return min(ring[i][0], ring[(i+1)%n][0]) }
+	sort.Slice(segments, func(a, b int) bool { return minX(segments[a]) < minX(segments[b]) })
+	for k, i := range segments {
+		a, b := ring[i], ring[(i+1)%n]
+		maxX := max(a[0], b[0])
+		minY, maxY := min(a[1], b[1]), max(a[1], b[1])
+		for _, j := range segments[k+1:] {
+			c, d := ring[j], ring[(j+1)%n]
+			if minX(j) > maxX {
+				break
+			}
+			if min(c[1], d[1]) > maxY || max(c[1], d[1]) < minY {
+				continue
+			}
+			lo, hi := i, j
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if hi-lo == 1 || lo == 0 && hi == n-1 {
+				continue
+			}
+			if at, ok := intersect(a, b, c, d); ok {
+				return intersection{i: lo, j: hi, at: at}, true
+			}
+		}
+	}
+	return intersection{}, false
+}
@@ -0,0 +1,126 @@
+package validate_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	usstates "github.com/deslittle/pinpoint-us-states"
+	"github.com/deslittle/pinpoint/pb"
+	"github.com/deslittle/pinpoint/validate"
+	"google.golang.org/protobuf/proto"
+)
+
+func ring(coordinates ...float32) []*pb.Point {
+	points := make([]*pb.Point, 0, len(coordinates)/2)
+	for i := 0; i+1 < len(coordinates); i += 2 {
+		points = append(points, &pb.Point{Lng: coordinates[i], Lat: coordinates[i+1]})
+	}
+	return points
+}
+
+func location(exterior []*pb.Point, holes ...[]*pb.Point) *pb.Location {
+	polygon := &pb.Polygon{Points: exterior}
+	for _, hole := range holes {
+		polygon.Holes = append(polygon.Holes, &pb.Polygon{Points: hole})
+	}
+	return &pb.Location{Name: "a", Polygons: []*pb.Polygon{polygon}}
+}
+
+func issuesString(issues []*validate.Issue) string {
+	ret := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		ret = append(ret, issue.String())
+	}
+	return strings.Join(ret, "\n")
+}
+
+var square = ring(0, 0, 1, 0, 1, 1, 0, 1, 0, 0)
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  *pb.Location
+		expect string
+	}{
+		{"valid", location(square, ring(0.2, 0.2, 0.2, 0.8, 0.8, 0.8, 0.2, 0.2)), ""},
+		{"unclosed", location(ring(0, 0, 1, 0, 1, 1, 0, 1)), `location 0 "a" polygon 0 ring 0: unclosed`},
+		{"too few points", location(ring(0, 0, 1, 0, 0, 0)), `location 0 "a" polygon 0 ring 0: too-few-points`},
+		{"zero area", location(ring(0, 0, 1, 0, 2, 0, 0, 0)), `location 0 "a" polygon 0 ring 0: zero-area`},
+		{"clockwise exterior", location(ring(0, 0, 0, 1, 1, 1, 1, 0, 0, 0)), `location 0 "a" polygon 0 ring 0: winding`},
+		{"counterclockwise hole", location(square, ring(0.2, 0.2, 0.8, 0.2, 0.8, 0.8, 0.2, 0.2)), `location 0 "a" polygon 0 ring 1: winding`},
+		{
+			"bow-tie", location(ring(0, 0, 2, 2, 2, 0, 0, 1, 0, 0)),
+			"location 0 \"a\" polygon 0 ring 0: winding\n" +
+				`location 0 "a" polygon 0 ring 0: self-intersection at 0.6666667,0.6666667`,
+		},
+		{"spike", location(ring(0, 0, 1, 0, 1, 1, 1, 2, 1, 1, 0, 1, 0, 0)), `location 0 "a" polygon 0 ring 0: self-intersection at 1,2`},
+	}
+	for _, c := range cases {
+		if got := issuesString(validate.CheckLocation(0, c.input)); got != c.expect {
+			t.Errorf("%v: got\n%v\nwant\n%v", c.name, got, c.expect)
+		}
+	}
+
+	input := location(ring(0, 0, 0, 1, 1, 1, 1, 0))
+	if got := issuesString(validate.CheckLocation(0, input, validate.SetKinds(validate.Winding))); got != `location 0 "a" polygon 0 ring 0: winding` {
+		t.Errorf("SetKinds: got %v", got)
+	}
+}
+
+func TestRepairLocation(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  *pb.Location
+		expect [][]int // every polygon's rings' number of points
+	}{
+		{"valid", location(square), [][]int{{5}}},
+		{"unclosed clockwise", location(ring(0, 0, 0, 1, 1, 1, 1, 0)), [][]int{{5}}},
+		{"degenerate hole", location(square, ring(0.2, 0.2, 0.3, 0.3, 0.2, 0.2)), [][]int{{5}}},
+		{"degenerate exterior", location(ring(0, 0, 1, 0, 2, 0, 0, 0), ring(0.2, 0.2, 0.2, 0.8, 0.8, 0.8, 0.2, 0.2)), [][]int{}},
+		{"bow-tie", location(ring(0, 0, 2, 2, 2, 0, 0, 1, 0, 0)), [][]int{{4}, {4}}},
+		{"spike", location(ring(0, 0, 1, 0, 1, 1, 1, 2, 1, 1, 0, 1, 0, 0)), [][]int{{5}}},
+		// exterior touching itself at (2, 4) around a triangle, which is a hole
+		{"keyhole", location(ring(0, 0, 4, 0, 4, 4, 2, 4, 3, 2, 1, 2, 2, 4, 0, 4, 0, 0)), [][]int{{6, 4}}},
+	}
+	for _, c := range cases {
+		validate.RepairLocation(0, c.input)
+		got := make([][]int, 0)
+		for _, polygon := range c.input.Polygons {
+			rings := []int{len(polygon.Points)}
+			for _, hole := range polygon.Holes {
+				rings = append(rings, len(hole.Points))
+			}
+			got = append(got, rings)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.expect) {
+			t.Errorf("%v: got rings %v, want %v", c.name, got, c.expect)
+		}
+		if issues := validate.CheckLocation(0, c.input); len(issues) > 0 {
+			t.Errorf("%v: got issues after repair\n%v", c.name, issuesString(issues))
+		}
+	}
+}
+
+func TestRepairUSStates(t *testing.T) {
+	input := &pb.Locations{}
+	if err := proto.Unmarshal(usstates.LiteData, input); err != nil {
+		t.Fatal(err)
+	}
+	issues := validate.Check(input)
+	kinds := map[validate.Kind]int{}
+	for _, issue := range issues {
+		kinds[issue.Kind]++
+	}
+	// Census rings are clockwise
+	if kinds[validate.Winding] == 0 {
+		t.Errorf("got issues %v, want winding", kinds)
+	}
+	repaired := validate.Repair(input)
+	if len(repaired) != len(issues) || len(repaired) > 0 && !repaired[0].Repaired {
+		t.Errorf("got %d repaired issues, want %d", len(repaired), len(issues))
+	}
+	if issues := validate.Check(input); len(issues) > 0 {
+		t.Errorf("got %d issues after repair, first %v", len(issues), issues[0])
+	}
+}